@@ -0,0 +1,260 @@
+package bot
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Moderation action kinds
+const (
+	actionMute     = "mute"
+	actionUnmute   = "unmute"
+	actionBan      = "ban"
+	actionUnban    = "unban"
+	actionSpamBan  = "spam_ban"
+	actionKick     = "kick"
+	actionPin      = "pin"
+	actionUnpin    = "unpin"
+	actionUnpinAll = "unpin_all"
+)
+
+// Decisions taken with admin chat buttons
+const (
+	actionApprove = "approve"
+	actionReject  = "reject"
+	actionBlock   = "block"
+	actionUnblock = "unblock"
+	actionDelete  = "delete"
+	actionDismiss = "dismiss"
+)
+
+// ModAction is a moderation action taken against a user
+type ModAction struct {
+	ID       int       `json:"id"`
+	Kind     string    `json:"kind"`
+	ChatID   int64     `json:"chat_id"`
+	UserID   int64     `json:"user_id"`
+	UserName string    `json:"user_name"`
+	ByID     int64     `json:"by_id"`
+	By       string    `json:"by"`
+	Reason   string    `json:"reason,omitempty"`
+	At       time.Time `json:"at"`
+	Until    time.Time `json:"until,omitzero"` // zero for permanent actions
+	Lifted   bool      `json:"lifted,omitempty"`
+	Undone   bool      `json:"undone,omitempty"`
+}
+
+// maxActions caps the stored action history
+const maxActions = 5000
+
+// ActionLog persists moderation actions and tracks the temporary ones until they expire
+type ActionLog struct {
+	mu      sync.Mutex
+	NextID  int         `json:"next_id"`
+	Actions []ModAction `json:"actions"`
+	file    string
+}
+
+// NewActionLog creates an action log backed by a JSON file
+func NewActionLog(file string) *ActionLog {
+	_ = os.MkdirAll("data", 0755)
+	al := &ActionLog{NextID: 1, file: file}
+	al.load()
+	return al
+}
+
+func (al *ActionLog) load() {
+	data, err := os.ReadFile(al.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, al)
+}
+
+func (al *ActionLog) save() {
+	data, err := json.MarshalIndent(al, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error("action log marshal")
+		return
+	}
+	if err := os.WriteFile(al.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("action log write")
+	}
+}
+
+// Record stores an action and returns it with its ID; beyond the limit the oldest finished actions are dropped
+func (al *ActionLog) Record(a ModAction) ModAction {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	a.ID = al.NextID
+	al.NextID++
+	if a.At.IsZero() {
+		a.At = time.Now()
+	}
+	al.Actions = append(al.Actions, a)
+	if extra := len(al.Actions) - maxActions; extra > 0 {
+		al.Actions = slices.DeleteFunc(al.Actions, func(old ModAction) bool {
+			if extra > 0 && !old.pending() {
+				extra--
+				return true
+			}
+			return false
+		})
+	}
+	al.save()
+	return a
+}
+
+// pending reports whether a temporary action still waits for its expiry
+func (a ModAction) pending() bool {
+	return !a.Until.IsZero() && !a.Lifted
+}
+
+// Expired returns temporary actions whose time is up and marks them lifted
+func (al *ActionLog) Expired(now time.Time) []ModAction {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	var expired []ModAction
+	for i, a := range al.Actions {
+		if a.pending() && now.After(a.Until) {
+			al.Actions[i].Lifted = true
+			expired = append(expired, al.Actions[i])
+		}
+	}
+	if len(expired) > 0 {
+		al.save()
+	}
+	return expired
+}
+
+// Lift marks the active actions of a kind against a user in a chat as lifted early
+func (al *ActionLog) Lift(kind string, chatID, userID int64) bool {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	lifted := false
+	for i, a := range al.Actions {
+		if a.Kind == kind && a.ChatID == chatID && a.UserID == userID && !a.Lifted {
+			al.Actions[i].Lifted = true
+			lifted = true
+		}
+	}
+	if lifted {
+		al.save()
+	}
+	return lifted
+}
+
+// LastBy returns the latest action of the given kinds an admin took in a chat that has not been undone yet
+func (al *ActionLog) LastBy(chatID, byID int64, kinds ...string) (ModAction, bool) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	for _, a := range slices.Backward(al.Actions) {
+		if a.ChatID == chatID && a.ByID == byID && !a.Undone && slices.Contains(kinds, a.Kind) {
+			return a, true
+		}
+	}
+	return ModAction{}, false
+}
+
+// Before returns the latest action of a kind against the same user in the same chat recorded before the given one
+func (al *ActionLog) Before(a ModAction, kind string) (ModAction, bool) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	for _, prev := range slices.Backward(al.Actions) {
+		if prev.ID < a.ID && prev.Kind == kind && prev.ChatID == a.ChatID && prev.UserID == a.UserID {
+			return prev, true
+		}
+	}
+	return ModAction{}, false
+}
+
+// ForUser returns the actions taken against a user, the oldest first
+func (al *ActionLog) ForUser(userID int64) []ModAction {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	var result []ModAction
+	for _, a := range al.Actions {
+		if a.UserID == userID {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// Get returns the action with the given ID
+func (al *ActionLog) Get(id int) (ModAction, bool) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	for _, a := range al.Actions {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return ModAction{}, false
+}
+
+// Active returns the latest action of the given kinds per user that is still in force, newest first; chatID 0 covers all chats
+func (al *ActionLog) Active(chatID int64, now time.Time, kinds ...string) []ModAction {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	type key struct{ chatID, userID int64 }
+	seen := make(map[key]bool)
+	var result []ModAction
+	for _, a := range slices.Backward(al.Actions) {
+		if chatID != 0 && a.ChatID != chatID || !slices.Contains(kinds, a.Kind) {
+			continue
+		}
+		k := key{a.ChatID, a.UserID}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		if !a.Lifted && !a.Undone && (a.Until.IsZero() || now.Before(a.Until)) {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// MarkUndone flags an action as reversed so it neither expires nor gets undone twice
+func (al *ActionLog) MarkUndone(id int) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	for i, a := range al.Actions {
+		if a.ID == id {
+			al.Actions[i].Undone, al.Actions[i].Lifted = true, true
+			al.save()
+			return
+		}
+	}
+}
+
+// parseModDuration parses durations like 30m, 2h, 7d or 1w
+func parseModDuration(s string) (time.Duration, bool) {
+	s = strings.ToLower(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			return time.Duration(v) * unit, err == nil && v > 0
+		}
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil && d > 0
+}
+
+// splitDuration takes an optional leading duration off a command payload; Telegram treats restrictions over 366 days as permanent
+func splitDuration(payload string) (time.Duration, string) {
+	first, rest, _ := strings.Cut(strings.TrimSpace(payload), " ")
+	d, ok := parseModDuration(first)
+	if !ok {
+		return 0, strings.TrimSpace(payload)
+	}
+	return min(d, 366*24*time.Hour), strings.TrimSpace(rest)
+}
@@ -1,9 +1,9 @@
 package bot
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -21,28 +21,59 @@ type AdminHandler struct {
 	bot             *tb.Bot
 	blacklist       core.BlacklistInterface
 	adminChatID     int64
-	violations      map[int64]int
-	violationsMu    sync.RWMutex
-	violationsFile  string
+	strikes         *StrikeStore
+	actions         *ActionLog
 	groupIDs        map[int64]struct{}
 	groupMu         sync.RWMutex
 	userLanguages   map[int64]i18n.Lang
 	userLanguagesMu sync.RWMutex
+	settings        *SettingsStore
+	domains         *DomainStore
+	namePatterns    *NamePatternStore
+	spamModel       *SpamModel
+	remote          *RemoteBlacklist
+	roles           *RoleStore
+	admins          adminCache
+	digest          *DigestStore
+	confirms        confirmations
+	reports         reports
+	reporters       *floodCounter
+	tasks           *TaskQueue
+	users           *UserIndex
+	trusted         *TrustStore
+	state           core.UserState
 }
 
-// NewAdminHandler creates a new admin handler with persisted violations
-func NewAdminHandler(bot *tb.Bot, blacklist core.BlacklistInterface, adminChatID int64, violations map[int64]int) *AdminHandler {
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(bot *tb.Bot, blacklist core.BlacklistInterface, adminChatID int64, strikes *StrikeStore, actions *ActionLog, settings *SettingsStore, domains *DomainStore, namePatterns *NamePatternStore, spamModel *SpamModel, remote *RemoteBlacklist, roles *RoleStore, digest *DigestStore, tasks *TaskQueue, users *UserIndex, trusted *TrustStore, state core.UserState) *AdminHandler {
 	_ = os.MkdirAll("data", 0755)
 	ah := &AdminHandler{
-		bot:            bot,
-		blacklist:      blacklist,
-		adminChatID:    adminChatID,
-		violations:     violations,
-		violationsFile: "data/violations.json",
-		groupIDs:       make(map[int64]struct{}),
-		userLanguages:  make(map[int64]i18n.Lang),
-	}
-	ah.loadViolations()
+		bot:           bot,
+		blacklist:     blacklist,
+		adminChatID:   adminChatID,
+		strikes:       strikes,
+		actions:       actions,
+		groupIDs:      make(map[int64]struct{}),
+		userLanguages: make(map[int64]i18n.Lang),
+		settings:      settings,
+		domains:       domains,
+		namePatterns:  namePatterns,
+		spamModel:     spamModel,
+		remote:        remote,
+		roles:         roles,
+		digest:        digest,
+		admins:        adminCache{chats: make(map[int64]chatAdmins)},
+		confirms:      confirmations{pending: make(map[int]pendingConfirm)},
+		reports:       reports{pending: make(map[int]pendingReport)},
+		reporters:     newFloodCounter(),
+		tasks:         tasks,
+		users:         users,
+		trusted:       trusted,
+		state:         state,
+	}
+	tasks.Handle(taskDelete, ah.deleteMessage)
+	tasks.Handle(taskExpireActions, func(Task) { ah.ExpireActions(time.Now()) })
+	tasks.Handle(taskSlowModeOff, ah.slowModeOff)
 	return ah
 }
 
@@ -55,6 +86,16 @@ func (ah *AdminHandler) LogToAdmin(message string) {
 
 // IsAdmin checks if a user is admin in chat
 func (ah *AdminHandler) IsAdmin(chat *tb.Chat, user *tb.User) bool {
+	// group and channel IDs are negative, private chats have no admins to cache
+	if chat.ID < 0 {
+		if user.ID == anonymousAdminID {
+			return true
+		}
+		if ids, ok := ah.cachedAdmins(chat.ID); ok {
+			_, admin := ids[user.ID]
+			return admin
+		}
+	}
 	member, err := ah.bot.ChatMemberOf(chat, user)
 	if err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": chat.ID, "user_id": user.ID}).Error("Failed to check member rights")
@@ -82,17 +123,22 @@ func (ah *AdminHandler) getLangForUser(user *tb.User) i18n.Lang {
 
 // DeleteAfter deletes message after delay
 func (ah *AdminHandler) DeleteAfter(m *tb.Message, d time.Duration) {
-	if m == nil {
+	if m == nil || m.Chat == nil {
 		return
 	}
-	go func() {
-		time.Sleep(d)
-		_ = ah.bot.Delete(m)
-	}()
+	ah.tasks.Add(Task{Kind: taskDelete, At: time.Now().Add(d), ChatID: m.Chat.ID, MessageID: m.ID})
 }
 
-// BanUser bans a user in chat
+// deleteMessage deletes the message of a delete task
+func (ah *AdminHandler) deleteMessage(t Task) {
+	if err := ah.bot.Delete(&tb.StoredMessage{MessageID: strconv.Itoa(t.MessageID), ChatID: t.ChatID}); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": t.ChatID, "message_id": t.MessageID}).Debug("Failed to delete message on schedule")
+	}
+}
+
+// BanUser bans a user in chat and takes away their trust
 func (ah *AdminHandler) BanUser(chat *tb.Chat, user *tb.User) error {
+	ah.trusted.Untrust(user.ID)
 	return ah.bot.Ban(chat, &tb.ChatMember{User: user, Rights: tb.Rights{}})
 }
 
@@ -106,19 +152,72 @@ func (ah *AdminHandler) HandleBan(c tb.Context) error {
 		ah.DeleteAfter(msg, 10*time.Second)
 		return nil
 	}
-	args := strings.Fields(c.Message().Text)
-	if len(args) < 2 {
+	level, category, rest, ok := parseBanFlags(c.Message().Payload)
+	if !ok {
+		msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Admin.BanInvalidLevel, strings.Join(core.BlacklistLevels, ", ")))
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	words := strings.Fields(rest)
+	if len(words) == 0 {
 		msg, _ := ah.bot.Send(c.Chat(), msgs.Admin.BanUsage)
 		ah.DeleteAfter(msg, 10*time.Second)
 		return nil
 	}
-	ah.blacklist.AddPhrase(args[1:])
-	msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Admin.BanAdded, strings.Join(args[1:], " ")))
+	entry := strings.Join(words, " ")
+	if pattern, isPattern := strings.CutPrefix(rest, "re:"); isPattern {
+		if err := ah.blacklist.AddPattern(pattern, level, category); err != nil {
+			msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Admin.BanInvalidPattern, err))
+			ah.DeleteAfter(msg, 10*time.Second)
+			return nil
+		}
+		entry = "re:" + pattern
+	} else {
+		ah.blacklist.AddPhrase(words, level, category)
+	}
+	if level == "" {
+		level = core.LevelDeleteWarn
+	}
+	tags := level
+	if category != "" {
+		tags += ", #" + category
+	}
+	msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Admin.BanAdded, entry)+fmt.Sprintf(" [%s]", tags))
 	ah.DeleteAfter(msg, 10*time.Second)
-	ah.LogToAdmin(fmt.Sprintf("🚫 Добавлено запрещённое слово\n\nАдмин: %s\nЗапрещённые слова: `%s`", ah.GetUserDisplayName(c.Sender()), strings.Join(args[1:], " ")))
+	ah.LogToAdmin(fmt.Sprintf("🚫 Добавлено запрещённое слово\n\nАдмин: %s\nЗапрещённые слова: `%s`\nУровень: %s", ah.GetUserDisplayName(c.Sender()), entry, tags))
 	return nil
 }
 
+// parseBanLevel extracts an optional --level=<level> flag from the /banword payload
+func parseBanLevel(payload string) (level, rest string, ok bool) {
+	level, _, rest, ok = parseBanFlags(payload)
+	return level, rest, ok
+}
+
+// parseBanFlags extracts the optional --level=<level> and --cat=<category> flags in any order
+func parseBanFlags(payload string) (level, category, rest string, ok bool) {
+	rest = strings.TrimSpace(payload)
+	for {
+		flag, after, _ := strings.Cut(rest, " ")
+		if value, isLevel := strings.CutPrefix(flag, "--level="); isLevel {
+			level = strings.ToLower(value)
+			if !slices.Contains(core.BlacklistLevels, level) {
+				return "", "", "", false
+			}
+		} else if value, isCategory := strings.CutPrefix(flag, "--cat="); isCategory {
+			category = normalizeCategory(value)
+		} else {
+			return level, category, rest, true
+		}
+		rest = strings.TrimSpace(after)
+	}
+}
+
+// normalizeCategory lowercases a category and drops a leading #
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(category), "#"))
+}
+
 // HandleUnban removes a phrase
 func (ah *AdminHandler) HandleUnban(c tb.Context) error {
 	lang := ah.getLangForUser(c.Sender())
@@ -135,41 +234,24 @@ func (ah *AdminHandler) HandleUnban(c tb.Context) error {
 		ah.DeleteAfter(msg, 10*time.Second)
 		return nil
 	}
-	ok := ah.blacklist.RemovePhrase(args[1:])
+	entry := strings.Join(args[1:], " ")
+	var ok bool
+	if pattern, isPattern := strings.CutPrefix(strings.TrimSpace(c.Message().Payload), "re:"); isPattern {
+		ok = ah.blacklist.RemovePattern(pattern)
+		entry = "re:" + pattern
+	} else {
+		ok = ah.blacklist.RemovePhrase(args[1:])
+	}
 	text := msgs.Admin.UnbanNotFound
 	if ok {
-		text = fmt.Sprintf(msgs.Admin.UnbanRemoved, strings.Join(args[1:], " "))
-		ah.LogToAdmin(fmt.Sprintf("✅ Удалено запрещённое слово\n\nАдмин: %s\nУдалённые слова: `%s`", ah.GetUserDisplayName(c.Sender()), strings.Join(args[1:], " ")))
+		text = fmt.Sprintf(msgs.Admin.UnbanRemoved, entry)
+		ah.LogToAdmin(fmt.Sprintf("✅ Удалено запрещённое слово\n\nАдмин: %s\nУдалённые слова: `%s`", ah.GetUserDisplayName(c.Sender()), entry))
 	}
 	msg, _ := ah.bot.Send(c.Chat(), text)
 	ah.DeleteAfter(msg, 10*time.Second)
 	return nil
 }
 
-// HandleListBan shows the banned list
-func (ah *AdminHandler) HandleListBan(c tb.Context) error {
-	lang := ah.getLangForUser(c.Sender())
-	msgs := i18n.Get().T(lang)
-
-	if c.Message() == nil || c.Sender() == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
-		msg, _ := ah.bot.Send(c.Chat(), msgs.Admin.ListCommandAdminOnly)
-		ah.DeleteAfter(msg, 10*time.Second)
-		return nil
-	}
-	phrases := ah.blacklist.List()
-	if len(phrases) == 0 {
-		_, _ = ah.bot.Send(c.Chat(), msgs.Admin.ListEmpty)
-		return nil
-	}
-	var sb strings.Builder
-	sb.WriteString(msgs.Admin.ListHeader)
-	for i, p := range phrases {
-		sb.WriteString(fmt.Sprintf("%d. `%s`\n", i+1, strings.Join(p, " ")))
-	}
-	_, _ = ah.bot.Send(c.Chat(), sb.String(), tb.ModeMarkdown)
-	return nil
-}
-
 // RegisterGroup remembers group chat for global actions
 func (ah *AdminHandler) RegisterGroup(chat *tb.Chat) {
 	if chat == nil || chat.Type == tb.ChatPrivate {
@@ -213,7 +295,7 @@ func (ah *AdminHandler) HandleSpamBan(c tb.Context) error {
 	lang := ah.getLangForUser(c.Sender())
 	msgs := i18n.Get().T(lang)
 
-	if c.Message() == nil || c.Sender() == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
+	if c.Message() == nil || c.Sender() == nil || !ah.IsModerator(c.Chat(), c.Sender()) {
 		msg, _ := ah.bot.Send(c.Chat(), msgs.Admin.SpambanCommandAdminOnly)
 		ah.DeleteAfter(msg, 10*time.Second)
 		return nil
@@ -229,11 +311,52 @@ func (ah *AdminHandler) HandleSpamBan(c tb.Context) error {
 		ah.DeleteAfter(msg, 10*time.Second)
 		return nil
 	}
-	ah.BanUserEverywhere(target)
-	ah.ClearViolations(target.ID)
-	_, _ = ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Admin.SpambanSuccess, ah.GetUserDisplayName(target)))
-	ah.LogToAdmin(fmt.Sprintf("🔨 Пользователь забанен за спам.\n\nЗабанен: %s\nАдмин: %s", ah.GetUserDisplayName(target), ah.GetUserDisplayName(c.Sender())))
-	return nil
+	prompt := fmt.Sprintf(msgs.Moderation.ConfirmSpamBan, ah.GetUserDisplayName(target))
+	return ah.confirmAction(c, msgs, prompt, func() {
+		if reply := c.Message().ReplyTo; reply != nil {
+			ah.spamModel.Train(messageText(reply), true)
+		}
+		ah.BanUserEverywhere(target)
+		wiped := ah.wipeMessages(target, c.Message().ReplyTo, time.Now().Add(-ah.settings.Get(c.Chat().ID).spamWipePeriod()))
+		ah.strikes.ClearAll(target.ID)
+		ah.actions.Record(ModAction{Kind: actionSpamBan, ChatID: c.Chat().ID, UserID: target.ID, UserName: ah.GetUserDisplayName(target), ByID: c.Sender().ID, By: ah.GetUserDisplayName(c.Sender())})
+		text := fmt.Sprintf(msgs.Admin.SpambanSuccess, ah.GetUserDisplayName(target))
+		if wiped > 0 {
+			text += "\n" + fmt.Sprintf(msgs.Admin.SpambanWiped, wiped)
+		}
+		_, _ = ah.bot.Send(c.Chat(), text)
+		ah.LogToAdmin(fmt.Sprintf("🔨 Пользователь забанен за спам.\n\nЗабанен: %s\nАдмин: %s\nУдалено сообщений: %d", ah.GetUserDisplayName(target), ah.GetUserDisplayName(c.Sender()), wiped))
+	})
+}
+
+// spamWipePeriod returns how far back a spam ban deletes the spammer's messages
+func (cs ChatSettings) spamWipePeriod() time.Duration {
+	if cs.SpamWipeHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(cs.SpamWipeHours) * time.Hour
+}
+
+// wipeMessages deletes the user's messages seen since the given time in every chat, along with the replied one, and returns how many were sent for deletion
+func (ah *AdminHandler) wipeMessages(user *tb.User, reply *tb.Message, since time.Time) int {
+	refs := ah.state.TakeMessages(int(user.ID), since)
+	if reply != nil && reply.Chat != nil && !slices.Contains(refs, core.MessageRef{ChatID: reply.Chat.ID, MessageID: reply.ID}) {
+		refs = append(refs, core.MessageRef{ChatID: reply.Chat.ID, MessageID: reply.ID})
+	}
+	byChat := make(map[int64][]tb.Editable)
+	for _, ref := range refs {
+		byChat[ref.ChatID] = append(byChat[ref.ChatID], &tb.StoredMessage{MessageID: strconv.Itoa(ref.MessageID), ChatID: ref.ChatID})
+	}
+	for chatID, batch := range byChat {
+		for len(batch) > 0 {
+			n := min(len(batch), purgeBatchSize)
+			if err := ah.bot.DeleteMany(batch[:n]); err != nil {
+				logrus.WithError(err).WithFields(logrus.Fields{"chat_id": chatID, "user_id": user.ID}).Warn("Failed to wipe spammer messages")
+			}
+			batch = batch[n:]
+		}
+	}
+	return len(refs)
 }
 
 // resolveTargetUser finds user from reply or argument
@@ -260,52 +383,5 @@ func (ah *AdminHandler) resolveTargetUser(c tb.Context) *tb.User {
 	return nil
 }
 
-// AddViolation increments violation count
-func (ah *AdminHandler) AddViolation(userID int64) {
-	ah.violationsMu.Lock()
-	ah.violations[userID]++
-	ah.violationsMu.Unlock()
-	ah.saveViolations()
-}
-
-// GetViolations returns count
-func (ah *AdminHandler) GetViolations(userID int64) int {
-	ah.violationsMu.RLock()
-	v := ah.violations[userID]
-	ah.violationsMu.RUnlock()
-	return v
-}
-
-// ClearViolations removes record
-func (ah *AdminHandler) ClearViolations(userID int64) {
-	ah.violationsMu.Lock()
-	delete(ah.violations, userID)
-	ah.violationsMu.Unlock()
-	ah.saveViolations()
-}
-
-// saveViolations persists violation count to disk
-func (ah *AdminHandler) saveViolations() {
-	data, err := json.MarshalIndent(ah.violations, "", "  ")
-	if err != nil {
-		return
-	}
-	_ = os.WriteFile(ah.violationsFile, data, 0644)
-}
-
-// loadViolations reads violation count from disk
-func (ah *AdminHandler) loadViolations() {
-	data, err := os.ReadFile(ah.violationsFile)
-	if err != nil {
-		return
-	}
-	ah.violationsMu.Lock()
-	_ = json.Unmarshal(data, &ah.violations)
-	if ah.violations == nil {
-		ah.violations = make(map[int64]int)
-	}
-	ah.violationsMu.Unlock()
-}
-
 // Bot returns bot instance
 func (ah *AdminHandler) Bot() *tb.Bot { return ah.bot }
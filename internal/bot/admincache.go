@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+const (
+	// AdminRefreshPeriod is how often the cached admin lists are reloaded
+	AdminRefreshPeriod = 10 * time.Minute
+	// adminCacheTTL is how long a list is trusted when the scheduled refresh is late
+	adminCacheTTL = 15 * time.Minute
+)
+
+// anonymousAdminID is @GroupAnonymousBot, the sender Telegram shows for admins posting on behalf of the group
+const anonymousAdminID = 1087968824
+
+// chatAdmins is the admin list and the linked channel of a chat as last fetched
+type chatAdmins struct {
+	ids     map[int64]struct{}
+	linked  int64
+	fetched time.Time
+}
+
+// adminCache keeps the admin lists of group chats so admin checks don't call Telegram each time
+type adminCache struct {
+	mu    sync.RWMutex
+	chats map[int64]chatAdmins
+}
+
+func (ac *adminCache) get(chatID int64, now time.Time) (map[int64]struct{}, bool) {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	a, ok := ac.chats[chatID]
+	if !ok || now.Sub(a.fetched) > adminCacheTTL {
+		return nil, false
+	}
+	return a.ids, true
+}
+
+func (ac *adminCache) put(chatID int64, ids map[int64]struct{}, linked int64, now time.Time) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	ac.chats[chatID] = chatAdmins{ids: ids, linked: linked, fetched: now}
+}
+
+// linked returns the cached linked channel of a chat, 0 when it has none or is not cached
+func (ac *adminCache) linked(chatID int64) int64 {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	return ac.chats[chatID].linked
+}
+
+// update adds or drops one user in a cached list, leaving chats not cached yet alone
+func (ac *adminCache) update(chatID, userID int64, admin bool) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	a, ok := ac.chats[chatID]
+	if !ok {
+		return
+	}
+	ids := make(map[int64]struct{}, len(a.ids)+1)
+	for id := range a.ids {
+		ids[id] = struct{}{}
+	}
+	if admin {
+		ids[userID] = struct{}{}
+	} else {
+		delete(ids, userID)
+	}
+	ac.chats[chatID] = chatAdmins{ids: ids, linked: a.linked, fetched: a.fetched}
+}
+
+func (ac *adminCache) chatIDs() []int64 {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	ids := make([]int64, 0, len(ac.chats))
+	for id := range ac.chats {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// loadAdmins fetches the admin list and the linked channel of a chat and caches them
+func (ah *AdminHandler) loadAdmins(chatID int64, now time.Time) (map[int64]struct{}, error) {
+	members, err := ah.bot.AdminsOf(&tb.Chat{ID: chatID})
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[int64]struct{}, len(members))
+	for _, m := range members {
+		if m.User != nil {
+			ids[m.User.ID] = struct{}{}
+		}
+	}
+	var linked int64
+	if chat, err := ah.bot.ChatByID(chatID); err == nil {
+		linked = chat.LinkedChatID
+	} else {
+		logrus.WithError(err).WithField("chat_id", chatID).Debug("Failed to load linked channel")
+	}
+	ah.admins.put(chatID, ids, linked, now)
+	return ids, nil
+}
+
+// cachedAdmins returns the admin list of a group chat, loading it when missing or stale
+func (ah *AdminHandler) cachedAdmins(chatID int64) (map[int64]struct{}, bool) {
+	now := time.Now()
+	if ids, ok := ah.admins.get(chatID, now); ok {
+		return ids, true
+	}
+	ids, err := ah.loadAdmins(chatID, now)
+	if err != nil {
+		logrus.WithError(err).WithField("chat_id", chatID).Warn("Failed to load chat admins")
+		return nil, false
+	}
+	return ids, true
+}
+
+// IsChatPost reports whether a group message was posted on behalf of the group by an anonymous admin or comes from its linked channel
+func (ah *AdminHandler) IsChatPost(chat *tb.Chat, m *tb.Message) bool {
+	if m.SenderChat == nil {
+		return false
+	}
+	if m.SenderChat.ID == chat.ID || m.AutomaticForward {
+		return true
+	}
+	if _, ok := ah.cachedAdmins(chat.ID); !ok {
+		return false
+	}
+	linked := ah.admins.linked(chat.ID)
+	return linked != 0 && m.SenderChat.ID == linked
+}
+
+// RefreshAdmins reloads the admin lists of every cached chat
+func (ah *AdminHandler) RefreshAdmins(now time.Time) {
+	for _, chatID := range ah.admins.chatIDs() {
+		if _, err := ah.loadAdmins(chatID, now); err != nil {
+			logrus.WithError(err).WithField("chat_id", chatID).Warn("Failed to refresh chat admins")
+		}
+	}
+}
+
+// HandleChatMember keeps the cached admin list in step with promotions and demotions
+func (ah *AdminHandler) HandleChatMember(c tb.Context) error {
+	upd := c.ChatMember()
+	if upd == nil || upd.Chat == nil || upd.NewChatMember == nil || upd.NewChatMember.User == nil {
+		return nil
+	}
+	role := upd.NewChatMember.Role
+	ah.admins.update(upd.Chat.ID, upd.NewChatMember.User.ID, role == tb.Administrator || role == tb.Creator)
+	return nil
+}
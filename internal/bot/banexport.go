@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"capybot/internal/core"
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// exportBlacklist renders entries as JSON readable by /importbanwords or as CSV
+func exportBlacklist(entries []core.BlacklistEntry, format string) ([]byte, error) {
+	for i := range entries {
+		entries[i].Level = entries[i].Severity()
+	}
+	if format != "csv" {
+		return json.MarshalIndent(struct {
+			Entries []core.BlacklistEntry `json:"entries"`
+		}{entries}, "", "  ")
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"mode", "value", "level", "category"})
+	for _, e := range entries {
+		value := strings.Join(e.Words, " ")
+		if e.Mode == core.MatchRegex {
+			value = e.Pattern
+		}
+		_ = w.Write([]string{e.Mode, value, e.Level, e.Category})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// HandleExportBan sends the blacklist to the admin chat as a JSON or CSV document
+func (ah *AdminHandler) HandleExportBan(c tb.Context) error {
+	lang := ah.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if c.Message() == nil || c.Sender() == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Admin.ListCommandAdminOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	format := strings.ToLower(strings.TrimSpace(c.Message().Payload))
+	if format != "csv" {
+		format = "json"
+	}
+	entries := ah.blacklist.List()
+	data, err := exportBlacklist(entries, format)
+	if err != nil {
+		return err
+	}
+	doc := &tb.Document{
+		File:     tb.FromReader(bytes.NewReader(data)),
+		FileName: fmt.Sprintf("banwords-%s.%s", time.Now().Format("2006-01-02"), format),
+		Caption:  fmt.Sprintf(msgs.Admin.ExportCaption.Form(msgs.Lang(), len(entries)), len(entries)),
+	}
+	if _, err := ah.bot.Send(&tb.Chat{ID: ah.adminChatID}, doc); err != nil {
+		logrus.WithError(err).Error("Failed to send blacklist export")
+		return err
+	}
+	if c.Chat().ID != ah.adminChatID {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Admin.ExportSent)
+		ah.DeleteAfter(msg, 10*time.Second)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"capybot/internal/core"
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// maxImportSize limits the size of an imported banword file
+const maxImportSize = 1 << 20
+
+// parseBanLine turns a /banword style line into a blacklist entry
+func parseBanLine(line string) (core.BlacklistEntry, bool) {
+	level, category, rest, ok := parseBanFlags(line)
+	if !ok || rest == "" {
+		return core.BlacklistEntry{}, false
+	}
+	if pattern, isPattern := strings.CutPrefix(rest, "re:"); isPattern {
+		return core.BlacklistEntry{Pattern: pattern, Mode: core.MatchRegex, Level: level, Category: category}, pattern != ""
+	}
+	words := strings.Fields(rest)
+	mode := core.MatchPhrase
+	if slices.ContainsFunc(words, func(w string) bool { return strings.Contains(w, "*") }) {
+		mode = core.MatchWildcard
+	}
+	return core.BlacklistEntry{Words: words, Mode: mode, Level: level, Category: category}, true
+}
+
+// parseBanImport reads entries from plain lines or JSON: a list of lines, a list of entries or an object with entries
+func parseBanImport(data []byte, isJSON bool) ([]core.BlacklistEntry, []string) {
+	var entries []core.BlacklistEntry
+	var invalid []string
+	addLine := func(line string) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			return
+		}
+		if e, ok := parseBanLine(line); ok {
+			entries = append(entries, e)
+		} else {
+			invalid = append(invalid, line)
+		}
+	}
+	if !isJSON {
+		for _, line := range strings.Split(string(data), "\n") {
+			addLine(line)
+		}
+		return entries, invalid
+	}
+
+	var lines []string
+	if json.Unmarshal(data, &lines) == nil {
+		for _, line := range lines {
+			addLine(line)
+		}
+		return entries, invalid
+	}
+	var list []core.BlacklistEntry
+	if json.Unmarshal(data, &list) != nil {
+		var wrapped struct {
+			Entries []core.BlacklistEntry `json:"entries"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, []string{err.Error()}
+		}
+		list = wrapped.Entries
+	}
+	for _, e := range list {
+		switch {
+		case e.Pattern != "":
+			e.Mode = core.MatchRegex
+		case len(e.Words) > 0:
+			line := strings.Join(e.Words, " ")
+			parsed, _ := parseBanLine(line)
+			e.Mode = parsed.Mode
+		default:
+			continue
+		}
+		e.Category = normalizeCategory(e.Category)
+		if e.Level != "" && !slices.Contains(core.BlacklistLevels, e.Level) {
+			invalid = append(invalid, e.String())
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, invalid
+}
+
+// readImportDocument downloads an attached banword file
+func (ah *AdminHandler) readImportDocument(doc *tb.Document) ([]byte, error) {
+	if doc.FileSize > maxImportSize {
+		return nil, fmt.Errorf("file is larger than %d KB", maxImportSize>>10)
+	}
+	rc, err := ah.bot.File(&doc.File)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, maxImportSize))
+}
+
+// HandleImportBan loads many banned phrases from an attached .txt/.json file or the lines of the message
+func (ah *AdminHandler) HandleImportBan(c tb.Context) error {
+	lang := ah.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	m := c.Message()
+	if m == nil || c.Sender() == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Admin.BanCommandAdminOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+
+	doc := m.Document
+	if doc == nil && m.ReplyTo != nil {
+		doc = m.ReplyTo.Document
+	}
+	var data []byte
+	isJSON := false
+	if doc != nil {
+		ext := strings.ToLower(path.Ext(doc.FileName))
+		if ext != ".txt" && ext != ".json" {
+			msg, _ := ah.bot.Send(c.Chat(), msgs.Admin.ImportUsage)
+			ah.DeleteAfter(msg, 10*time.Second)
+			return nil
+		}
+		var err error
+		if data, err = ah.readImportDocument(doc); err != nil {
+			msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Admin.ImportFailed, err))
+			ah.DeleteAfter(msg, 10*time.Second)
+			return nil
+		}
+		isJSON = ext == ".json"
+	} else if _, body, found := strings.Cut(m.Text, "\n"); found {
+		data = []byte(body)
+	}
+
+	entries, invalid := parseBanImport(data, isJSON)
+	if len(entries) == 0 && len(invalid) == 0 {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Admin.ImportUsage)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	added, duplicates, badPatterns := ah.blacklist.Import(entries)
+	invalid = append(invalid, badPatterns...)
+
+	text := fmt.Sprintf(msgs.Admin.ImportSummary, added, duplicates, len(invalid))
+	if len(invalid) > 0 {
+		shown := invalid[:min(len(invalid), 10)]
+		text += "\n" + strings.Join(shown, "\n")
+	}
+	msg, _ := ah.bot.Send(c.Chat(), text)
+	ah.DeleteAfter(msg, 30*time.Second)
+	ah.LogToAdmin(fmt.Sprintf("📥 Импорт запрещённых слов\n\nАдмин: %s\nДобавлено: %d\nДубликатов: %d\nОшибок: %d", ah.GetUserDisplayName(c.Sender()), added, duplicates, len(invalid)))
+	return nil
+}
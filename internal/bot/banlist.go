@@ -0,0 +1,127 @@
+package bot
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"capybot/internal/core"
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// banListPageSize is the number of blacklist entries shown per page
+const banListPageSize = 25
+
+// filterBanList keeps entries whose category or text contains the query and groups them by category
+func filterBanList(entries []core.BlacklistEntry, query string) []core.BlacklistEntry {
+	query = normalizeCategory(query)
+	if query != "" {
+		entries = slices.DeleteFunc(entries, func(e core.BlacklistEntry) bool {
+			return !strings.Contains(e.Category, query) && !strings.Contains(strings.ToLower(e.String()), query)
+		})
+	}
+	slices.SortStableFunc(entries, func(a, b core.BlacklistEntry) int {
+		return cmp.Compare(a.Category, b.Category)
+	})
+	return entries
+}
+
+// renderBanListPage formats one page of entries with navigation buttons
+func renderBanListPage(msgs *i18n.Messages, entries []core.BlacklistEntry, query string, page int) (string, *tb.ReplyMarkup) {
+	pages := (len(entries) + banListPageSize - 1) / banListPageSize
+	page = max(0, min(page, pages-1))
+	var sb strings.Builder
+	if query != "" {
+		sb.WriteString(fmt.Sprintf(msgs.Admin.ListSearchHeader, query))
+	} else {
+		sb.WriteString(msgs.Admin.ListHeader)
+	}
+	from := page * banListPageSize
+	category := "\x00"
+	for i, e := range entries[from:min(from+banListPageSize, len(entries))] {
+		if e.Category != category {
+			category = e.Category
+			if category == "" {
+				sb.WriteString("\n" + msgs.Admin.ListUncategorized + "\n")
+			} else {
+				sb.WriteString("\n#" + category + "\n")
+			}
+		}
+		lock := ""
+		if e.Remote {
+			lock = " 🔒"
+		}
+		sb.WriteString(fmt.Sprintf("%d. `%s` — %s%s\n", from+i+1, truncateText(e.String(), 100), e.Severity(), lock))
+	}
+	if pages <= 1 {
+		return sb.String(), nil
+	}
+	sb.WriteString("\n" + fmt.Sprintf(msgs.Admin.ListPage, page+1, pages))
+
+	// callback data is limited to 64 bytes, so long queries are cut
+	if len(query) > 48 {
+		query = query[:48]
+	}
+	var row []tb.InlineButton
+	if page > 0 {
+		row = append(row, tb.InlineButton{Unique: "banlist_page", Data: fmt.Sprintf("%d|%s", page-1, query), Text: "◀️"})
+	}
+	if page < pages-1 {
+		row = append(row, tb.InlineButton{Unique: "banlist_page", Data: fmt.Sprintf("%d|%s", page+1, query), Text: "▶️"})
+	}
+	return sb.String(), &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{row}}
+}
+
+// HandleListBan shows the banned list page by page; an argument filters it by category or text
+func (ah *AdminHandler) HandleListBan(c tb.Context) error {
+	lang := ah.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if c.Message() == nil || c.Sender() == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Admin.ListCommandAdminOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	entries := ah.blacklist.List()
+	if len(entries) == 0 {
+		_, _ = ah.bot.Send(c.Chat(), msgs.Admin.ListEmpty)
+		return nil
+	}
+	query := strings.TrimSpace(c.Message().Payload)
+	entries = filterBanList(entries, query)
+	if len(entries) == 0 {
+		msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Admin.ListNoMatches, query))
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	text, markup := renderBanListPage(msgs, entries, query, 0)
+	_, _ = ah.bot.Send(c.Chat(), text, markup, tb.ModeMarkdown)
+	return nil
+}
+
+// HandleListBanPage switches the page of a /listbanword message
+func (ah *AdminHandler) HandleListBanPage(c tb.Context) error {
+	cb := c.Callback()
+	if cb == nil || cb.Message == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
+		return ah.bot.Respond(cb)
+	}
+	pageText, query, _ := strings.Cut(cb.Data, "|")
+	page, err := strconv.Atoi(pageText)
+	if err != nil {
+		return ah.bot.Respond(cb)
+	}
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	entries := filterBanList(ah.blacklist.List(), query)
+	if len(entries) == 0 {
+		_, _ = ah.bot.Edit(cb.Message, msgs.Admin.ListEmpty)
+		return ah.bot.Respond(cb)
+	}
+	text, markup := renderBanListPage(msgs, entries, query, page)
+	_, _ = ah.bot.Edit(cb.Message, text, markup, tb.ModeMarkdown)
+	return ah.bot.Respond(cb)
+}
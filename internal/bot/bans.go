@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// HandleBanUser bans the author of the replied message for good
+func (ah *AdminHandler) HandleBanUser(c tb.Context) error {
+	return ah.banReplied(c, false)
+}
+
+// HandleTempBan bans the author of the replied message for the duration at the start of the payload, like /tban 7d
+func (ah *AdminHandler) HandleTempBan(c tb.Context) error {
+	return ah.banReplied(c, true)
+}
+
+// banReplied bans the author of the replied message, recording the reason and telling them in private where possible
+func (ah *AdminHandler) banReplied(c tb.Context, temporary bool) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if !ah.requireModerator(c, msgs) {
+		return nil
+	}
+	target, payload := ah.commandTarget(c, msgs)
+	if target == nil {
+		return nil
+	}
+	d, reason := splitDuration(payload)
+	if temporary && d == 0 {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Moderation.TempBanUsage)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	if !temporary {
+		d, reason = 0, payload
+	}
+	prompt := fmt.Sprintf(msgs.Moderation.ConfirmBan, ah.GetUserDisplayName(target))
+	return ah.confirmAction(c, msgs, prompt, func() { ah.ban(c.Chat(), c.Sender(), target, d, reason) })
+}
+
+// ban bans the target in the chat for d, or for good when d is zero, and announces it
+func (ah *AdminHandler) ban(chat *tb.Chat, by, target *tb.User, d time.Duration, reason string) {
+	msgs := i18n.Get().T(ah.getLangForUser(by))
+	var until time.Time
+	member := &tb.ChatMember{User: target, Rights: tb.Rights{}}
+	if d > 0 {
+		until = time.Now().Add(d)
+		member.RestrictedUntil = until.Unix()
+	}
+	if err := ah.bot.Ban(chat, member); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": chat.ID, "user_id": target.ID}).Error("Failed to ban user")
+		return
+	}
+	ah.ClearViolations(chat.ID, target.ID)
+	ah.trusted.Untrust(target.ID)
+	ah.actions.Record(ModAction{Kind: actionBan, ChatID: chat.ID, UserID: target.ID, UserName: ah.GetUserDisplayName(target), ByID: by.ID, By: ah.GetUserDisplayName(by), Reason: reason, Until: until})
+	ah.scheduleExpiry(until)
+
+	userMsgs := i18n.Get().T(ah.getLangForUser(target))
+	text := fmt.Sprintf(userMsgs.Moderation.Banned, ah.GetUserDisplayName(target), formatUntil(until, userMsgs))
+	if reason != "" {
+		text += "\n" + fmt.Sprintf(userMsgs.Moderation.Reason, reason)
+	}
+	_, _ = ah.bot.Send(chat, text)
+	notice := fmt.Sprintf(userMsgs.Moderation.BannedNotice, chat.Title, formatUntil(until, userMsgs))
+	if reason != "" {
+		notice += "\n" + fmt.Sprintf(userMsgs.Moderation.Reason, reason)
+	}
+	if _, err := ah.bot.Send(target, notice); err != nil {
+		logrus.WithError(err).WithField("user_id", target.ID).Debug("Could not tell the banned user in private")
+	}
+	ah.LogToAdmin(fmt.Sprintf("🔨 Бан\n\nАдмин: %s\nЗабанен: %s\nДо: %s\nПричина: %s", ah.GetUserDisplayName(by), ah.GetUserDisplayName(target), formatUntil(until, msgs), reason))
+}
+
+// HandleKick removes the replied or named user from the chat without keeping them out
+func (ah *AdminHandler) HandleKick(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if !ah.requireModerator(c, msgs) {
+		return nil
+	}
+	target, reason := ah.commandTarget(c, msgs)
+	if target == nil {
+		return nil
+	}
+	prompt := fmt.Sprintf(msgs.Moderation.ConfirmKick, ah.GetUserDisplayName(target))
+	return ah.confirmAction(c, msgs, prompt, func() { ah.kick(c, target, reason) })
+}
+
+// kick removes the target from the chat and announces it
+func (ah *AdminHandler) kick(c tb.Context, target *tb.User, reason string) {
+	fields := logrus.Fields{"chat_id": c.Chat().ID, "user_id": target.ID}
+	if err := ah.BanUser(c.Chat(), target); err != nil {
+		logrus.WithError(err).WithFields(fields).Error("Failed to kick user")
+		return
+	}
+	if err := ah.bot.Unban(c.Chat(), target); err != nil {
+		logrus.WithError(err).WithFields(fields).Warn("Failed to unban kicked user")
+	}
+	ah.actions.Record(ModAction{Kind: actionKick, ChatID: c.Chat().ID, UserID: target.ID, UserName: ah.GetUserDisplayName(target), ByID: c.Sender().ID, By: ah.GetUserDisplayName(c.Sender()), Reason: reason})
+
+	userMsgs := i18n.Get().T(ah.getLangForUser(target))
+	text := fmt.Sprintf(userMsgs.Moderation.Kicked, ah.GetUserDisplayName(target))
+	if reason != "" {
+		text += "\n" + fmt.Sprintf(userMsgs.Moderation.Reason, reason)
+	}
+	_, _ = ah.bot.Send(c.Chat(), text)
+	ah.LogToAdmin(fmt.Sprintf("👢 Кик\n\nАдмин: %s\nПользователь: %s\nПричина: %s", ah.GetUserDisplayName(c.Sender()), ah.GetUserDisplayName(target), reason))
+}
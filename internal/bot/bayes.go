@@ -0,0 +1,262 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// Minimum training samples per class before the model is trusted
+const (
+	minSpamSamples = 20
+	minHamSamples  = 50
+)
+
+// bayesRulePrefix marks filter actions taken by the classifier itself, which are not fed back into training
+const bayesRulePrefix = "bayes:"
+
+// SpamModel is a naive Bayes classifier over message words, persisted as word counts
+type SpamModel struct {
+	mu        sync.RWMutex
+	SpamDocs  int            `json:"spam_docs"`
+	HamDocs   int            `json:"ham_docs"`
+	SpamWords map[string]int `json:"spam_words"`
+	HamWords  map[string]int `json:"ham_words"`
+	SpamTotal int            `json:"spam_total"`
+	HamTotal  int            `json:"ham_total"`
+	file      string
+	dirty     bool
+}
+
+// NewSpamModel creates a classifier backed by a JSON file
+func NewSpamModel(file string) *SpamModel {
+	_ = os.MkdirAll("data", 0755)
+	sm := &SpamModel{
+		SpamWords: make(map[string]int),
+		HamWords:  make(map[string]int),
+		file:      file,
+	}
+	sm.load()
+	return sm
+}
+
+func (sm *SpamModel) load() {
+	data, err := os.ReadFile(sm.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, sm)
+	if sm.SpamWords == nil {
+		sm.SpamWords = make(map[string]int)
+	}
+	if sm.HamWords == nil {
+		sm.HamWords = make(map[string]int)
+	}
+}
+
+func (sm *SpamModel) save() {
+	data, err := json.Marshal(sm)
+	if err != nil {
+		logrus.WithError(err).Error("spam model marshal")
+		return
+	}
+	if err := os.WriteFile(sm.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("spam model write")
+	}
+}
+
+// Flush writes the model to disk if it changed since the last write
+func (sm *SpamModel) Flush(time.Time) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.dirty {
+		sm.save()
+		sm.dirty = false
+	}
+}
+
+// spamFeatures returns the distinct normalized words of a message
+func spamFeatures(text string) []string {
+	seen := make(map[string]bool)
+	var words []string
+	for _, w := range tokenize(normalizeText(text)) {
+		if len([]rune(w)) < 2 || seen[w] {
+			continue
+		}
+		seen[w] = true
+		words = append(words, w)
+	}
+	return words
+}
+
+// Train adds a message to the spam or ham samples
+func (sm *SpamModel) Train(text string, spam bool) {
+	words := spamFeatures(text)
+	if len(words) == 0 {
+		return
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	counts, total := sm.HamWords, &sm.HamTotal
+	if spam {
+		counts, total = sm.SpamWords, &sm.SpamTotal
+		sm.SpamDocs++
+	} else {
+		sm.HamDocs++
+	}
+	for _, w := range words {
+		counts[w]++
+	}
+	*total += len(words)
+	sm.dirty = true
+}
+
+// Score returns the probability that a message is spam; ok is false until the model has enough samples
+func (sm *SpamModel) Score(text string) (float64, bool) {
+	words := spamFeatures(text)
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	if sm.SpamDocs < minSpamSamples || sm.HamDocs < minHamSamples || len(words) == 0 {
+		return 0, false
+	}
+	vocab := float64(len(sm.SpamWords) + len(sm.HamWords))
+	logSpam := math.Log(float64(sm.SpamDocs) / float64(sm.SpamDocs+sm.HamDocs))
+	logHam := math.Log(float64(sm.HamDocs) / float64(sm.SpamDocs+sm.HamDocs))
+	for _, w := range words {
+		// Laplace smoothing keeps unseen words from zeroing a class
+		logSpam += math.Log((float64(sm.SpamWords[w]) + 1) / (float64(sm.SpamTotal) + vocab))
+		logHam += math.Log((float64(sm.HamWords[w]) + 1) / (float64(sm.HamTotal) + vocab))
+	}
+	return 1 / (1 + math.Exp(logHam-logSpam)), true
+}
+
+// spamReview is a message waiting for an admin verdict
+type spamReview struct {
+	chatID    int64
+	messageID int
+	user      *tb.User
+	text      string
+}
+
+// spamReviews keeps messages sent to admins for review
+type spamReviews struct {
+	mu      sync.Mutex
+	next    int
+	pending map[int]spamReview
+}
+
+func (sr *spamReviews) add(r spamReview) int {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.next++
+	sr.pending[sr.next] = r
+	return sr.next
+}
+
+func (sr *spamReviews) get(id int) (spamReview, bool) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	r, ok := sr.pending[id]
+	return r, ok
+}
+
+func (sr *spamReviews) take(id int) (spamReview, bool) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	r, ok := sr.pending[id]
+	delete(sr.pending, id)
+	return r, ok
+}
+
+// matchSpamScore finds messages the classifier considers spam, or likely enough to ask the admins about
+func (fh *FeatureHandler) matchSpamScore(in filterInput) (filterHit, bool) {
+	cs := in.cs
+	if cs.BayesDisabled {
+		return filterHit{}, false
+	}
+	score, ok := fh.spamModel.Score(in.text)
+	if !ok {
+		return filterHit{}, false
+	}
+	deleteAt, reviewAt := cs.BayesDeleteScore, cs.BayesReviewScore
+	if deleteAt <= 0 {
+		deleteAt = 95
+	}
+	if reviewAt <= 0 {
+		reviewAt = 80
+	}
+	percent := int(score * 100)
+	rule := fmt.Sprintf("%s%d%%", bayesRulePrefix, percent)
+	switch {
+	case percent >= deleteAt:
+		return filterHit{rule: rule, level: cs.bayesLevel()}, true
+	case percent >= reviewAt:
+		return filterHit{rule: rule, level: levelSpamReview, percent: percent}, true
+	}
+	return filterHit{}, false
+}
+
+// requestSpamReview asks admins whether a suspicious message is spam
+func (fh *FeatureHandler) requestSpamReview(c tb.Context, text string, percent int) {
+	m := c.Message()
+	id := strconv.Itoa(fh.spamReviews.add(spamReview{chatID: c.Chat().ID, messageID: m.ID, user: m.Sender, text: text}))
+	kb := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{
+		{Unique: "bayes_spam", Data: id, Text: "🚫 Спам"},
+		{Unique: "bayes_ham", Data: id, Text: "✅ Не спам"},
+	}}}
+	logMsg := fmt.Sprintf("🤖 Похоже на спам (%d%%).\n\nПользователь: %s\nЧат: %s\nСообщение: `%s`", percent, fh.adminHandler.GetUserDisplayName(m.Sender), c.Chat().Title, text)
+	if _, err := fh.bot.Send(&tb.Chat{ID: fh.adminChatID}, logMsg, kb); err != nil {
+		logrus.WithError(err).WithField("user_id", m.Sender.ID).Error("Failed to send spam review")
+	}
+}
+
+// HandleSpamVerdict deletes a reviewed message as spam or keeps it, training the classifier either way
+func (fh *FeatureHandler) HandleSpamVerdict(c tb.Context) error {
+	id, err := strconv.Atoi(c.Callback().Data)
+	if err != nil {
+		return fh.bot.Respond(c.Callback())
+	}
+	r, ok := fh.spamReviews.get(id)
+	if !ok {
+		return fh.bot.Respond(c.Callback())
+	}
+	if !fh.adminHandler.IsModerator(&tb.Chat{ID: r.chatID}, c.Sender()) {
+		msgs := i18n.Get().T(fh.getLangForUser(c.Sender()))
+		return fh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Moderation.AdminOnly})
+	}
+	if _, ok := fh.spamReviews.take(id); !ok {
+		return fh.bot.Respond(c.Callback())
+	}
+	spam := c.Callback().Unique == "bayes_spam"
+	fh.spamModel.Train(r.text, spam)
+	if spam {
+		fh.spamCorpus.Add(r.text)
+	}
+	verdict, kind := "✅ Не спам", actionDismiss
+	if spam {
+		verdict, kind = "🚫 Спам", actionDelete
+		if err := fh.bot.Delete(&tb.StoredMessage{MessageID: strconv.Itoa(r.messageID), ChatID: r.chatID}); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{"chat_id": r.chatID, "user_id": r.user.ID}).Warn("Failed to delete reviewed spam")
+		}
+	}
+	fh.adminHandler.RecordDecision(c, verdict, kind, &tb.Chat{ID: r.chatID}, r.user, "спам-фильтр")
+	return fh.bot.Respond(c.Callback())
+}
+
+// trainHam feeds messages of established members to the classifier as examples of normal chat
+func (fh *FeatureHandler) trainHam(c tb.Context, text string) {
+	if len(strings.Fields(text)) < 3 || fh.isRestrictedMember(c.Chat(), c.Message().Sender) {
+		return
+	}
+	fh.spamModel.Train(text, false)
+}
@@ -2,48 +2,131 @@ package bot
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strings"
 	"sync"
+	"unicode"
+
+	"capybot/internal/core"
+
+	"github.com/sirupsen/logrus"
 )
 
-// Blacklist stores blocked phrases
+// Blacklist stores blocked phrases and patterns
 type Blacklist struct {
-	mu      sync.RWMutex
-	Phrases [][]string `json:"phrases"`
-	file    string
+	mu       sync.RWMutex
+	Entries  []core.BlacklistEntry `json:"entries"`
+	Phrases  [][]string            `json:"phrases,omitempty"` // legacy format, migrated on load
+	Remote   []core.BlacklistEntry `json:"remote,omitempty"`
+	ETag     string                `json:"remote_etag,omitempty"`
+	file     string
+	compiled map[string]*regexp.Regexp
 }
 
 // NewBlacklist creates a blocklist backed by a JSON file in data/
 func NewBlacklist(file string) BlacklistInterface {
 	_ = os.MkdirAll("data", 0755)
-	bl := &Blacklist{file: filepath.Join("data", filepath.Base(file))}
+	bl := &Blacklist{
+		file:     filepath.Join("data", filepath.Base(file)),
+		compiled: make(map[string]*regexp.Regexp),
+	}
 	bl.load()
 	return bl
 }
 
-// AddPhrase adds a phrase to the blacklist
-func (b *Blacklist) AddPhrase(words []string) {
+// AddPhrase adds a phrase to the blacklist; words with * match any word they expand to
+func (b *Blacklist) AddPhrase(words []string, level, category string) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	lower := toLowerSlice(words)
-	b.Phrases = append(b.Phrases, lower)
+	mode := core.MatchPhrase
+	if slices.ContainsFunc(words, func(w string) bool { return strings.Contains(w, "*") }) {
+		mode = core.MatchWildcard
+	}
+	b.Entries = append(b.Entries, core.BlacklistEntry{Words: toLowerSlice(words), Mode: mode, Level: level, Category: category})
 	_ = b.save()
 }
 
-// RemovePhrase removes a phrase from the blacklist
-func (b *Blacklist) RemovePhrase(words []string) bool {
+// AddPattern validates and adds a regular expression to the blacklist
+func (b *Blacklist) AddPattern(pattern, level, category string) error {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return err
+	}
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.compiled[pattern] = re
+	b.Entries = append(b.Entries, core.BlacklistEntry{Pattern: pattern, Mode: core.MatchRegex, Level: level, Category: category})
+	_ = b.save()
+	return nil
+}
+
+// Import adds many entries at once, skipping duplicates and invalid patterns
+func (b *Blacklist) Import(entries []core.BlacklistEntry) (added, duplicates int, invalid []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	seen := make(map[string]bool, len(b.Entries)+len(b.Remote))
+	for _, e := range slices.Concat(b.Entries, b.Remote) {
+		seen[e.String()] = true
+	}
+	for _, e := range entries {
+		e.Remote = false
+		if e.Mode == core.MatchRegex {
+			re, err := compilePattern(e.Pattern)
+			if err != nil {
+				invalid = append(invalid, e.String())
+				continue
+			}
+			b.compiled[e.Pattern] = re
+		} else {
+			e.Words = toLowerSlice(e.Words)
+		}
+		if seen[e.String()] {
+			duplicates++
+			continue
+		}
+		seen[e.String()] = true
+		b.Entries = append(b.Entries, e)
+		added++
+	}
+	if added > 0 {
+		_ = b.save()
+	}
+	return added, duplicates, invalid
+}
+
+// RemovePhrase removes a phrase from the blacklist
+func (b *Blacklist) RemovePhrase(words []string) bool {
 	target := strings.Join(toLowerSlice(words), " ")
-	before := len(b.Phrases)
-	b.Phrases = slices.DeleteFunc(b.Phrases, func(p []string) bool {
-		return strings.Join(p, " ") == target
+	return b.remove(func(e core.BlacklistEntry) bool {
+		return e.Mode != core.MatchRegex && strings.Join(e.Words, " ") == target
 	})
-	if len(b.Phrases) < before {
+}
+
+// RemovePattern removes a regular expression from the blacklist
+func (b *Blacklist) RemovePattern(pattern string) bool {
+	removed := b.remove(func(e core.BlacklistEntry) bool {
+		return e.Mode == core.MatchRegex && e.Pattern == pattern
+	})
+	if removed {
+		b.mu.Lock()
+		delete(b.compiled, pattern)
+		b.mu.Unlock()
+	}
+	return removed
+}
+
+// remove deletes matching entries and persists the list if anything changed
+func (b *Blacklist) remove(match func(core.BlacklistEntry) bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	before := len(b.Entries)
+	b.Entries = slices.DeleteFunc(b.Entries, match)
+	if len(b.Entries) < before {
 		_ = b.save()
 		return true
 	}
@@ -58,30 +141,143 @@ func toLowerSlice(words []string) []string {
 	return result
 }
 
-// CheckMessage checks if a message contains any blacklisted phrases
-func (b *Blacklist) CheckMessage(msg string) bool {
+// compilePattern compiles a case-insensitive blacklist pattern
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// globMatch matches a word against a pattern where * stands for any run of characters
+func globMatch(pattern, word string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == word
+	}
+	if !strings.HasPrefix(word, parts[0]) {
+		return false
+	}
+	word = word[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(word, part)
+		if i < 0 {
+			return false
+		}
+		word = word[i+len(part):]
+	}
+	return len(word) >= len(last) && strings.HasSuffix(word, last)
+}
+
+// tokenize splits text into words without surrounding punctuation
+func tokenize(text string) []string {
+	fields := strings.Fields(text)
+	for i, f := range fields {
+		fields[i] = strings.TrimFunc(f, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	}
+	return fields
+}
+
+// Match returns the most severe blacklist entry found in a message; phrases and text are compared after normalization
+func (b *Blacklist) Match(msg string) (core.BlacklistEntry, bool) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
-	text := strings.ToLower(msg)
+	text := normalizeText(msg)
 	words := strings.Fields(text)
-	return slices.ContainsFunc(b.Phrases, func(phrase []string) bool {
-		if len(phrase) == 1 {
-			return slices.Contains(words, phrase[0])
+	var tokens []string
+	matches := func(e core.BlacklistEntry) bool {
+		switch e.Mode {
+		case core.MatchRegex:
+			re := b.compiled[e.Pattern]
+			return re != nil && (re.MatchString(msg) || re.MatchString(text))
+		case core.MatchWildcard:
+			if tokens == nil {
+				tokens = tokenize(text)
+			}
+			for _, pw := range e.Words {
+				pw = normalizeText(pw)
+				if !slices.ContainsFunc(tokens, func(t string) bool { return globMatch(pw, t) }) {
+					return false
+				}
+			}
+			return true
+		}
+		if len(e.Words) == 1 {
+			return slices.Contains(words, normalizeText(e.Words[0]))
 		}
-		for _, pw := range phrase {
-			if !strings.Contains(text, pw) {
+		for _, pw := range e.Words {
+			if !strings.Contains(text, normalizeText(pw)) {
 				return false
 			}
 		}
 		return true
-	})
+	}
+	var found core.BlacklistEntry
+	ok := false
+	for _, list := range [][]core.BlacklistEntry{b.Entries, b.Remote} {
+		for _, e := range list {
+			if (!ok || severityRank(e.Severity()) > severityRank(found.Severity())) && matches(e) {
+				found, ok = e, true
+			}
+		}
+	}
+	return found, ok
+}
+
+// severityRank orders levels from the mildest to the harshest
+func severityRank(level string) int {
+	return slices.Index(core.BlacklistLevels, level)
+}
+
+// List returns a copy of the local entries followed by the subscribed ones
+func (b *Blacklist) List() []core.BlacklistEntry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return slices.Concat(b.Entries, b.Remote)
 }
 
-// List returns a copy of the blacklisted phrases
-func (b *Blacklist) List() [][]string {
+// SetRemote replaces the entries of the subscribed list, skipping invalid patterns; skipped lists the lines
+// that failed to parse, and the old list stays when nothing valid is left or the invalid lines outnumber the valid ones
+func (b *Blacklist) SetRemote(entries []core.BlacklistEntry, skipped []string, etag string) ([]string, error) {
+	invalid := slices.Clone(skipped)
+	remote := make([]core.BlacklistEntry, 0, len(entries))
+	compiled := make(map[string]*regexp.Regexp)
+	for _, e := range entries {
+		if e.Mode == core.MatchRegex {
+			re, err := compilePattern(e.Pattern)
+			if err != nil {
+				invalid = append(invalid, e.String())
+				continue
+			}
+			compiled[e.Pattern] = re
+		} else {
+			e.Words = toLowerSlice(e.Words)
+		}
+		e.Remote = true
+		remote = append(remote, e)
+	}
+	if len(remote) == 0 {
+		return invalid, errors.New("list has no valid entries")
+	}
+	if len(invalid) > len(remote) {
+		return invalid, fmt.Errorf("list has more invalid entries (%d) than valid ones (%d)", len(invalid), len(remote))
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for p, re := range compiled {
+		b.compiled[p] = re
+	}
+	b.Remote, b.ETag = remote, etag
+	_ = b.save()
+	return invalid, nil
+}
+
+// RemoteETag returns the ETag of the last fetched subscribed list
+func (b *Blacklist) RemoteETag() string {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
-	return slices.Clone(b.Phrases)
+	return b.ETag
 }
 
 // save persists the blacklist to disk
@@ -96,11 +292,30 @@ func (b *Blacklist) save() error {
 	return nil
 }
 
-// load reads the blacklist from the disk
+// load reads the blacklist from the disk, migrating plain phrases and compiling patterns
 func (b *Blacklist) load() {
 	data, err := os.ReadFile(b.file)
 	if err != nil {
 		return
 	}
 	_ = json.Unmarshal(data, b)
+	migrated := len(b.Phrases) > 0
+	for _, p := range b.Phrases {
+		b.Entries = append(b.Entries, core.BlacklistEntry{Words: p, Mode: core.MatchPhrase})
+	}
+	b.Phrases = nil
+	for _, e := range slices.Concat(b.Entries, b.Remote) {
+		if e.Mode != core.MatchRegex {
+			continue
+		}
+		re, err := compilePattern(e.Pattern)
+		if err != nil {
+			logrus.WithError(err).WithField("pattern", e.Pattern).Warn("Skipping invalid blacklist pattern")
+			continue
+		}
+		b.compiled[e.Pattern] = re
+	}
+	if migrated {
+		_ = b.save()
+	}
 }
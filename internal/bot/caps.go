@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"fmt"
+	"unicode"
+
+	"capybot/internal/core"
+)
+
+// capsStats counts cased letters and how many of them are uppercase
+func capsStats(text string) (letters, upper int) {
+	for _, r := range text {
+		switch {
+		case unicode.IsUpper(r):
+			letters++
+			upper++
+		case unicode.IsLower(r):
+			letters++
+		}
+	}
+	return letters, upper
+}
+
+// matchCaps finds messages written mostly in capital letters
+func (fh *FeatureHandler) matchCaps(in filterInput) (filterHit, bool) {
+	if !in.cs.CapsFilter {
+		return filterHit{}, false
+	}
+	share, shouting := in.cs.shouting(in.text)
+	if !shouting {
+		return filterHit{}, false
+	}
+	return filterHit{rule: fmt.Sprintf("caps:%d%%", share), level: in.cs.capsLevel()}, true
+}
+
+// shouting returns the share of capitals in percent and whether it exceeds the chat thresholds
+func (cs ChatSettings) shouting(text string) (int, bool) {
+	minLetters, percent := cs.CapsMinLetters, cs.CapsPercent
+	if minLetters <= 0 {
+		minLetters = 20
+	}
+	if percent <= 0 {
+		percent = 70
+	}
+	letters, upper := capsStats(text)
+	if letters < minLetters || upper*100 < letters*percent {
+		return 0, false
+	}
+	return upper * 100 / letters, true
+}
+
+// capsLevel returns the action for shouting
+func (cs ChatSettings) capsLevel() string {
+	if cs.CapsLevel == "" {
+		return core.LevelDeleteWarn
+	}
+	return cs.CapsLevel
+}
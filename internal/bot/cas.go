@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// casAPI is the Combot Anti-Spam lookup endpoint
+const casAPI = "https://api.cas.chat/check?user_id=%d"
+
+// casCacheTTL is how long a CAS answer is reused, older answers are dropped on the next insert
+const casCacheTTL = 6 * time.Hour
+
+// casResult is a cached CAS answer
+type casResult struct {
+	banned   bool
+	offenses int
+	at       time.Time
+}
+
+// casChecker looks users up in the CAS database
+type casChecker struct {
+	mu     sync.Mutex
+	cache  map[int64]casResult
+	client *http.Client
+}
+
+// newCASChecker creates a checker with an empty cache
+func newCASChecker() *casChecker {
+	return &casChecker{cache: make(map[int64]casResult), client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// lookup reports whether the user is banned in CAS and the number of recorded offenses
+func (cc *casChecker) lookup(userID int64) (bool, int) {
+	cc.mu.Lock()
+	if r, ok := cc.cache[userID]; ok && time.Since(r.at) < casCacheTTL {
+		cc.mu.Unlock()
+		return r.banned, r.offenses
+	}
+	cc.mu.Unlock()
+
+	resp, err := cc.client.Get(fmt.Sprintf(casAPI, userID))
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", userID).Warn("CAS lookup failed")
+		return false, 0
+	}
+	defer resp.Body.Close()
+	var body struct {
+		OK     bool `json:"ok"`
+		Result struct {
+			Offenses int `json:"offenses"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		logrus.WithError(err).WithField("user_id", userID).Warn("CAS response decode failed")
+		return false, 0
+	}
+	r := casResult{banned: body.OK, offenses: body.Result.Offenses, at: time.Now()}
+	cc.mu.Lock()
+	maps.DeleteFunc(cc.cache, func(_ int64, old casResult) bool { return time.Since(old.at) >= casCacheTTL })
+	cc.cache[userID] = r
+	cc.mu.Unlock()
+	return r.banned, r.offenses
+}
+
+// checkCAS bans a joining user listed in CAS and reports whether it happened
+func (fh *FeatureHandler) checkCAS(chat *tb.Chat, u *tb.User) bool {
+	if fh.settings.Get(chat.ID).CASDisabled {
+		return false
+	}
+	banned, offenses := fh.cas.lookup(u.ID)
+	if !banned {
+		return false
+	}
+	if err := fh.adminHandler.BanUser(chat, u); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": chat.ID, "user_id": u.ID}).Error("Failed to ban CAS listed user")
+	}
+	fh.recordVerification(chat, u, VerifyEvent{Outcome: verifyCASBanned, Score: fmt.Sprintf("offenses: %d", offenses)})
+	fh.adminHandler.LogToAdmin(fmt.Sprintf("🛡 CAS: пользователь в базе спамеров забанен при входе.\n\nПользователь: %s\nЧат: %s\nНарушений в CAS: %d\nhttps://cas.chat/query?u=%d", fh.adminHandler.GetUserDisplayName(u), chat.Title, offenses, u.ID))
+	logrus.WithFields(logrus.Fields{"chat_id": chat.ID, "user_id": u.ID, "offenses": offenses}).Info("CAS listed user banned")
+	return true
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// confirmTimeout is how long a confirmation keyboard stays valid
+const confirmTimeout = time.Minute
+
+// pendingConfirm is a destructive action waiting for the admin who asked for it
+type pendingConfirm struct {
+	byID    int64
+	expires time.Time
+	run     func()
+}
+
+// confirmations keeps actions waiting for a Yes or Cancel
+type confirmations struct {
+	mu      sync.Mutex
+	next    int
+	pending map[int]pendingConfirm
+}
+
+func (cf *confirmations) add(p pendingConfirm) int {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	now := time.Now()
+	for id, old := range cf.pending {
+		if now.After(old.expires) {
+			delete(cf.pending, id)
+		}
+	}
+	cf.next++
+	cf.pending[cf.next] = p
+	return cf.next
+}
+
+// take removes and returns the action if it has not expired and belongs to the given admin; an action asked for
+// by an anonymous admin may be confirmed by any moderator, as their real account is unknown
+func (cf *confirmations) take(id int, byID int64) (pendingConfirm, bool) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	p, ok := cf.pending[id]
+	if !ok || p.byID != byID && p.byID != anonymousAdminID {
+		return pendingConfirm{}, false
+	}
+	delete(cf.pending, id)
+	return p, time.Now().Before(p.expires)
+}
+
+// confirmAction runs a destructive action right away, or after the issuing admin presses Yes when the chat asks for confirmation
+func (ah *AdminHandler) confirmAction(c tb.Context, msgs *i18n.Messages, prompt string, run func()) error {
+	if !ah.settings.Get(c.Chat().ID).ConfirmActions {
+		run()
+		return nil
+	}
+	id := strconv.Itoa(ah.confirms.add(pendingConfirm{byID: c.Sender().ID, expires: time.Now().Add(confirmTimeout), run: run}))
+	kb := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{
+		{Unique: "mod_confirm", Data: id, Text: msgs.Moderation.ConfirmYes},
+		{Unique: "mod_cancel", Data: id, Text: msgs.Moderation.ConfirmCancel},
+	}}}
+	msg, _ := ah.bot.Send(c.Chat(), prompt, kb)
+	ah.DeleteAfter(msg, confirmTimeout)
+	return nil
+}
+
+// HandleConfirmAction carries out or drops an action waiting for confirmation
+func (ah *AdminHandler) HandleConfirmAction(c tb.Context) error {
+	cb := c.Callback()
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	id, err := strconv.Atoi(cb.Data)
+	if err != nil {
+		return ah.bot.Respond(cb)
+	}
+	if !ah.IsModerator(c.Chat(), c.Sender()) {
+		return ah.bot.Respond(cb, &tb.CallbackResponse{Text: msgs.Moderation.ConfirmUnavailable})
+	}
+	p, ok := ah.confirms.take(id, c.Sender().ID)
+	if !ok {
+		return ah.bot.Respond(cb, &tb.CallbackResponse{Text: msgs.Moderation.ConfirmUnavailable})
+	}
+	_ = ah.bot.Delete(c.Message())
+	if cb.Unique != "mod_confirm" {
+		return ah.bot.Respond(cb, &tb.CallbackResponse{Text: msgs.Moderation.ConfirmCancelled})
+	}
+	p.run()
+	return ah.bot.Respond(cb)
+}
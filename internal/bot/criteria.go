@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// ratingCriteria are the aspects rated after the overall score; reviews written before them have none
+var ratingCriteria = []string{"clarity", "fairness", "difficulty"}
+
+// criterionLabel returns the localized name of a criterion
+func criterionLabel(msgs *i18n.Messages, criterion string) string {
+	switch criterion {
+	case "clarity":
+		return msgs.Rating.CriterionClarity
+	case "fairness":
+		return msgs.Rating.CriterionFairness
+	case "difficulty":
+		return msgs.Rating.CriterionDifficulty
+	}
+	return criterion
+}
+
+// criterionKeyboard returns the buttons for scoring a criterion, 0 skipping it
+func criterionKeyboard(msgs *i18n.Messages) *tb.ReplyMarkup {
+	var scores []tb.InlineButton
+	for n := 1; n <= 5; n++ {
+		scores = append(scores, tb.InlineButton{Data: fmt.Sprintf("rate_crit_%d", n), Text: fmt.Sprintf("%d", n)})
+	}
+	return &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{
+			scores,
+			{{Data: "rate_crit_0", Text: msgs.Rating.BtnSkip}},
+			draftRow(msgs),
+		},
+	}
+}
+
+// criterionPrompt asks for the score of the criterion the session is at
+func criterionPrompt(msgs *i18n.Messages, session *RatingSession) string {
+	criterion := ratingCriteria[session.Criterion]
+	prompt := fmt.Sprintf(msgs.Rating.ChooseCriterion, criterionLabel(msgs, criterion))
+	if criterion == "difficulty" {
+		prompt += "\n" + msgs.Rating.DifficultyHint
+	}
+	return prompt
+}
+
+// formatCriteria lists the criteria scores of a review, empty when it has none
+func formatCriteria(msgs *i18n.Messages, criteria map[string]int) string {
+	var parts []string
+	for _, c := range ratingCriteria {
+		if n := criteria[c]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", criterionLabel(msgs, c), n))
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatCriteriaAverages lists the average criteria scores of a professor, empty when no review has them
+func formatCriteriaAverages(msgs *i18n.Messages, card professorCard) string {
+	var parts []string
+	for _, c := range ratingCriteria {
+		if n := card.criteriaCount[c]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %.1f", criterionLabel(msgs, c), float64(card.criteriaSum[c])/float64(n)))
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// criteriaLine returns the criteria scores as a line to append after the overall score
+func criteriaLine(msgs *i18n.Messages, criteria map[string]int) string {
+	if s := formatCriteria(msgs, criteria); s != "" {
+		return "\n📐 " + s
+	}
+	return ""
+}
@@ -0,0 +1,36 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// adminSignature names an admin together with their ID
+func (ah *AdminHandler) adminSignature(user *tb.User) string {
+	if user.Username != "" {
+		return fmt.Sprintf("@%s (ID: %d)", user.Username, user.ID)
+	}
+	return ah.GetUserDisplayName(user)
+}
+
+// RecordDecision marks an admin chat message with the verdict and the admin who pressed the button, and stores the decision in the action log unless kind is empty
+func (ah *AdminHandler) RecordDecision(c tb.Context, verdict, kind string, chat *tb.Chat, user *tb.User, reason string) {
+	by := c.Sender()
+	if _, err := ah.bot.Edit(c.Message(), fmt.Sprintf("%s\n\n%s\nАдмин: %s", c.Message().Text, verdict, ah.adminSignature(by))); err != nil {
+		logrus.WithError(err).WithField("admin_id", by.ID).Warn("Failed to mark admin chat message")
+	}
+	if kind == "" {
+		return
+	}
+	a := ModAction{Kind: kind, ByID: by.ID, By: ah.GetUserDisplayName(by), Reason: reason}
+	if chat != nil {
+		a.ChatID = chat.ID
+	}
+	if user != nil {
+		a.UserID, a.UserName = user.ID, ah.GetUserDisplayName(user)
+	}
+	ah.actions.Record(a)
+	logrus.WithFields(logrus.Fields{"kind": kind, "chat_id": a.ChatID, "user_id": a.UserID, "admin_id": by.ID}).Info("Admin chat decision")
+}
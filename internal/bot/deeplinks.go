@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// ProfLinkPrefix starts the /start payload of a link to the reviews of a professor
+const ProfLinkPrefix = "prof_"
+
+// maxSlugLength keeps a professor link within the 64 characters Telegram allows in a start payload
+const maxSlugLength = 48
+
+// professorSlug returns the part of a professor link naming them, like jan-kowalski; names with letters
+// that cannot be written in a link get a hash of the name appended so they stay distinct
+func professorSlug(name string) string {
+	key := professorKey(name)
+	var sb strings.Builder
+	lossy := false
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			sb.WriteRune(r)
+		case r == ' ':
+			sb.WriteByte('-')
+		default:
+			lossy = true
+		}
+	}
+	slug := strings.Trim(sb.String(), "-")
+	if len(slug) > maxSlugLength {
+		slug, lossy = strings.TrimRight(slug[:maxSlugLength], "-"), true
+	}
+	if lossy || slug == "" {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(key))
+		slug = strings.TrimLeft(fmt.Sprintf("%s-%08x", slug, h.Sum32()), "-")
+	}
+	return slug
+}
+
+// professorDeepLink returns the link opening the reviews of a professor in the bot at botLink, like https://t.me/capybot
+func professorDeepLink(botLink, name string) string {
+	return botLink + "?start=" + ProfLinkPrefix + professorSlug(name)
+}
+
+// botLink returns the t.me link of the bot
+func (rh *RatingHandler) botLink() string {
+	return "https://t.me/" + rh.bot.Me.Username
+}
+
+// HandleProfessorLink opens the reviews of the professor named by a deep link slug
+func (rh *RatingHandler) HandleProfessorLink(c tb.Context, slug string) error {
+	for _, card := range professorCards(rh.store.GetApprovedReviews()) {
+		if professorSlug(card.Professor) == slug {
+			rh.renderProfessor(c, card.ReviewID, 0)
+			return nil
+		}
+	}
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	_, _ = rh.bot.Send(c.Chat(), msgs.Rating.LinkNotFound)
+	return nil
+}
+
+// OnStartPayload routes /start payloads beginning with prefix, sent by deep links, to fn with the rest of the payload
+func (fh *FeatureHandler) OnStartPayload(prefix string, fn func(c tb.Context, arg string) error) {
+	fh.startPayloads = append(fh.startPayloads, startPayload{prefix: prefix, handle: fn})
+}
+
+// startPayload is a deep link handler registered with OnStartPayload
+type startPayload struct {
+	prefix string
+	handle func(c tb.Context, arg string) error
+}
+
+// dispatchStart hands a /start payload to its deep link handler and reports whether one took it
+func (fh *FeatureHandler) dispatchStart(c tb.Context) (bool, error) {
+	payload := c.Message().Payload
+	for _, p := range fh.startPayloads {
+		if arg, ok := strings.CutPrefix(payload, p.prefix); ok && payload != "" {
+			return true, p.handle(c, arg)
+		}
+	}
+	return false, nil
+}
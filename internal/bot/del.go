@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// HandleDel deletes the replied message and the command; /del warn [reason] also warns the author
+func (ah *AdminHandler) HandleDel(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if !ah.requireModerator(c, msgs) {
+		return nil
+	}
+	reply := c.Message().ReplyTo
+	if reply == nil {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Moderation.ReplyRequired)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	fields := logrus.Fields{"chat_id": c.Chat().ID, "message_id": reply.ID}
+	if err := ah.bot.Delete(reply); err != nil {
+		logrus.WithError(err).WithFields(fields).Warn("Failed to delete replied message")
+	}
+	if err := ah.bot.Delete(c.Message()); err != nil {
+		logrus.WithError(err).WithFields(fields).Debug("Failed to delete /del command")
+	}
+	author := ""
+	if reply.Sender != nil {
+		author = ah.GetUserDisplayName(reply.Sender)
+	}
+	ah.LogToAdmin(fmt.Sprintf("🗑 Сообщение удалено вручную\n\nАдмин: %s\nАвтор: %s\nСообщение: %s", ah.GetUserDisplayName(c.Sender()), author, truncateText(messageText(reply), 200)))
+
+	flag, reason, _ := strings.Cut(strings.TrimSpace(c.Message().Payload), " ")
+	if strings.EqualFold(flag, "warn") && reply.Sender != nil && !ah.IsAdmin(c.Chat(), reply.Sender) {
+		ah.warn(c.Chat(), c.Sender(), reply.Sender, strings.TrimSpace(reason))
+	}
+	return nil
+}
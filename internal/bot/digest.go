@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// Routine admin log events that a chat may move into the digest
+const (
+	eventJoin        = "join"
+	eventLeave       = "leave"
+	eventTrusted     = "trusted"
+	eventGuest       = "guest"
+	eventAds         = "ads"
+	eventVerified    = "verified"
+	eventJoinRequest = "join_request"
+	eventApproved    = "approved"
+)
+
+// digestEvents lists events that can be batched; bans, failed verifications and the like are always sent at once
+var digestEvents = []string{eventJoin, eventLeave, eventTrusted, eventGuest, eventAds, eventVerified, eventJoinRequest, eventApproved}
+
+// digestLabels are the digest section titles for the admin chat
+var digestLabels = map[string]string{
+	eventJoin:        "👤 Вошли",
+	eventLeave:       "👋 Вышли",
+	eventTrusted:     "🤝 Доверенные без проверки",
+	eventGuest:       "🧐 Гости с вопросом",
+	eventAds:         "📢 Выбрали рекламу",
+	eventVerified:    "✅ Прошли проверку",
+	eventJoinRequest: "📨 Заявки на вступление",
+	eventApproved:    "✅ Одобренные заявки",
+}
+
+// digestNamesShown is how many users a digest section names before summing up the rest
+const digestNamesShown = 15
+
+// chatDigest collects the routine events of one chat since the last summary
+type chatDigest struct {
+	Title  string              `json:"title,omitempty"`
+	Since  time.Time           `json:"since"`
+	Events map[string][]string `json:"events"`
+}
+
+// DigestStore persists events waiting for the next summary
+type DigestStore struct {
+	mu    sync.Mutex
+	Chats map[int64]*chatDigest `json:"chats"`
+	file  string
+}
+
+// NewDigestStore creates a digest store backed by a JSON file
+func NewDigestStore(file string) *DigestStore {
+	_ = os.MkdirAll("data", 0755)
+	ds := &DigestStore{
+		Chats: make(map[int64]*chatDigest),
+		file:  file,
+	}
+	ds.load()
+	return ds
+}
+
+func (ds *DigestStore) load() {
+	data, err := os.ReadFile(ds.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, ds)
+	if ds.Chats == nil {
+		ds.Chats = make(map[int64]*chatDigest)
+	}
+}
+
+func (ds *DigestStore) save() {
+	data, err := json.MarshalIndent(ds, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error("digest store marshal")
+		return
+	}
+	if err := os.WriteFile(ds.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("digest store write")
+	}
+}
+
+// Add queues an event about a user for the chat's next summary
+func (ds *DigestStore) Add(chat *tb.Chat, kind, user string) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	d, ok := ds.Chats[chat.ID]
+	if !ok {
+		d = &chatDigest{Since: time.Now(), Events: make(map[string][]string)}
+		ds.Chats[chat.ID] = d
+	}
+	if chat.Title != "" {
+		d.Title = chat.Title
+	}
+	d.Events[kind] = append(d.Events[kind], user)
+	ds.save()
+}
+
+// Due removes and returns the digests collected for longer than the period of their chat
+func (ds *DigestStore) Due(now time.Time, period func(chatID int64) time.Duration) map[int64]chatDigest {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	due := make(map[int64]chatDigest)
+	for chatID, d := range ds.Chats {
+		if now.Sub(d.Since) >= period(chatID) {
+			due[chatID] = *d
+			delete(ds.Chats, chatID)
+		}
+	}
+	if len(due) > 0 {
+		ds.save()
+	}
+	return due
+}
+
+// digestPeriod returns how often the chat's digest is sent
+func (cs ChatSettings) digestPeriod() time.Duration {
+	if cs.DigestHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(cs.DigestHours) * time.Hour
+}
+
+// LogEvent sends a routine event to the admin chat, or queues it for the digest when the chat batches this kind
+func (ah *AdminHandler) LogEvent(chat *tb.Chat, kind string, user *tb.User, message string) {
+	if chat == nil || !slices.Contains(ah.settings.Get(chat.ID).DigestEvents, kind) {
+		ah.LogToAdmin(message)
+		return
+	}
+	ah.digest.Add(chat, kind, ah.GetUserDisplayName(user))
+}
+
+// SendDigests posts the summaries that are due to the admin chat
+func (ah *AdminHandler) SendDigests(now time.Time) {
+	due := ah.digest.Due(now, func(chatID int64) time.Duration { return ah.settings.Get(chatID).digestPeriod() })
+	for chatID, d := range due {
+		ah.LogToAdmin(formatDigest(chatID, d, now))
+	}
+}
+
+// formatDigest renders a summary with a section per event kind in the usual order
+func formatDigest(chatID int64, d chatDigest, now time.Time) string {
+	title := d.Title
+	if title == "" {
+		title = fmt.Sprintf("%d", chatID)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📋 Сводка событий\n\nЧат: %s\nПериод: %s – %s", title, d.Since.Format("02.01.2006 15:04"), now.Format("02.01.2006 15:04")))
+	for _, kind := range digestEvents {
+		users := d.Events[kind]
+		if len(users) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n\n%s: %d\n", digestLabels[kind], len(users)))
+		shown := users[:min(len(users), digestNamesShown)]
+		sb.WriteString(strings.Join(shown, ", "))
+		if rest := len(users) - len(shown); rest > 0 {
+			sb.WriteString(fmt.Sprintf(" и ещё %d", rest))
+		}
+	}
+	return sb.String()
+}
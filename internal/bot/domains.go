@@ -0,0 +1,369 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"capybot/internal/core"
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// shorteners are link shortening services whose targets are resolved before matching
+var shorteners = []string{"bit.ly", "tinyurl.com", "t.co", "goo.gl", "cutt.ly", "is.gd", "clck.ru", "vk.cc", "shorturl.at", "rebrand.ly"}
+
+// resolveTimeout limits how long a shortened link is followed
+const resolveTimeout = 3 * time.Second
+
+// resolvedTTL and failedTTL are how long the target of a shortened link, or the failure to find it, is reused
+const (
+	resolvedTTL = 24 * time.Hour
+	failedTTL   = 10 * time.Minute
+)
+
+// maxResolved caps the number of cached shortened links
+const maxResolved = 4096
+
+// sharedAddressSpace is the carrier-grade NAT range, which is not reachable from the internet either
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// redirectClient follows shortened links to public addresses only
+var redirectClient = &http.Client{
+	Timeout: resolveTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{Timeout: resolveTimeout, Control: dialPublicOnly}).DialContext,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return fmt.Errorf("redirect to %s scheme", req.URL.Scheme)
+		}
+		if len(via) >= 5 {
+			return errors.New("too many redirects")
+		}
+		return nil
+	},
+}
+
+// dialPublicOnly refuses connections to loopback, private and other non-public addresses
+func dialPublicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("address %s is not public", ip)
+	}
+	return nil
+}
+
+// resolvedLink is a cached target of a shortened link, empty when it could not be resolved
+type resolvedLink struct {
+	target string
+	at     time.Time
+}
+
+// resolvedLinks remembers where shortened links lead so repeated spam does not fetch them again
+var resolvedLinks = struct {
+	mu    sync.Mutex
+	links map[string]resolvedLink
+}{links: make(map[string]resolvedLink)}
+
+// expired reports whether a cached link should be resolved again
+func (l resolvedLink) expired() bool {
+	if l.target == "" {
+		return time.Since(l.at) >= failedTTL
+	}
+	return time.Since(l.at) >= resolvedTTL
+}
+
+// DomainStore persists blocked domains with their severity levels
+type DomainStore struct {
+	mu      sync.RWMutex
+	Domains map[string]string `json:"domains"`
+	file    string
+}
+
+// NewDomainStore creates a domain blocklist backed by a JSON file
+func NewDomainStore(file string) *DomainStore {
+	_ = os.MkdirAll("data", 0755)
+	ds := &DomainStore{
+		Domains: make(map[string]string),
+		file:    file,
+	}
+	ds.load()
+	return ds
+}
+
+func (ds *DomainStore) load() {
+	data, err := os.ReadFile(ds.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, ds)
+	if ds.Domains == nil {
+		ds.Domains = make(map[string]string)
+	}
+}
+
+func (ds *DomainStore) save() {
+	data, err := json.MarshalIndent(ds, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error("domain store marshal")
+		return
+	}
+	if err := os.WriteFile(ds.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("domain store write")
+	}
+}
+
+// Add blocks a domain with the given level
+func (ds *DomainStore) Add(domain, level string) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.Domains[domain] = level
+	ds.save()
+}
+
+// Remove unblocks a domain
+func (ds *DomainStore) Remove(domain string) bool {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if _, ok := ds.Domains[domain]; !ok {
+		return false
+	}
+	delete(ds.Domains, domain)
+	ds.save()
+	return true
+}
+
+// List returns blocked domains with their levels in alphabetical order
+func (ds *DomainStore) List() []string {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	domains := make([]string, 0, len(ds.Domains))
+	for d, level := range ds.Domains {
+		domains = append(domains, d+" — "+level)
+	}
+	slices.Sort(domains)
+	return domains
+}
+
+// Match finds the most severe rule covering any of the keys or their parent domains
+func (ds *DomainStore) Match(keys []string) (string, string, bool) {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	var domain, level string
+	for _, key := range keys {
+		for k := key; k != ""; {
+			if l, ok := ds.Domains[k]; ok && (domain == "" || severityRank(l) > severityRank(level)) {
+				domain, level = k, l
+			}
+			_, parent, found := strings.Cut(k, ".")
+			if !found || strings.Contains(k, "/") {
+				break
+			}
+			k = parent
+		}
+	}
+	return domain, level, domain != ""
+}
+
+// normalizeDomain turns user input like https://www.Example.com/path into example.com; t.me links keep the channel
+func normalizeDomain(input string) (string, bool) {
+	keys := linkKeys(input, false)
+	if len(keys) == 0 {
+		return "", false
+	}
+	return keys[0], true
+}
+
+// linkKeys returns the domains a link may be blocked by, the most specific first
+func linkKeys(raw string, resolve bool) []string {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" || !strings.Contains(u.Hostname(), ".") {
+		return nil
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	switch host {
+	case "t.me", "telegram.me", "telegram.dog":
+		segment, _, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+		// Share and instant view links point to another page
+		if inner := u.Query().Get("url"); inner != "" && (segment == "share" || segment == "iv") {
+			return append(linkKeys(inner, resolve), "t.me")
+		}
+		if segment == "" {
+			return []string{"t.me"}
+		}
+		return []string{"t.me/" + strings.ToLower(segment), "t.me"}
+	}
+	keys := []string{host}
+	if resolve && slices.Contains(shorteners, host) {
+		if target := resolveRedirect(raw); target != "" && target != raw {
+			keys = append(linkKeys(target, false), keys...)
+		}
+	}
+	return keys
+}
+
+// resolveRedirect returns the final address of a shortened link, from the cache when it was followed recently
+func resolveRedirect(raw string) string {
+	resolvedLinks.mu.Lock()
+	if l, ok := resolvedLinks.links[raw]; ok && !l.expired() {
+		resolvedLinks.mu.Unlock()
+		return l.target
+	}
+	resolvedLinks.mu.Unlock()
+
+	target := followRedirect(raw)
+	resolvedLinks.mu.Lock()
+	defer resolvedLinks.mu.Unlock()
+	if len(resolvedLinks.links) >= maxResolved {
+		maps.DeleteFunc(resolvedLinks.links, func(_ string, l resolvedLink) bool { return l.expired() })
+		for key := range resolvedLinks.links {
+			if len(resolvedLinks.links) < maxResolved {
+				break
+			}
+			delete(resolvedLinks.links, key)
+		}
+	}
+	resolvedLinks.links[raw] = resolvedLink{target: target, at: time.Now()}
+	return target
+}
+
+// followRedirect follows a shortened link and returns its final address
+func followRedirect(raw string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, raw, nil)
+	if err != nil || req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return ""
+	}
+	resp, err := redirectClient.Do(req)
+	if err != nil {
+		logrus.WithError(err).WithField("url", raw).Debug("Failed to resolve shortened link")
+		return ""
+	}
+	_ = resp.Body.Close()
+	return resp.Request.URL.String()
+}
+
+// messageLinks extracts links from text and caption entities
+func messageLinks(m *tb.Message) []string {
+	var links []string
+	for _, e := range append(slices.Clone(m.Entities), m.CaptionEntities...) {
+		switch e.Type {
+		case tb.EntityURL:
+			links = append(links, m.EntityText(e))
+		case tb.EntityTextLink:
+			links = append(links, e.URL)
+		}
+	}
+	return links
+}
+
+// matchDomains finds a link to a blocked domain
+func (fh *FeatureHandler) matchDomains(in filterInput) (filterHit, bool) {
+	links := messageLinks(in.msg)
+	if len(links) == 0 {
+		return filterHit{}, false
+	}
+	var keys []string
+	for _, link := range links {
+		keys = append(keys, linkKeys(link, true)...)
+	}
+	domain, level, ok := fh.domains.Match(keys)
+	if !ok {
+		return filterHit{}, false
+	}
+	return filterHit{rule: "domain:" + domain, level: level}, true
+}
+
+// HandleBanDomain adds a domain to the blocklist or lists blocked domains
+func (ah *AdminHandler) HandleBanDomain(c tb.Context) error {
+	lang := ah.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if c.Message() == nil || c.Sender() == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Domains.AdminOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	level, rest, ok := parseBanLevel(c.Message().Payload)
+	if !ok {
+		msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Admin.BanInvalidLevel, strings.Join(core.BlacklistLevels, ", ")))
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	if rest == "" {
+		text := msgs.Domains.Usage
+		if domains := ah.domains.List(); len(domains) > 0 {
+			text += "\n\n" + msgs.Domains.ListHeader + strings.Join(domains, "\n")
+		}
+		msg, _ := ah.bot.Send(c.Chat(), text)
+		ah.DeleteAfter(msg, 30*time.Second)
+		return nil
+	}
+	domain, valid := normalizeDomain(rest)
+	if !valid {
+		msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Domains.Invalid, rest))
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	if level == "" {
+		level = core.LevelDeleteWarn
+	}
+	ah.domains.Add(domain, level)
+	msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Domains.Added, domain, level))
+	ah.DeleteAfter(msg, 10*time.Second)
+	ah.LogToAdmin(fmt.Sprintf("🚫 Добавлен запрещённый домен\n\nАдмин: %s\nДомен: `%s`\nУровень: %s", ah.GetUserDisplayName(c.Sender()), domain, level))
+	return nil
+}
+
+// HandleUnbanDomain removes a domain from the blocklist
+func (ah *AdminHandler) HandleUnbanDomain(c tb.Context) error {
+	lang := ah.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if c.Message() == nil || c.Sender() == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Domains.AdminOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	domain, valid := normalizeDomain(strings.TrimSpace(c.Message().Payload))
+	if !valid {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Domains.UnbanUsage)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	text := msgs.Domains.NotFound
+	if ah.domains.Remove(domain) {
+		text = fmt.Sprintf(msgs.Domains.Removed, domain)
+		ah.LogToAdmin(fmt.Sprintf("✅ Удалён запрещённый домен\n\nАдмин: %s\nДомен: `%s`", ah.GetUserDisplayName(c.Sender()), domain))
+	}
+	msg, _ := ah.bot.Send(c.Chat(), text)
+	ah.DeleteAfter(msg, 10*time.Second)
+	return nil
+}
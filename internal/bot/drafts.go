@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"fmt"
+
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// draftRow returns the buttons for leaving a review unfinished, saving it as a draft or dropping it
+func draftRow(msgs *i18n.Messages) []tb.InlineButton {
+	return []tb.InlineButton{{Unique: "rate_draft_save", Text: msgs.Rating.BtnDraftSave}, {Unique: "rate_cancel", Text: msgs.Rating.BtnCancel}}
+}
+
+// Draft returns the unfinished review a user saved
+func (rs *RatingStore) Draft(userID int64) (RatingSession, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	d, ok := rs.Drafts[userID]
+	return d, ok
+}
+
+// SaveDraft keeps an unfinished review until the user comes back to it, replacing an older draft
+func (rs *RatingStore) SaveDraft(userID int64, s RatingSession) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.Drafts == nil {
+		rs.Drafts = make(map[int64]RatingSession)
+	}
+	rs.Drafts[userID] = s
+	rs.save()
+}
+
+// DeleteDraft drops the draft of a user
+func (rs *RatingStore) DeleteDraft(userID int64) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if _, ok := rs.Drafts[userID]; !ok {
+		return
+	}
+	delete(rs.Drafts, userID)
+	rs.save()
+}
+
+// saveDraft stores the session as a draft and ends it
+func (rh *RatingHandler) saveDraft(c tb.Context, session *RatingSession, msgs *i18n.Messages) error {
+	if session.Professor == "" {
+		return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Rating.DraftEmpty})
+	}
+	draft := *session
+	draft.MessageID, draft.Revising = 0, false
+	if draft.Step == StepChooseName {
+		draft.Step = StepEnterName
+	}
+	rh.store.SaveDraft(c.Sender().ID, draft)
+	rh.clearSession(c.Sender().ID)
+	_, _ = rh.bot.Edit(c.Message(), msgs.Rating.DraftSaved)
+	return rh.bot.Respond(c.Callback())
+}
+
+// resumeDraft restores a saved draft and asks again for the step it was left at
+func (rh *RatingHandler) resumeDraft(c tb.Context, msgs *i18n.Messages) {
+	draft, ok := rh.store.Draft(c.Sender().ID)
+	if !ok {
+		rh.startRating(c, msgs)
+		return
+	}
+	session := &draft
+	rh.sessionsMu.Lock()
+	rh.sessions[c.Sender().ID] = session
+	rh.sessionsMu.Unlock()
+
+	_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.DraftRestored, session.Professor))
+	switch session.Step {
+	case StepEnterName:
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.EnterName, cancelKeyboard(msgs))
+	case StepChooseScore:
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.ChooseScore, scoreKeyboard(msgs))
+	case StepChooseCriteria:
+		_, _ = rh.bot.Send(c.Chat(), criterionPrompt(msgs, session), criterionKeyboard(msgs))
+	case StepChooseTag:
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.ChooseTag, tagKeyboard(msgs))
+	case StepEnterCourse:
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.EnterCourse, courseKeyboard(msgs))
+	case StepEnterReview:
+		prompt := msgs.Rating.EnterReview
+		if session.Text != "" {
+			prompt += "\n\n" + fmt.Sprintf(msgs.Rating.DraftText, session.Text)
+		}
+		_, _ = rh.bot.Send(c.Chat(), prompt, cancelKeyboard(msgs))
+	case StepConfirm, StepAttachPhoto:
+		rh.showPreview(c, session, msgs)
+	default:
+		rh.clearSession(c.Sender().ID)
+		rh.startRating(c, msgs)
+	}
+}
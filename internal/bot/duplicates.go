@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+	"unicode/utf8"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// duplicateMinLength is the shortest normalized text treated as a possible copy, so short replies like "thanks" repeat freely
+const duplicateMinLength = 16
+
+// duplicateKind returns the counter kind for a text, keyed by the hash of its normalized form
+func duplicateKind(text string) (string, bool) {
+	text = normalizeText(text)
+	if utf8.RuneCountInString(text) < duplicateMinLength {
+		return "", false
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(text))
+	return fmt.Sprintf("dup:%x", h.Sum64()), true
+}
+
+// checkDuplicates deletes repeated copies of a recent message, mutes users posting it over and over and reports whether the message was handled
+func (fh *FeatureHandler) checkDuplicates(c tb.Context, text string) bool {
+	cs := fh.settings.Get(c.Chat().ID)
+	if cs.DuplicatesDisabled {
+		return false
+	}
+	kind, ok := duplicateKind(text)
+	if !ok {
+		return false
+	}
+	window, muteAfter, mute := time.Duration(cs.DuplicateWindow)*time.Minute, cs.DuplicateMuteAfter, time.Duration(cs.FloodMuteMinutes)*time.Minute
+	if window <= 0 {
+		window = 10 * time.Minute
+	}
+	if muteAfter <= 0 {
+		muteAfter = 3
+	}
+	if mute <= 0 {
+		mute = 10 * time.Minute
+	}
+	m := c.Message()
+	copies := fh.duplicates.hit(floodKey{chatID: c.Chat().ID, kind: kind}, m.ID, window)
+	own := fh.duplicates.hit(floodKey{chatID: c.Chat().ID, userID: m.Sender.ID, kind: kind}, m.ID, window)
+	if len(copies) < 2 {
+		return false
+	}
+
+	fields := logrus.Fields{"chat_id": c.Chat().ID, "user_id": m.Sender.ID, "copies": len(copies)}
+	if err := fh.bot.Delete(m); err != nil {
+		logrus.WithError(err).WithFields(fields).Warn("Failed to delete duplicate message")
+	}
+	logrus.WithFields(fields).Info("Duplicate message deleted")
+	if len(own) == muteAfter {
+		until := time.Now().Add(mute)
+		if err := fh.bot.Restrict(c.Chat(), &tb.ChatMember{User: m.Sender, Rights: tb.Rights{CanSendMessages: false}, RestrictedUntil: until.Unix()}); err != nil {
+			logrus.WithError(err).WithFields(fields).Error("Failed to mute user posting duplicates")
+		}
+		msgs := i18n.Get().T(fh.getLangForUser(m.Sender))
+		fh.publicWarning(c, "duplicate", fmt.Sprintf(msgs.Filter.Duplicate, fh.adminHandler.GetUserDisplayName(m.Sender), int(mute.Minutes())))
+		fh.adminHandler.LogToAdmin(fmt.Sprintf("🔁 Повтор одного и того же сообщения.\n\nПользователь: %s\nКопий: %d за %s\nМут до: %s\nТекст: %s", fh.adminHandler.GetUserDisplayName(m.Sender), len(own), window, until.Format("02.01.2006 15:04"), truncateText(text, 200)))
+	} else if len(copies) == muteAfter && len(own) < muteAfter {
+		fh.adminHandler.LogToAdmin(fmt.Sprintf("🔁 Одно и то же сообщение от разных аккаунтов.\n\nКопий: %d за %s\nПоследний: %s\nТекст: %s", len(copies), window, fh.adminHandler.GetUserDisplayName(m.Sender), truncateText(text, 200)))
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"capybot/internal/core"
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// newbieMediaKinds are the media kinds that can be restricted for new members
+var newbieMediaKinds = []string{"photo", "video", "document", "link"}
+
+// isNewMember reports whether the user was verified recently in this chat
+func (fh *FeatureHandler) isNewMember(chat *tb.Chat, user *tb.User) bool {
+	verified, ok := fh.state.VerifiedAt(int(user.ID))
+	return ok && time.Since(verified) < fh.settings.Get(chat.ID).newbiePeriod()
+}
+
+// isRestrictedMember reports whether the user has not passed verification yet or passed it recently
+func (fh *FeatureHandler) isRestrictedMember(chat *tb.Chat, user *tb.User) bool {
+	return fh.state.IsNewbie(int(user.ID)) || fh.isNewMember(chat, user)
+}
+
+// newbieMediaKind returns the first restricted media kind found in the message
+func newbieMediaKind(m *tb.Message, kinds []string) string {
+	for _, kind := range kinds {
+		switch {
+		case kind == "photo" && m.Photo != nil,
+			kind == "video" && (m.Video != nil || m.VideoNote != nil),
+			kind == "document" && m.Document != nil,
+			kind == "link" && len(messageLinks(m)) > 0:
+			return kind
+		}
+	}
+	return ""
+}
+
+// isForeignBotMention reports whether an entity mentions a bot other than ours
+func (fh *FeatureHandler) isForeignBotMention(m *tb.Message, e tb.MessageEntity) bool {
+	switch e.Type {
+	case tb.EntityMention:
+		name := strings.ToLower(strings.TrimPrefix(m.EntityText(e), "@"))
+		return strings.HasSuffix(name, "bot") && (fh.bot.Me == nil || !strings.EqualFold(name, fh.bot.Me.Username))
+	case tb.EntityTMention:
+		return e.User != nil && e.User.IsBot && (fh.bot.Me == nil || e.User.ID != fh.bot.Me.ID)
+	}
+	return false
+}
+
+// matchEntities finds phone numbers, emails or bot mentions of new members as configured
+func (fh *FeatureHandler) matchEntities(in filterInput) (filterHit, bool) {
+	cs := in.cs
+	if !cs.FilterPhones && !cs.FilterEmails && !cs.FilterBotMentions {
+		return filterHit{}, false
+	}
+	m := in.msg
+	for _, e := range append(slices.Clone(m.Entities), m.CaptionEntities...) {
+		var rule string
+		switch {
+		case e.Type == tb.EntityPhone && cs.FilterPhones:
+			rule = "entity:phone_number"
+		case e.Type == tb.EntityEmail && cs.FilterEmails:
+			rule = "entity:email"
+		case cs.FilterBotMentions && fh.isForeignBotMention(m, e) && (in.dryRun || fh.isNewMember(in.chat, m.Sender)):
+			rule = "entity:bot_mention"
+		default:
+			continue
+		}
+		return filterHit{rule: rule, level: core.LevelDeleteWarn}, true
+	}
+	return filterHit{}, false
+}
+
+// checkNewbieMedia deletes media new members are not allowed to post yet and reports whether the message was handled
+func (fh *FeatureHandler) checkNewbieMedia(c tb.Context) bool {
+	cs := fh.settings.Get(c.Chat().ID)
+	m := c.Message()
+	if len(cs.NewbieMedia) == 0 || !fh.isRestrictedMember(c.Chat(), m.Sender) {
+		return false
+	}
+	kind := newbieMediaKind(m, cs.NewbieMedia)
+	if kind == "" {
+		return false
+	}
+	if err := fh.bot.Delete(m); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": c.Chat().ID, "user_id": m.Sender.ID}).Warn("Failed to delete newbie media")
+	}
+	msgs := i18n.Get().T(fh.getLangForUser(m.Sender))
+	fh.publicWarning(c, "newbie_media", fmt.Sprintf(msgs.Filter.NewbieMedia, fh.adminHandler.GetUserDisplayName(m.Sender)))
+	fh.adminHandler.LogToAdmin(fmt.Sprintf("🐣 Удалено медиа от нового участника.\n\nПользователь: %s\nТип: %s", fh.adminHandler.GetUserDisplayName(m.Sender), kind))
+	return true
+}
@@ -2,18 +2,26 @@ package bot
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
+
+	"capybot/internal/core"
+	"capybot/internal/i18n"
 
 	"github.com/sirupsen/logrus"
 	tb "gopkg.in/telebot.v4"
 )
 
-// FilterMessage checks a text message against the blacklist and applies sanctions
+// FilterMessage checks a message against the media restrictions and the blacklist and applies sanctions
 func (fh *FeatureHandler) FilterMessage(c tb.Context) error {
 	msg := c.Message()
 	if msg == nil || msg.Sender == nil || c.Chat() == nil {
 		return nil
 	}
+	if msg.SenderChat == nil {
+		fh.users.Seen(msg.Sender)
+	}
 
 	// Ignore commands
 	if strings.HasPrefix(msg.Text, "/") {
@@ -24,9 +32,16 @@ func (fh *FeatureHandler) FilterMessage(c tb.Context) error {
 	if c.Chat().ID == fh.adminChatID {
 		return nil
 	}
+	if c.Chat().Type != tb.ChatPrivate {
+		fh.state.TrackMessage(int(msg.Sender.ID), core.MessageRef{ChatID: c.Chat().ID, MessageID: msg.ID})
+	}
+
+	// Skip admins, also anonymous ones, and posts of the linked channel
+	if fh.adminHandler != nil && (fh.adminHandler.IsChatPost(c.Chat(), msg) || fh.adminHandler.IsAdmin(c.Chat(), msg.Sender)) {
+		return nil
+	}
 
-	// Skip admins
-	if fh.adminHandler != nil && fh.adminHandler.IsAdmin(c.Chat(), msg.Sender) {
+	if fh.checkNightMode(c) || fh.checkFlood(c) || fh.checkSlowMode(c) {
 		return nil
 	}
 
@@ -34,56 +49,200 @@ func (fh *FeatureHandler) FilterMessage(c tb.Context) error {
 	logrus.WithFields(logrus.Fields{
 		"chat_id": c.Chat().ID,
 		"user_id": msg.Sender.ID,
-		"message": msg.Text,
+		"message": messageText(msg),
 	}).Debug("Filtering message")
 
-	if fh.blacklist != nil && fh.blacklist.CheckMessage(msg.Text) {
-		// Record violation
-		if fh.adminHandler != nil {
-			fh.adminHandler.AddViolation(msg.Sender.ID)
+	text := messageText(msg)
+	in := filterInput{chat: c.Chat(), msg: msg, text: text, cs: fh.settings.Get(c.Chat().ID)}
+	for _, step := range filterSteps {
+		if step.check != nil {
+			if step.check(fh, c, text) {
+				return nil
+			}
+			continue
 		}
-		violationCount := 0
-		if fh.adminHandler != nil {
-			violationCount = fh.adminHandler.GetViolations(msg.Sender.ID)
+		if hit, found := step.match(fh, in); found && fh.applyFilterHit(c, text, hit) {
+			return nil
 		}
+	}
+	fh.trainHam(c, text)
+	return nil
+}
+
+// Levels of filter hits that are not blacklist levels
+const (
+	levelSpamReview    = "review" // ask the admins whether the message is spam
+	levelModerationLog = "log"    // only report the moderation score to the admin chat
+)
+
+// filterInput is what the content checks look at
+type filterInput struct {
+	chat   *tb.Chat
+	msg    *tb.Message
+	text   string
+	cs     ChatSettings
+	dryRun bool // /testfilter: treat the author as a new member
+}
 
-		// Try to delete original
+// filterHit is a rule a message matched and the level it is enforced with
+type filterHit struct {
+	rule    string
+	level   string
+	percent int // classifier score of a spam review
+}
+
+// filterStep is one check of the message filter; check acts on its own and depends on the chat state, match only
+// looks at the message so /testfilter can run it as well
+type filterStep struct {
+	check func(fh *FeatureHandler, c tb.Context, text string) bool
+	match func(fh *FeatureHandler, in filterInput) (filterHit, bool)
+}
+
+// filterSteps are the checks of FilterMessage in the order they run, the first one to handle the message stops the rest
+var filterSteps = []filterStep{
+	{check: func(fh *FeatureHandler, c tb.Context, _ string) bool { return fh.checkProbation(c) }},
+	{check: func(fh *FeatureHandler, c tb.Context, _ string) bool { return fh.checkChannelPosts(c) }},
+	{check: func(fh *FeatureHandler, c tb.Context, _ string) bool { return fh.checkNewbieMedia(c) }},
+	{match: (*FeatureHandler).matchDomains},
+	{match: (*FeatureHandler).matchPromo},
+	{match: (*FeatureHandler).matchEntities},
+	{check: (*FeatureHandler).checkNewbieLimits},
+	{match: (*FeatureHandler).matchCaps},
+	{check: (*FeatureHandler).checkDuplicates},
+	{match: (*FeatureHandler).matchBlacklist},
+	{match: (*FeatureHandler).matchSimilarSpam},
+	{match: (*FeatureHandler).matchSpamScore},
+	{match: (*FeatureHandler).matchModeration},
+}
+
+// applyFilterHit enforces a matched rule and reports whether the message was handled
+func (fh *FeatureHandler) applyFilterHit(c tb.Context, text string, hit filterHit) bool {
+	switch hit.level {
+	case levelSpamReview:
+		fh.requestSpamReview(c, text, hit.percent)
+		return true
+	case levelModerationLog:
+		fh.adminHandler.LogToAdmin(fmt.Sprintf("🧪 Модерация (%s): подозрительное сообщение.\n\nПользователь: %s\nОценка: %s\nСообщение: `%s`", fh.moderation.Name(), fh.adminHandler.GetUserDisplayName(c.Message().Sender), strings.TrimPrefix(hit.rule, moderationRulePrefix), text))
+		return false
+	}
+	return fh.applyFilterAction(c, hit.rule, hit.level)
+}
+
+// matchBlacklist finds a blacklisted phrase or pattern
+func (fh *FeatureHandler) matchBlacklist(in filterInput) (filterHit, bool) {
+	if fh.blacklist == nil {
+		return filterHit{}, false
+	}
+	entry, found := fh.blacklist.Match(in.text)
+	if !found {
+		return filterHit{}, false
+	}
+	return filterHit{rule: entry.String(), level: entryLevel(in.cs, entry)}, true
+}
+
+// entryLevel returns the level of a matched entry, capping subscribed entries at delete unless the chat trusts them
+func entryLevel(cs ChatSettings, entry core.BlacklistEntry) string {
+	level := entry.Severity()
+	if !entry.Remote || cs.RemoteLevels {
+		return level
+	}
+	switch level {
+	case core.LevelMonitor, core.LevelWarn:
+		return core.LevelMonitor
+	default:
+		return core.LevelDelete
+	}
+}
+
+// messageText returns the text, caption or poll contents of a message
+func messageText(m *tb.Message) string {
+	if m.Poll != nil {
+		parts := []string{m.Poll.Question}
+		for _, o := range m.Poll.Options {
+			parts = append(parts, o.Text)
+		}
+		return strings.Join(parts, "\n")
+	}
+	if m.Text != "" {
+		return m.Text
+	}
+	return m.Caption
+}
+
+// applyFilterAction enforces a severity level for a message that matched a filter rule and reports whether the message was handled
+func (fh *FeatureHandler) applyFilterAction(c tb.Context, rule, level string) bool {
+	msg := c.Message()
+	if level == core.LevelMonitor {
+		fh.adminHandler.LogToAdmin(fmt.Sprintf("👀 Сработало правило в режиме наблюдения.\n\nПользователь: %s\nЧат: %s\nПравило: `%s`\nСообщение: `%s`", fh.adminHandler.GetUserDisplayName(msg.Sender), c.Chat().Title, rule, messageText(msg)))
+		return false
+	}
+	fields := logrus.Fields{"message_id": msg.ID, "chat_id": c.Chat().ID, "user_id": msg.Sender.ID, "level": level}
+
+	if level != core.LevelWarn {
+		if !strings.HasPrefix(rule, bayesRulePrefix) && !strings.HasPrefix(rule, moderationRulePrefix) && !strings.HasPrefix(rule, similarRulePrefix) {
+			fh.spamModel.Train(messageText(msg), true)
+			fh.spamCorpus.Add(messageText(msg))
+		}
 		if err := fh.bot.Delete(msg); err != nil {
-			logrus.WithError(err).WithFields(logrus.Fields{
-				"message_id": msg.ID,
-				"chat_id":    c.Chat().ID,
-				"user_id":    msg.Sender.ID,
-			}).Warn("Failed to delete blacklisted message")
+			logrus.WithError(err).WithFields(fields).Warn("Failed to delete blacklisted message")
 		} else {
-			logrus.WithFields(logrus.Fields{
-				"message_id": msg.ID,
-				"user_id":    msg.Sender.ID,
-				"violations": violationCount,
-			}).Info("Deleted blacklisted message")
+			logrus.WithFields(fields).Info("Deleted blacklisted message")
 		}
+	}
 
-		if violationCount >= 2 {
-			// Ban after the second violation
-			if fh.adminHandler != nil {
-				if err := fh.adminHandler.BanUser(c.Chat(), msg.Sender); err != nil {
-					logrus.WithError(err).WithFields(logrus.Fields{
-						"chat_id": c.Chat().ID,
-						"user_id": msg.Sender.ID,
-					}).Error("Failed to ban user for repeated violations")
-				} else {
-					fh.adminHandler.ClearViolations(msg.Sender.ID)
-					banLog := fmt.Sprintf("🔨 Выдан бан за спам.\n\nЗабанен: %s\nНарушений: %d", fh.adminHandler.GetUserDisplayName(msg.Sender), violationCount)
-					fh.adminHandler.LogToAdmin(banLog)
-					logrus.WithFields(logrus.Fields{"user_id": msg.Sender.ID, "violations": violationCount}).Info("User banned after violations")
-				}
-			}
-			return nil
-		}
+	switch level {
+	case core.LevelDelete:
+		fh.notifyViolation(c, rule, level, 0)
+		fh.adminHandler.LogToAdmin(fmt.Sprintf("🧹 Удалено сообщение по фильтру.\n\nПользователь: %s\nПравило: `%s`\nСообщение: `%s`", fh.adminHandler.GetUserDisplayName(msg.Sender), rule, messageText(msg)))
+		return true
+	case core.LevelBan:
+		fh.banForViolation(c, rule, 0)
+		return true
+	}
 
-		if fh.adminHandler != nil {
-			logMsg := fmt.Sprintf("⚠️ Обнаружено нарушение.\n\nПользователь: %s\nНарушение: #%d\nСообщение: `%s`", fh.adminHandler.GetUserDisplayName(msg.Sender), violationCount, msg.Text)
-			fh.adminHandler.LogToAdmin(logMsg)
-		}
+	// Record violation
+	violationCount := fh.adminHandler.AddViolation(c.Chat().ID, msg.Sender.ID, nil, rule)
+	if violationCount >= fh.settings.Get(c.Chat().ID).warnLimit() {
+		// Ban once the chat limit of strikes is reached
+		fh.banForViolation(c, rule, violationCount)
+		return true
 	}
-	return nil
+
+	lang := fh.getLangForUser(msg.Sender)
+	msgs := i18n.Get().T(lang)
+	if !fh.notifyViolation(c, rule, level, violationCount) {
+		fh.publicWarning(c, level, fmt.Sprintf(msgs.Filter.Warning, fh.adminHandler.GetUserDisplayName(msg.Sender)))
+	}
+
+	logMsg := fmt.Sprintf("⚠️ Обнаружено нарушение.\n\nПользователь: %s\nНарушение: #%d\nПравило: `%s`\nСообщение: `%s`", fh.adminHandler.GetUserDisplayName(msg.Sender), violationCount, rule, messageText(msg))
+	fh.adminHandler.LogToAdmin(logMsg)
+	return true
+}
+
+// silentActions are the actions whose public warning can be turned off
+var silentActions = []string{core.LevelWarn, core.LevelDeleteWarn, "flood", "media_flood", "duplicate", "newbie_media"}
+
+// publicWarning posts a short-lived warning in the chat unless the action is silent there
+func (fh *FeatureHandler) publicWarning(c tb.Context, action, text string) {
+	if slices.Contains(fh.settings.Get(c.Chat().ID).SilentActions, action) {
+		return
+	}
+	warning, _ := fh.bot.Send(c.Chat(), text)
+	fh.adminHandler.DeleteAfter(warning, 30*time.Second)
+}
+
+// banForViolation bans the author of a message that matched a filter rule
+func (fh *FeatureHandler) banForViolation(c tb.Context, rule string, violationCount int) {
+	msg := c.Message()
+	if err := fh.adminHandler.BanUser(c.Chat(), msg.Sender); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"chat_id": c.Chat().ID,
+			"user_id": msg.Sender.ID,
+		}).Error("Failed to ban user for blacklisted message")
+		return
+	}
+	fh.adminHandler.ClearViolations(c.Chat().ID, msg.Sender.ID)
+	banLog := fmt.Sprintf("🔨 Выдан бан за спам.\n\nЗабанен: %s\nНарушений: %d\nПравило: `%s`", fh.adminHandler.GetUserDisplayName(msg.Sender), violationCount, rule)
+	fh.adminHandler.LogToAdmin(banLog)
+	logrus.WithFields(logrus.Fields{"user_id": msg.Sender.ID, "violations": violationCount}).Info("User banned for blacklisted message")
 }
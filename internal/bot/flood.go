@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// floodKey identifies a counter of one user in one chat
+type floodKey struct {
+	chatID int64
+	userID int64
+	kind   string
+}
+
+// floodHit is a counted message
+type floodHit struct {
+	at        time.Time
+	messageID int
+}
+
+// floodCounter counts messages per key over a sliding window
+type floodCounter struct {
+	mu   sync.Mutex
+	hits map[floodKey][]floodHit
+}
+
+// newFloodCounter creates an empty counter
+func newFloodCounter() *floodCounter {
+	return &floodCounter{hits: make(map[floodKey][]floodHit)}
+}
+
+// hit records a message and returns the IDs of the key's messages that fall into the window
+func (fc *floodCounter) hit(key floodKey, messageID int, window time.Duration) []int {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	now := time.Now()
+	hits := fc.hits[key]
+	i := 0
+	for i < len(hits) && now.Sub(hits[i].at) > window {
+		i++
+	}
+	hits = append(hits[i:], floodHit{at: now, messageID: messageID})
+	fc.hits[key] = hits
+	if len(fc.hits) > 4096 {
+		fc.prune(now, window)
+	}
+	ids := make([]int, len(hits))
+	for i, h := range hits {
+		ids[i] = h.messageID
+	}
+	return ids
+}
+
+// prune forgets keys without hits in the window
+func (fc *floodCounter) prune(now time.Time, window time.Duration) {
+	for key, hits := range fc.hits {
+		if now.Sub(hits[len(hits)-1].at) > window {
+			delete(fc.hits, key)
+		}
+	}
+}
+
+// checkFlood mutes users sending messages too fast, removes the burst and reports whether the message was handled
+func (fh *FeatureHandler) checkFlood(c tb.Context) bool {
+	cs := fh.settings.Get(c.Chat().ID)
+	m := c.Message()
+	if cs.FloodDisabled {
+		return false
+	}
+	limit, window, mute := cs.FloodLimit, time.Duration(cs.FloodWindow)*time.Second, time.Duration(cs.FloodMuteMinutes)*time.Minute
+	if limit <= 0 {
+		limit = 8
+	}
+	if window <= 0 {
+		window = 10 * time.Second
+	}
+	if mute <= 0 {
+		mute = 10 * time.Minute
+	}
+	if fh.onProbation(c.Chat(), m.Sender) {
+		limit = max(2, limit/2)
+	}
+	ids := fh.messageFlood.hit(floodKey{chatID: c.Chat().ID, userID: m.Sender.ID, kind: "message"}, m.ID, window)
+	if len(ids) <= limit {
+		return false
+	}
+	fields := logrus.Fields{"chat_id": c.Chat().ID, "user_id": m.Sender.ID}
+	if len(ids) > limit+1 {
+		// The user is already muted, clean up messages that slipped through
+		if err := fh.bot.Delete(m); err != nil {
+			logrus.WithError(err).WithFields(fields).Warn("Failed to delete flood message")
+		}
+		return true
+	}
+
+	until := time.Now().Add(mute)
+	if err := fh.bot.Restrict(c.Chat(), &tb.ChatMember{User: m.Sender, Rights: tb.Rights{CanSendMessages: false}, RestrictedUntil: until.Unix()}); err != nil {
+		logrus.WithError(err).WithFields(fields).Error("Failed to mute flooding user")
+	}
+	for _, id := range ids {
+		if err := fh.bot.Delete(&tb.StoredMessage{MessageID: fmt.Sprint(id), ChatID: c.Chat().ID}); err != nil {
+			logrus.WithError(err).WithFields(fields).WithField("message_id", id).Warn("Failed to delete flood message")
+		}
+	}
+
+	msgs := i18n.Get().T(fh.getLangForUser(m.Sender))
+	fh.publicWarning(c, "flood", fmt.Sprintf(msgs.Filter.Flood, fh.adminHandler.GetUserDisplayName(m.Sender), int(mute.Minutes())))
+	fh.adminHandler.LogToAdmin(fmt.Sprintf("🌊 Флуд сообщениями.\n\nПользователь: %s\nСообщений: %d за %s\nМут до: %s", fh.adminHandler.GetUserDisplayName(m.Sender), len(ids), window, until.Format("02.01.2006 15:04")))
+	logrus.WithFields(fields).WithField("messages", len(ids)).Info("User muted for flooding")
+	return true
+}
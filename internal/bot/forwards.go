@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"slices"
+	"strconv"
+
+	"capybot/internal/core"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// forwardedChannel returns the channel a message was forwarded from
+func forwardedChannel(m *tb.Message) *tb.Chat {
+	if m.Origin != nil && m.Origin.Type == "channel" && m.Origin.Chat != nil {
+		return m.Origin.Chat
+	}
+	if m.OriginalChat != nil && m.OriginalChat.Type == tb.ChatChannel {
+		return m.OriginalChat
+	}
+	return nil
+}
+
+// channelRuleName names a channel in filter logs
+func channelRuleName(chat *tb.Chat) string {
+	if chat.Username != "" {
+		return "@" + chat.Username
+	}
+	return strconv.FormatInt(chat.ID, 10)
+}
+
+// checkChannelPosts removes channel forwards and posts sent on behalf of a channel and reports whether the message was handled
+func (fh *FeatureHandler) checkChannelPosts(c tb.Context) bool {
+	cs := fh.settings.Get(c.Chat().ID)
+	m := c.Message()
+	allowed := func(ch *tb.Chat) bool {
+		return ch.ID == c.Chat().ID || (ch.Username != "" && slices.Contains(cs.PromoAllowlist, promoKey(ch.Username)))
+	}
+	// Posts from the linked channel are forwarded automatically
+	if cs.FilterSenderChat && m.SenderChat != nil && !m.AutomaticForward && !allowed(m.SenderChat) {
+		fh.applyFilterAction(c, "sender_chat:"+channelRuleName(m.SenderChat), core.LevelDelete)
+		return true
+	}
+	if ch := forwardedChannel(m); cs.FilterChannelForwards && ch != nil && !allowed(ch) {
+		fh.applyFilterAction(c, "forward:"+channelRuleName(ch), core.LevelDelete)
+		return true
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package bot
 
 import (
+	"time"
+
 	"capybot/internal/core"
 
 	tb "gopkg.in/telebot.v4"
@@ -21,9 +23,19 @@ type FeatureHandlerInterface interface {
 	SetUserRestriction(chat *tb.Chat, user *tb.User, allowAll bool)
 	HandleUserJoined(c tb.Context) error
 	HandleUserLeft(c tb.Context) error
+	HandleJoinRequest(c tb.Context) error
 	HandleStudent(c tb.Context) error
 	HandleGuest(c tb.Context) error
 	HandleAds(c tb.Context) error
+	HandleConfirm(c tb.Context) error
+	HandleAddQuestion(c tb.Context) error
+	HandleDelQuestion(c tb.Context) error
+	HandleListQuestions(c tb.Context) error
+	HandleTrust(c tb.Context) error
+	HandleUntrust(c tb.Context) error
+	HandleVerifyLog(c tb.Context) error
+	HandleWhois(c tb.Context) error
+	HandleTestFilter(c tb.Context) error
 	HandlePing(c tb.Context) error
 	HandleStart(c tb.Context) error
 	HandlePrivateMessage(c tb.Context) error
@@ -31,4 +43,6 @@ type FeatureHandlerInterface interface {
 	RegisterQuizHandlers(bot *tb.Bot)
 	CreateQuizHandler(i int, q QuestionInterface, btn tb.InlineButton) func(tb.Context) error
 	FilterMessage(c tb.Context) error
+	FilterMedia(c tb.Context) error
+	NightModeTick(now time.Time)
 }
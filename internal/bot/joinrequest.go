@@ -0,0 +1,131 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// HandleJoinRequest sends the applicant the verification in private and keeps the request pending
+func (fh *FeatureHandler) HandleJoinRequest(c tb.Context) error {
+	req := c.ChatJoinRequest()
+	if req == nil || req.Sender == nil || req.Chat == nil {
+		return nil
+	}
+	u := req.Sender
+	if fh.trusted.IsTrusted(req.Chat.ID, u.ID) {
+		fh.recordVerification(req.Chat, u, VerifyEvent{Outcome: verifyTrusted})
+		fh.markApprovedJoin(u.ID)
+		if err := fh.bot.ApproveJoinRequest(req.Chat, u); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{"chat_id": req.Chat.ID, "user_id": u.ID}).Error("Failed to approve join request of trusted user")
+			return nil
+		}
+		fh.adminHandler.LogEvent(req.Chat, eventTrusted, u, fmt.Sprintf("🤝 Заявка доверенного пользователя одобрена без проверки.\n\nПользователь: %s\nЧат: %s", fh.adminHandler.GetUserDisplayName(u), req.Chat.Title))
+		return nil
+	}
+	if fh.checkCAS(req.Chat, u) {
+		if err := fh.bot.DeclineJoinRequest(req.Chat, u); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{"chat_id": req.Chat.ID, "user_id": u.ID}).Warn("Failed to decline join request of CAS listed user")
+		}
+		return nil
+	}
+	kicked, suspicious := fh.screenProfile(req.Chat, u, true)
+	if kicked {
+		return nil
+	}
+	lang := fh.getLangForUser(u)
+	msgs := i18n.Get().T(lang)
+
+	risk, score := fh.assessRisk(u, true)
+	if suspicious {
+		risk = RiskHigh
+	}
+	fh.state.SetNewbie(int(u.ID))
+	fh.state.SetRisk(int(u.ID), int(risk))
+	fh.state.SetJoinRequest(int(u.ID), req.Chat.ID)
+	fh.state.InitUser(int(u.ID))
+	fh.recordVerification(req.Chat, u, VerifyEvent{Outcome: verifyJoinRequest, Score: fmt.Sprintf("%s, %d", risk, score)})
+
+	kb := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{{Unique: "student", Text: msgs.Buttons.Student}}}}
+	if risk == RiskLow {
+		kb = &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{{Unique: "confirm", Text: msgs.Buttons.Confirm}}}}
+	}
+	txt := fmt.Sprintf(msgs.JoinRequest.Greeting, req.Chat.Title) + "\n\n" + msgs.Welcome.ChooseOption
+	dm := &tb.Chat{ID: req.UserChatID}
+	if req.UserChatID == 0 {
+		dm = &tb.Chat{ID: u.ID}
+	}
+	if _, err := fh.bot.Send(dm, txt, kb); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": req.Chat.ID, "user_id": u.ID}).Error("Failed to send join request quiz")
+	}
+
+	link := ""
+	if req.InviteLink != nil {
+		link = "\nСсылка: " + req.InviteLink.InviteLink
+	}
+	logMsg := fmt.Sprintf("📨 Новая заявка на вступление.\n\nПользователь: %s\nЧат: %s\nРиск: %s (%d)%s", fh.adminHandler.GetUserDisplayName(u), req.Chat.Title, risk, score, link)
+	fh.adminHandler.LogEvent(req.Chat, eventJoinRequest, u, logMsg)
+	return nil
+}
+
+// targetChat returns the chat a verification result applies to
+func (fh *FeatureHandler) targetChat(chat *tb.Chat, user *tb.User) *tb.Chat {
+	if chatID, ok := fh.state.JoinRequest(int(user.ID)); ok {
+		return &tb.Chat{ID: chatID}
+	}
+	return chat
+}
+
+// admitUser lets a verified user write, approving a pending join request if there is one, and trusts them in that chat from now on
+func (fh *FeatureHandler) admitUser(chat *tb.Chat, user *tb.User) {
+	fh.state.SetVerified(int(user.ID))
+	chatID, ok := fh.state.JoinRequest(int(user.ID))
+	if !ok {
+		fh.trusted.TrustIn(chat.ID, user.ID)
+		fh.SetUserRestriction(chat, user, true)
+		return
+	}
+	fh.trusted.TrustIn(chatID, user.ID)
+	fh.state.ClearJoinRequest(int(user.ID))
+	fh.markApprovedJoin(user.ID)
+	if err := fh.bot.ApproveJoinRequest(&tb.Chat{ID: chatID}, user); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": chatID, "user_id": user.ID}).Error("Failed to approve join request")
+		return
+	}
+	fh.adminHandler.LogEvent(&tb.Chat{ID: chatID}, eventApproved, user, fmt.Sprintf("✅ Заявка на вступление одобрена.\n\nПользователь: %s", fh.adminHandler.GetUserDisplayName(user)))
+}
+
+// rejectUser declines a pending join request after failed verification
+func (fh *FeatureHandler) rejectUser(user *tb.User) {
+	chatID, ok := fh.state.JoinRequest(int(user.ID))
+	if !ok {
+		return
+	}
+	fh.state.ClearJoinRequest(int(user.ID))
+	fh.state.ClearNewbie(int(user.ID))
+	if err := fh.bot.DeclineJoinRequest(&tb.Chat{ID: chatID}, user); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": chatID, "user_id": user.ID}).Error("Failed to decline join request")
+		return
+	}
+	fh.adminHandler.LogToAdmin(fmt.Sprintf("🚪 Заявка на вступление отклонена.\n\nПользователь: %s", fh.adminHandler.GetUserDisplayName(user)))
+}
+
+// markApprovedJoin remembers a user whose join request was approved so the join message skips the quiz
+func (fh *FeatureHandler) markApprovedJoin(userID int64) {
+	fh.approvedMu.Lock()
+	fh.approvedJoins[userID] = time.Now()
+	fh.approvedMu.Unlock()
+}
+
+// takeApprovedJoin reports and forgets a recent join request approval
+func (fh *FeatureHandler) takeApprovedJoin(userID int64) bool {
+	fh.approvedMu.Lock()
+	defer fh.approvedMu.Unlock()
+	at, ok := fh.approvedJoins[userID]
+	delete(fh.approvedJoins, userID)
+	return ok && time.Since(at) < 10*time.Minute
+}
@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"capybot/internal/core"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// isEmoji reports whether the rune is a pictographic emoji; modifiers and joiners are not counted
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F1E6 && r <= 0x1F1FF: // regional indicators
+		return true
+	case r >= 0x1F300 && r <= 0x1FAFF:
+		return !(r >= 0x1F3FB && r <= 0x1F3FF) // skin tones
+	case r >= 0x2600 && r <= 0x27BF:
+		return true
+	case r >= 0x1F000 && r <= 0x1F2FF:
+		return true
+	}
+	return false
+}
+
+// countEmoji counts emoji in the text, premium emoji included through their fallback characters
+func countEmoji(text string) int {
+	n := 0
+	for _, r := range text {
+		if isEmoji(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// checkNewbieLimits deletes overly long or emoji-heavy messages of new members and reports whether the message was handled
+func (fh *FeatureHandler) checkNewbieLimits(c tb.Context, text string) bool {
+	cs := fh.settings.Get(c.Chat().ID)
+	if cs.NewbieMaxLength <= 0 && cs.NewbieMaxEmoji <= 0 {
+		return false
+	}
+	if !fh.isRestrictedMember(c.Chat(), c.Message().Sender) {
+		return false
+	}
+	var rule string
+	if n := utf8.RuneCountInString(text); cs.NewbieMaxLength > 0 && n > cs.NewbieMaxLength {
+		rule = fmt.Sprintf("length:%d", n)
+	} else if n := countEmoji(text); cs.NewbieMaxEmoji > 0 && n > cs.NewbieMaxEmoji {
+		rule = fmt.Sprintf("emoji:%d", n)
+	}
+	if rule == "" {
+		return false
+	}
+	fh.applyFilterAction(c, rule, core.LevelDelete)
+	return true
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// mediaKind names the flood-controlled media type of a message
+func mediaKind(m *tb.Message) string {
+	switch {
+	case m.Sticker != nil:
+		return "sticker"
+	case m.Animation != nil:
+		return "animation"
+	}
+	return ""
+}
+
+// checkMediaFlood deletes stickers and GIFs above the chat limit and reports whether the message was handled
+func (fh *FeatureHandler) checkMediaFlood(c tb.Context) bool {
+	m := c.Message()
+	kind := mediaKind(m)
+	cs := fh.settings.Get(c.Chat().ID)
+	if kind == "" || cs.MediaFloodDisabled {
+		return false
+	}
+	limit, window := cs.MediaFloodLimit, time.Duration(cs.MediaFloodWindow)*time.Second
+	if limit <= 0 {
+		limit = 5
+	}
+	if window <= 0 {
+		window = time.Minute
+	}
+	count := len(fh.mediaFlood.hit(floodKey{chatID: c.Chat().ID, userID: m.Sender.ID, kind: kind}, m.ID, window))
+	if count <= limit {
+		return false
+	}
+	if err := fh.bot.Delete(m); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": c.Chat().ID, "user_id": m.Sender.ID}).Warn("Failed to delete media flood message")
+	}
+	// Warn once per burst
+	if count == limit+1 {
+		msgs := i18n.Get().T(fh.getLangForUser(m.Sender))
+		fh.publicWarning(c, "media_flood", fmt.Sprintf(msgs.Filter.MediaFlood, fh.adminHandler.GetUserDisplayName(m.Sender)))
+		fh.adminHandler.LogToAdmin(fmt.Sprintf("🌊 Флуд стикерами/GIF.\n\nПользователь: %s\nТип: %s\nЛимит: %d за %s", fh.adminHandler.GetUserDisplayName(m.Sender), kind, limit, window))
+	}
+	return true
+}
+
+// FilterMedia applies flood control to stickers and GIFs before the regular message filter
+func (fh *FeatureHandler) FilterMedia(c tb.Context) error {
+	m := c.Message()
+	if m == nil || m.Sender == nil || c.Chat() == nil || c.Chat().Type == tb.ChatPrivate || c.Chat().ID == fh.adminChatID {
+		return nil
+	}
+	if fh.adminHandler.IsChatPost(c.Chat(), m) || fh.adminHandler.IsAdmin(c.Chat(), m.Sender) {
+		return nil
+	}
+	if fh.checkMediaFlood(c) {
+		return nil
+	}
+	return fh.FilterMessage(c)
+}
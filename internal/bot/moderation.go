@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"capybot/internal/core"
+
+	"github.com/sirupsen/logrus"
+)
+
+// moderationRulePrefix marks filter actions taken on external moderation scores
+const moderationRulePrefix = "moderation:"
+
+// moderationClient is shared by the moderation providers
+var moderationClient = &http.Client{Timeout: 5 * time.Second}
+
+// NewModerationProvider returns the configured provider, or nil when moderation is not set up
+func NewModerationProvider(name, apiKey string) core.ModerationProvider {
+	if apiKey == "" {
+		return nil
+	}
+	switch name {
+	case "perspective":
+		return &perspectiveProvider{apiKey: apiKey}
+	case "openai":
+		return &openAIProvider{apiKey: apiKey}
+	}
+	return nil
+}
+
+// postJSON sends a JSON request and decodes the JSON answer
+func postJSON(url string, headers map[string]string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := moderationClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// worstCategory picks the highest scored category
+func worstCategory(scores map[string]float64) (string, float64) {
+	var category string
+	var score float64
+	for c, s := range scores {
+		if s > score || category == "" {
+			category, score = c, s
+		}
+	}
+	return category, score
+}
+
+// perspectiveProvider uses the Google Perspective API
+type perspectiveProvider struct {
+	apiKey string
+}
+
+// Name returns the provider name
+func (p *perspectiveProvider) Name() string { return "perspective" }
+
+// Moderate scores text for toxicity, insults, threats and identity attacks
+func (p *perspectiveProvider) Moderate(text string) (string, float64, error) {
+	attrs := map[string]struct{}{"TOXICITY": {}, "INSULT": {}, "THREAT": {}, "IDENTITY_ATTACK": {}}
+	in := map[string]any{
+		"comment":             map[string]string{"text": text},
+		"requestedAttributes": attrs,
+		"doNotStore":          true,
+	}
+	var out struct {
+		AttributeScores map[string]struct {
+			SummaryScore struct {
+				Value float64 `json:"value"`
+			} `json:"summaryScore"`
+		} `json:"attributeScores"`
+	}
+	url := "https://commentanalyzer.googleapis.com/v1alpha1/comments:analyze?key=" + p.apiKey
+	if err := postJSON(url, nil, in, &out); err != nil {
+		return "", 0, err
+	}
+	scores := make(map[string]float64, len(out.AttributeScores))
+	for attr, s := range out.AttributeScores {
+		scores[attr] = s.SummaryScore.Value
+	}
+	category, score := worstCategory(scores)
+	return category, score, nil
+}
+
+// openAIProvider uses the OpenAI moderation endpoint
+type openAIProvider struct {
+	apiKey string
+}
+
+// Name returns the provider name
+func (p *openAIProvider) Name() string { return "openai" }
+
+// Moderate scores text across the OpenAI moderation categories
+func (p *openAIProvider) Moderate(text string) (string, float64, error) {
+	in := map[string]string{"model": "omni-moderation-latest", "input": text}
+	var out struct {
+		Results []struct {
+			CategoryScores map[string]float64 `json:"category_scores"`
+		} `json:"results"`
+	}
+	if err := postJSON("https://api.openai.com/v1/moderations", map[string]string{"Authorization": "Bearer " + p.apiKey}, in, &out); err != nil {
+		return "", 0, err
+	}
+	if len(out.Results) == 0 {
+		return "", 0, fmt.Errorf("empty moderation result")
+	}
+	category, score := worstCategory(out.Results[0].CategoryScores)
+	return category, score, nil
+}
+
+// matchModeration scores a message with the external provider and picks logging, a warning or deletion by the chat thresholds
+func (fh *FeatureHandler) matchModeration(in filterInput) (filterHit, bool) {
+	cs := in.cs
+	if fh.moderation == nil || !cs.ModerationEnabled || in.text == "" {
+		return filterHit{}, false
+	}
+	category, score, err := fh.moderation.Moderate(in.text)
+	if err != nil {
+		logrus.WithError(err).WithField("provider", fh.moderation.Name()).Warn("Moderation request failed")
+		return filterHit{}, false
+	}
+	logAt, warnAt, deleteAt := cs.ModerationLogScore, cs.ModerationWarnScore, cs.ModerationDeleteScore
+	if logAt <= 0 {
+		logAt = 50
+	}
+	if warnAt <= 0 {
+		warnAt = 70
+	}
+	if deleteAt <= 0 {
+		deleteAt = 90
+	}
+	percent := int(score * 100)
+	rule := fmt.Sprintf("%s%s %d%%", moderationRulePrefix, category, percent)
+	switch {
+	case percent >= deleteAt:
+		return filterHit{rule: rule, level: core.LevelDelete}, true
+	case percent >= warnAt:
+		return filterHit{rule: rule, level: core.LevelWarn}, true
+	case percent >= logAt:
+		return filterHit{rule: rule, level: levelModerationLog}, true
+	}
+	return filterHit{}, false
+}
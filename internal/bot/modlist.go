@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// modListPageSize is the number of users shown per page of /banlist and /mutelist
+const modListPageSize = 10
+
+// modListKinds maps a list to the action kinds it shows
+var modListKinds = map[string][]string{
+	actionBan:  {actionBan, actionSpamBan},
+	actionMute: {actionMute},
+}
+
+// modListScope returns the chat a list covers, 0 in the admin chat where every chat is shown
+func (ah *AdminHandler) modListScope(chat *tb.Chat) int64 {
+	if chat.ID == ah.adminChatID {
+		return 0
+	}
+	return chat.ID
+}
+
+// renderModListPage formats one page of active bans or mutes with a lift button per user
+func (ah *AdminHandler) renderModListPage(msgs *i18n.Messages, list string, actions []ModAction, page int) (string, *tb.ReplyMarkup) {
+	header, empty, lift := msgs.Moderation.BanListHeader, msgs.Moderation.BanListEmpty, "🔓"
+	if list == actionMute {
+		header, empty, lift = msgs.Moderation.MuteListHeader, msgs.Moderation.MuteListEmpty, "🔊"
+	}
+	if len(actions) == 0 {
+		return empty, nil
+	}
+	pages := (len(actions) + modListPageSize - 1) / modListPageSize
+	page = max(0, min(page, pages-1))
+	from := page * modListPageSize
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(header, len(actions)))
+	var rows [][]tb.InlineButton
+	var row []tb.InlineButton
+	for i, a := range actions[from:min(from+modListPageSize, len(actions))] {
+		n := from + i + 1
+		sb.WriteString(fmt.Sprintf("\n\n%d. %s · %s\n   %s", n, a.UserName, formatUntil(a.Until, msgs), fmt.Sprintf(msgs.Moderation.ListIssuedBy, a.By, a.At.Format("02.01.2006 15:04"))))
+		if a.Reason != "" {
+			sb.WriteString("\n   " + fmt.Sprintf(msgs.Moderation.Reason, a.Reason))
+		}
+		row = append(row, tb.InlineButton{Unique: "modlist_lift", Data: fmt.Sprintf("%d|%d", a.ID, page), Text: fmt.Sprintf("%s %d", lift, n)})
+		if len(row) == 5 {
+			rows, row = append(rows, row), nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	if pages > 1 {
+		sb.WriteString("\n\n" + fmt.Sprintf(msgs.Admin.ListPage, page+1, pages))
+		var nav []tb.InlineButton
+		if page > 0 {
+			nav = append(nav, tb.InlineButton{Unique: "modlist_page", Data: fmt.Sprintf("%s|%d", list, page-1), Text: "◀️"})
+		}
+		if page < pages-1 {
+			nav = append(nav, tb.InlineButton{Unique: "modlist_page", Data: fmt.Sprintf("%s|%d", list, page+1), Text: "▶️"})
+		}
+		rows = append(rows, nav)
+	}
+	return sb.String(), &tb.ReplyMarkup{InlineKeyboard: rows}
+}
+
+// showModList sends or updates a page of a list
+func (ah *AdminHandler) showModList(c tb.Context, list string, page int) {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	actions := ah.actions.Active(ah.modListScope(c.Chat()), time.Now(), modListKinds[list]...)
+	text, markup := ah.renderModListPage(msgs, list, actions, page)
+	if c.Callback() != nil {
+		_, _ = ah.bot.Edit(c.Callback().Message, text, markup)
+		return
+	}
+	_, _ = ah.bot.Send(c.Chat(), text, markup)
+}
+
+// HandleBanList lists users banned by the bot that are still banned
+func (ah *AdminHandler) HandleBanList(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if !ah.requireModerator(c, msgs) {
+		return nil
+	}
+	ah.showModList(c, actionBan, 0)
+	return nil
+}
+
+// HandleMuteList lists users muted by the bot that are still muted
+func (ah *AdminHandler) HandleMuteList(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if !ah.requireModerator(c, msgs) {
+		return nil
+	}
+	ah.showModList(c, actionMute, 0)
+	return nil
+}
+
+// HandleModListPage switches the page of a /banlist or /mutelist message
+func (ah *AdminHandler) HandleModListPage(c tb.Context) error {
+	cb := c.Callback()
+	list, pageText, _ := strings.Cut(cb.Data, "|")
+	page, err := strconv.Atoi(pageText)
+	if _, known := modListKinds[list]; err != nil || !known || !ah.IsModerator(c.Chat(), c.Sender()) {
+		return ah.bot.Respond(cb)
+	}
+	ah.showModList(c, list, page)
+	return ah.bot.Respond(cb)
+}
+
+// HandleModListLift unbans or unmutes the user behind a list button and refreshes the page
+func (ah *AdminHandler) HandleModListLift(c tb.Context) error {
+	cb := c.Callback()
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	idText, pageText, _ := strings.Cut(cb.Data, "|")
+	id, err := strconv.Atoi(idText)
+	if err != nil {
+		return ah.bot.Respond(cb)
+	}
+	page, _ := strconv.Atoi(pageText)
+	a, ok := ah.actions.Get(id)
+	if !ok || a.Lifted || a.Undone {
+		return ah.bot.Respond(cb, &tb.CallbackResponse{Text: msgs.Moderation.ListLiftGone})
+	}
+	chat := &tb.Chat{ID: a.ChatID}
+	if !ah.IsModerator(chat, c.Sender()) {
+		return ah.bot.Respond(cb, &tb.CallbackResponse{Text: msgs.Moderation.AdminOnly})
+	}
+	if err := ah.revert(chat, a); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": a.ChatID, "user_id": a.UserID, "kind": a.Kind}).Error("Failed to lift action from list")
+		return ah.bot.Respond(cb, &tb.CallbackResponse{Text: msgs.Moderation.ListLiftFailed})
+	}
+	ah.actions.Lift(a.Kind, a.ChatID, a.UserID)
+
+	list, kind, title := actionBan, actionUnban, "🔓 Бан снят"
+	if a.Kind == actionMute {
+		list, kind, title = actionMute, actionUnmute, "🔊 Мут снят"
+	}
+	ah.actions.Record(ModAction{Kind: kind, ChatID: a.ChatID, UserID: a.UserID, UserName: a.UserName, ByID: c.Sender().ID, By: ah.GetUserDisplayName(c.Sender())})
+	ah.LogToAdmin(fmt.Sprintf("%s\n\nАдмин: %s\nПользователь: %s", title, ah.GetUserDisplayName(c.Sender()), a.UserName))
+	ah.showModList(c, list, page)
+	return ah.bot.Respond(cb, &tb.CallbackResponse{Text: msgs.Moderation.ListLifted})
+}
@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// memberRights are the permissions of a regular member who may post anything
+var memberRights = tb.Rights{CanSendMessages: true, CanSendPhotos: true, CanSendVideos: true, CanSendVideoNotes: true, CanSendVoiceNotes: true, CanSendPolls: true, CanSendOther: true, CanAddPreviews: true, CanInviteUsers: true}
+
+// formatUntil returns the end of a temporary action or a mark for a permanent one
+func formatUntil(until time.Time, msgs *i18n.Messages) string {
+	if until.IsZero() {
+		return msgs.Moderation.Forever
+	}
+	return until.Format("02.01.2006 15:04")
+}
+
+// HandleMute restricts the replied or named user, for a time when the payload starts with a duration like 2h
+func (ah *AdminHandler) HandleMute(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if !ah.requireModerator(c, msgs) {
+		return nil
+	}
+	target, payload := ah.commandTarget(c, msgs)
+	if target == nil {
+		return nil
+	}
+	d, reason := splitDuration(payload)
+	var until time.Time
+	if d > 0 {
+		until = time.Now().Add(d)
+	}
+	member := &tb.ChatMember{User: target, Rights: tb.Rights{CanSendMessages: false}, RestrictedUntil: tb.Forever()}
+	if !until.IsZero() {
+		member.RestrictedUntil = until.Unix()
+	}
+	if err := ah.bot.Restrict(c.Chat(), member); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": c.Chat().ID, "user_id": target.ID}).Error("Failed to mute user")
+		return nil
+	}
+	ah.actions.Lift(actionMute, c.Chat().ID, target.ID)
+	ah.actions.Record(ModAction{Kind: actionMute, ChatID: c.Chat().ID, UserID: target.ID, UserName: ah.GetUserDisplayName(target), ByID: c.Sender().ID, By: ah.GetUserDisplayName(c.Sender()), Reason: reason, Until: until})
+	ah.scheduleExpiry(until)
+
+	userMsgs := i18n.Get().T(ah.getLangForUser(target))
+	text := fmt.Sprintf(userMsgs.Moderation.Muted, ah.GetUserDisplayName(target), formatUntil(until, userMsgs))
+	if reason != "" {
+		text += "\n" + fmt.Sprintf(userMsgs.Moderation.Reason, reason)
+	}
+	_, _ = ah.bot.Send(c.Chat(), text)
+	ah.LogToAdmin(fmt.Sprintf("🔇 Мут\n\nАдмин: %s\nПользователь: %s\nДо: %s\nПричина: %s", ah.GetUserDisplayName(c.Sender()), ah.GetUserDisplayName(target), formatUntil(until, msgs), reason))
+	return nil
+}
+
+// HandleUnmute lifts the restriction of the replied or named user
+func (ah *AdminHandler) HandleUnmute(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if !ah.requireModerator(c, msgs) {
+		return nil
+	}
+	target, _ := ah.commandTarget(c, msgs)
+	if target == nil {
+		return nil
+	}
+	if err := ah.bot.Restrict(c.Chat(), &tb.ChatMember{User: target, Rights: memberRights, RestrictedUntil: tb.Forever()}); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": c.Chat().ID, "user_id": target.ID}).Error("Failed to unmute user")
+		return nil
+	}
+	ah.actions.Lift(actionMute, c.Chat().ID, target.ID)
+	ah.actions.Record(ModAction{Kind: actionUnmute, ChatID: c.Chat().ID, UserID: target.ID, UserName: ah.GetUserDisplayName(target), ByID: c.Sender().ID, By: ah.GetUserDisplayName(c.Sender())})
+
+	userMsgs := i18n.Get().T(ah.getLangForUser(target))
+	_, _ = ah.bot.Send(c.Chat(), fmt.Sprintf(userMsgs.Moderation.Unmuted, ah.GetUserDisplayName(target)))
+	ah.LogToAdmin(fmt.Sprintf("🔊 Мут снят\n\nАдмин: %s\nПользователь: %s", ah.GetUserDisplayName(c.Sender()), ah.GetUserDisplayName(target)))
+	return nil
+}
+
+// scheduleExpiry runs ExpireActions when a temporary action runs out
+func (ah *AdminHandler) scheduleExpiry(until time.Time) {
+	if !until.IsZero() {
+		ah.tasks.Add(Task{Kind: taskExpireActions, At: until})
+	}
+}
+
+// ExpireActions lifts temporary restrictions whose time is up
+func (ah *AdminHandler) ExpireActions(now time.Time) {
+	for _, a := range ah.actions.Expired(now) {
+		chat, user := &tb.Chat{ID: a.ChatID}, &tb.User{ID: a.UserID}
+		fields := logrus.Fields{"chat_id": a.ChatID, "user_id": a.UserID, "kind": a.Kind}
+		switch a.Kind {
+		case actionMute:
+			if err := ah.bot.Restrict(chat, &tb.ChatMember{User: user, Rights: memberRights, RestrictedUntil: tb.Forever()}); err != nil {
+				logrus.WithError(err).WithFields(fields).Error("Failed to lift expired mute")
+				continue
+			}
+			ah.LogToAdmin(fmt.Sprintf("⏰ Мут истёк\n\nПользователь: %s\nВыдал: %s", a.UserName, a.By))
+		case actionBan:
+			if err := ah.bot.Unban(chat, user, true); err != nil {
+				logrus.WithError(err).WithFields(fields).Error("Failed to lift expired ban")
+				continue
+			}
+			ah.LogToAdmin(fmt.Sprintf("⏰ Временный бан истёк\n\nПользователь: %s\nВыдал: %s", a.UserName, a.By))
+		}
+		logrus.WithFields(fields).Info("Temporary action expired")
+	}
+}
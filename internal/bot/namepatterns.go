@@ -0,0 +1,189 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// NamePatternStore persists regular expressions checked against names of joining users, each with a profile action
+type NamePatternStore struct {
+	mu       sync.RWMutex
+	Patterns map[string]string `json:"patterns"`
+	file     string
+	compiled map[string]*regexp.Regexp
+}
+
+// NewNamePatternStore creates a name pattern list backed by a JSON file
+func NewNamePatternStore(file string) *NamePatternStore {
+	_ = os.MkdirAll("data", 0755)
+	ns := &NamePatternStore{
+		Patterns: make(map[string]string),
+		file:     file,
+		compiled: make(map[string]*regexp.Regexp),
+	}
+	ns.load()
+	return ns
+}
+
+func (ns *NamePatternStore) load() {
+	data, err := os.ReadFile(ns.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, ns)
+	if ns.Patterns == nil {
+		ns.Patterns = make(map[string]string)
+	}
+	for p := range ns.Patterns {
+		re, err := compilePattern(p)
+		if err != nil {
+			logrus.WithError(err).WithField("pattern", p).Warn("Skipping invalid name pattern")
+			continue
+		}
+		ns.compiled[p] = re
+	}
+}
+
+func (ns *NamePatternStore) save() {
+	data, err := json.MarshalIndent(ns, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error("name pattern store marshal")
+		return
+	}
+	if err := os.WriteFile(ns.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("name pattern store write")
+	}
+}
+
+// Add validates and stores a pattern with the given action
+func (ns *NamePatternStore) Add(pattern, action string) error {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return err
+	}
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	ns.Patterns[pattern] = action
+	ns.compiled[pattern] = re
+	ns.save()
+	return nil
+}
+
+// Remove deletes a pattern
+func (ns *NamePatternStore) Remove(pattern string) bool {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if _, ok := ns.Patterns[pattern]; !ok {
+		return false
+	}
+	delete(ns.Patterns, pattern)
+	delete(ns.compiled, pattern)
+	ns.save()
+	return true
+}
+
+// List returns patterns with their actions in alphabetical order
+func (ns *NamePatternStore) List() []string {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	patterns := make([]string, 0, len(ns.Patterns))
+	for p, action := range ns.Patterns {
+		patterns = append(patterns, fmt.Sprintf("`%s` — %s", p, action))
+	}
+	slices.Sort(patterns)
+	return patterns
+}
+
+// Match checks the first, last, full and user names; a kick rule wins over a quiz rule
+func (ns *NamePatternStore) Match(u *tb.User) (pattern, action string, ok bool) {
+	names := []string{u.FirstName, u.LastName, strings.TrimSpace(u.FirstName + " " + u.LastName), u.Username}
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	for p, re := range ns.compiled {
+		if action == profileKick || !slices.ContainsFunc(names, func(n string) bool { return n != "" && re.MatchString(n) }) {
+			continue
+		}
+		pattern, action = p, ns.Patterns[p]
+	}
+	return pattern, action, pattern != ""
+}
+
+// parseProfileAction extracts an optional --action=<kick|quiz> flag
+func parseProfileAction(payload string) (action, rest string, ok bool) {
+	rest = strings.TrimSpace(payload)
+	flag, after, _ := strings.Cut(rest, " ")
+	value, isFlag := strings.CutPrefix(flag, "--action=")
+	if !isFlag {
+		return profileKick, rest, true
+	}
+	value = strings.ToLower(value)
+	return value, strings.TrimSpace(after), slices.Contains(profileActions, value)
+}
+
+// HandleBanNamePattern adds a name pattern for joining users or lists the patterns
+func (ah *AdminHandler) HandleBanNamePattern(c tb.Context) error {
+	lang := ah.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if c.Message() == nil || c.Sender() == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.NamePatterns.AdminOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	action, pattern, ok := parseProfileAction(c.Message().Payload)
+	if !ok || pattern == "" {
+		text := msgs.NamePatterns.Usage
+		if patterns := ah.namePatterns.List(); ok && len(patterns) > 0 {
+			text += "\n\n" + msgs.NamePatterns.ListHeader + strings.Join(patterns, "\n")
+		}
+		msg, _ := ah.bot.Send(c.Chat(), text, tb.ModeMarkdown)
+		ah.DeleteAfter(msg, 30*time.Second)
+		return nil
+	}
+	if err := ah.namePatterns.Add(pattern, action); err != nil {
+		msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Admin.BanInvalidPattern, err))
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.NamePatterns.Added, pattern, action))
+	ah.DeleteAfter(msg, 10*time.Second)
+	ah.LogToAdmin(fmt.Sprintf("🪪 Добавлен шаблон имени\n\nАдмин: %s\nШаблон: `%s`\nДействие: %s", ah.GetUserDisplayName(c.Sender()), pattern, action))
+	return nil
+}
+
+// HandleUnbanNamePattern removes a name pattern
+func (ah *AdminHandler) HandleUnbanNamePattern(c tb.Context) error {
+	lang := ah.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if c.Message() == nil || c.Sender() == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.NamePatterns.AdminOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	pattern := strings.TrimSpace(c.Message().Payload)
+	if pattern == "" {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.NamePatterns.UnbanUsage)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	text := msgs.NamePatterns.NotFound
+	if ah.namePatterns.Remove(pattern) {
+		text = fmt.Sprintf(msgs.NamePatterns.Removed, pattern)
+		ah.LogToAdmin(fmt.Sprintf("✅ Удалён шаблон имени\n\nАдмин: %s\nШаблон: `%s`", ah.GetUserDisplayName(c.Sender()), pattern))
+	}
+	msg, _ := ah.bot.Send(c.Chat(), text)
+	ah.DeleteAfter(msg, 10*time.Second)
+	return nil
+}
@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// parseClock parses HH:MM into minutes since midnight
+func parseClock(s string) (int, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// parseNightHours validates a quiet hours range like 01:00-07:00
+func parseNightHours(v string) (int, int, bool) {
+	from, to, found := strings.Cut(v, "-")
+	start, ok1 := parseClock(from)
+	end, ok2 := parseClock(to)
+	return start, end, found && ok1 && ok2 && start != end
+}
+
+// isNight reports whether the time falls into the chat quiet hours
+func (cs ChatSettings) isNight(now time.Time) bool {
+	start, end, ok := parseNightHours(cs.NightHours)
+	if !ok {
+		return false
+	}
+	m := now.Hour()*60 + now.Minute()
+	if start < end {
+		return m >= start && m < end
+	}
+	return m >= start || m < end
+}
+
+// checkNightMode deletes messages during quiet hours when the chat is set to delete instead of restricting
+func (fh *FeatureHandler) checkNightMode(c tb.Context) bool {
+	cs := fh.settings.Get(c.Chat().ID)
+	if !cs.NightDelete || !cs.isNight(time.Now()) {
+		return false
+	}
+	if err := fh.bot.Delete(c.Message()); err != nil {
+		logrus.WithError(err).WithField("chat_id", c.Chat().ID).Warn("Failed to delete night mode message")
+	}
+	return true
+}
+
+// NightModeTick starts and ends quiet hours in all configured chats
+func (fh *FeatureHandler) NightModeTick(now time.Time) {
+	for _, chatID := range fh.settings.ChatIDs() {
+		cs := fh.settings.Get(chatID)
+		night := cs.isNight(now)
+		if night == cs.NightActive {
+			continue
+		}
+		chat := &tb.Chat{ID: chatID}
+		if night {
+			fh.startNight(chat, cs)
+		} else {
+			fh.endNight(chat, cs)
+		}
+	}
+}
+
+// startNight announces quiet hours and closes the chat for non-admins
+func (fh *FeatureHandler) startNight(chat *tb.Chat, cs ChatSettings) {
+	var saved *tb.Rights
+	if !cs.NightDelete {
+		saved = &tb.Rights{CanSendMessages: true, CanSendPhotos: true, CanSendVideos: true, CanSendVideoNotes: true, CanSendVoiceNotes: true, CanSendPolls: true, CanSendOther: true, CanAddPreviews: true, CanInviteUsers: true}
+		if full, err := fh.bot.ChatByID(chat.ID); err == nil && full.Permissions != nil {
+			saved = full.Permissions
+		}
+		if err := fh.bot.SetGroupPermissions(chat, tb.Rights{}); err != nil {
+			logrus.WithError(err).WithField("chat_id", chat.ID).Error("Failed to close chat for the night")
+			return
+		}
+	}
+	fh.settings.Update(chat.ID, func(s *ChatSettings) { s.NightActive, s.NightSavedRights = true, saved })
+	msgs := i18n.Get().T(i18n.Get().GetDefault())
+	_, to, _ := strings.Cut(cs.NightHours, "-")
+	if _, err := fh.bot.Send(chat, fmt.Sprintf(msgs.Night.Start, strings.TrimSpace(to))); err != nil {
+		logrus.WithError(err).WithField("chat_id", chat.ID).Warn("Failed to announce night mode")
+	}
+	fh.adminHandler.LogToAdmin(fmt.Sprintf("🌙 Начался ночной режим.\n\nЧат: %d\nЧасы: %s", chat.ID, cs.NightHours))
+}
+
+// endNight reopens the chat and announces the end of quiet hours
+func (fh *FeatureHandler) endNight(chat *tb.Chat, cs ChatSettings) {
+	// Only chats closed at the start of the night have permissions to restore
+	if cs.NightSavedRights != nil {
+		if err := fh.bot.SetGroupPermissions(chat, *cs.NightSavedRights); err != nil {
+			logrus.WithError(err).WithField("chat_id", chat.ID).Error("Failed to reopen chat after the night")
+			return
+		}
+	}
+	fh.settings.Update(chat.ID, func(s *ChatSettings) { s.NightActive, s.NightSavedRights = false, nil })
+	msgs := i18n.Get().T(i18n.Get().GetDefault())
+	if _, err := fh.bot.Send(chat, msgs.Night.End); err != nil {
+		logrus.WithError(err).WithField("chat_id", chat.ID).Warn("Failed to announce night mode end")
+	}
+	fh.adminHandler.LogToAdmin(fmt.Sprintf("☀️ Ночной режим закончился.\n\nЧат: %d", chat.ID))
+}
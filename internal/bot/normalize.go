@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"strings"
+	"unicode"
+)
+
+// invisibleRunes are characters spammers insert between letters to split words
+var invisibleRunes = map[rune]bool{
+	'\u00ad': true, // soft hyphen
+	'\u034f': true, // combining grapheme joiner
+	'\u180e': true, // mongolian vowel separator
+	'\u200b': true, // zero width space
+	'\u200c': true, // zero width non-joiner
+	'\u200d': true, // zero width joiner
+	'\u2060': true, // word joiner
+	'\ufeff': true, // zero width no-break space
+}
+
+// foldRunes maps accented letters and lookalikes from other scripts to a single latin skeleton
+var foldRunes = map[rune]rune{
+	// Latin letters with diacritics
+	'á': 'a', 'à': 'a', 'â': 'a', 'ä': 'a', 'ã': 'a', 'å': 'a', 'ą': 'a', 'ā': 'a',
+	'ć': 'c', 'č': 'c', 'ç': 'c',
+	'ď': 'd', 'đ': 'd',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e', 'ę': 'e', 'ě': 'e', 'ē': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i', 'ī': 'i',
+	'ł': 'l', 'ľ': 'l',
+	'ń': 'n', 'ñ': 'n', 'ň': 'n',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'ö': 'o', 'õ': 'o', 'ø': 'o', 'ō': 'o',
+	'ř': 'r',
+	'ś': 's', 'š': 's', 'ş': 's',
+	'ť': 't',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u', 'ů': 'u', 'ū': 'u',
+	'ý': 'y', 'ÿ': 'y',
+	'ź': 'z', 'ż': 'z', 'ž': 'z',
+	// Cyrillic lookalikes
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o',
+	'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ї': 'i', 'ј': 'j',
+	'ѕ': 's', 'ԁ': 'd', 'һ': 'h', 'ӏ': 'l', 'ү': 'y', 'ў': 'y',
+	// Greek lookalikes
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+}
+
+// normalizeText lowercases text, drops invisible characters and combining marks and folds lookalike letters
+func normalizeText(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for _, r := range strings.ToLower(s) {
+		if invisibleRunes[r] || unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if f, ok := foldRunes[r]; ok {
+			r = f
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// truncateText cuts text to at most n runes, marking the cut with an ellipsis
+func truncateText(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
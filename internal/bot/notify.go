@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"capybot/internal/core"
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// ruleLabel returns the reason for a filter rule in the user's language, with the category of a matched blacklist entry
+func (fh *FeatureHandler) ruleLabel(rule string, msg *tb.Message, msgs *i18n.Messages) string {
+	prefix, _, _ := strings.Cut(rule, ":")
+	switch prefix + ":" {
+	case bayesRulePrefix, similarRulePrefix:
+		return msgs.Filter.RuleSpam
+	case moderationRulePrefix:
+		return msgs.Filter.RuleModeration
+	}
+	switch prefix {
+	case "caps":
+		return msgs.Filter.RuleCaps
+	case "forward", "sender_chat":
+		return msgs.Filter.RuleChannel
+	case "domain":
+		return msgs.Filter.RuleDomain
+	case "promo":
+		return msgs.Filter.RulePromo
+	case "probation":
+		return msgs.Filter.RuleProbation
+	case "entity":
+		return msgs.Filter.RuleEntity
+	case "length", "emoji":
+		return msgs.Filter.RuleNewbieLimits
+	}
+	if entry, ok := fh.blacklist.Match(messageText(msg)); ok && entry.Category != "" {
+		return fmt.Sprintf("%s (%s)", msgs.Filter.RuleBlacklist, entry.Category)
+	}
+	return msgs.Filter.RuleBlacklist
+}
+
+// notifyViolation tells the author in private why the filter acted on their message and reports whether the message was delivered;
+// Telegram refuses it unless the user has started the bot
+func (fh *FeatureHandler) notifyViolation(c tb.Context, rule, level string, strikes int) bool {
+	msg := c.Message()
+	msgs := i18n.Get().T(fh.getLangForUser(msg.Sender))
+	cs := fh.settings.Get(c.Chat().ID)
+
+	text := msgs.Filter.DMRemoved
+	if level == core.LevelWarn {
+		text = msgs.Filter.DMWarned
+	}
+	text = fmt.Sprintf(text, c.Chat().Title) + "\n" + fmt.Sprintf(msgs.Filter.DMReason, fh.ruleLabel(rule, msg, msgs))
+	if strikes > 0 {
+		text += "\n" + fmt.Sprintf(msgs.Filter.DMStrikes, strikes, cs.warnLimit())
+	}
+	if cs.RulesLink != "" {
+		text += "\n\n" + fmt.Sprintf(msgs.Filter.DMRules, cs.RulesLink)
+	}
+	if _, err := fh.bot.Send(msg.Sender, text); err != nil {
+		logrus.WithError(err).WithField("user_id", msg.Sender.ID).Debug("Could not explain the filter action in private")
+		return false
+	}
+	return true
+}
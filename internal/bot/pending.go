@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// pendingListLimit is the number of reviews /pending posts at once
+const pendingListLimit = 20
+
+// reviewAdminText formats a review waiting for moderation for the admin chat
+func reviewAdminText(adminMsgs *i18n.Messages, r Review) string {
+	kind := adminMsgs.Rating.Public
+	if r.IsAnonymous {
+		kind = adminMsgs.Rating.Anonymous
+	}
+	text := fmt.Sprintf("📝 %s\n\n%s: @%s (ID: %d)\n%s: %s\n%s: %s\n%s: [%d/5] %s\n\n%s: %s",
+		adminMsgs.Rating.NewReviewAdmin,
+		adminMsgs.Rating.Sender, r.Username, r.UserID,
+		adminMsgs.Rating.TypeLabel, kind,
+		adminMsgs.Rating.Professor, r.Professor,
+		adminMsgs.Rating.Score, r.Score, strings.Repeat("⭐", r.Score),
+		adminMsgs.Rating.ReviewLabel, r.Text,
+	)
+	text += criteriaLine(adminMsgs, r.Criteria) + courseLine(adminMsgs, r.Course, r.Tag)
+	if r.Replaces != 0 {
+		text += "\n\n✏️ " + fmt.Sprintf(adminMsgs.Rating.EditOf, r.Replaces)
+	}
+	return text
+}
+
+// formatAge returns how long something has been waiting, in the largest whole unit
+func formatAge(msgs *i18n.Messages, d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf(msgs.Rating.AgeDays, int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf(msgs.Rating.AgeHours, int(d/time.Hour))
+	default:
+		return fmt.Sprintf(msgs.Rating.AgeMinutes, int(d/time.Minute))
+	}
+}
+
+// HandlePending reposts the reviews waiting for moderation with their buttons, oldest first
+func (rh *RatingHandler) HandlePending(c tb.Context) error {
+	if !rh.adminHandler.canReview(c.Sender()) {
+		msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+		msg, _ := rh.bot.Send(c.Chat(), msgs.Roles.ReviewDenied)
+		rh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	adminMsgs := rh.adminMsgs()
+	pending, responses := rh.store.PendingReviews(), rh.store.PendingResponses()
+	if len(pending) == 0 && len(responses) == 0 {
+		_, _ = rh.bot.Send(c.Chat(), adminMsgs.Rating.QueueEmpty)
+		return nil
+	}
+	for _, r := range responses {
+		_, _ = rh.bot.Send(c.Chat(), rh.responseAdminText(adminMsgs, r), responseKeyboard(adminMsgs, r.ID))
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	header := fmt.Sprintf(adminMsgs.Rating.QueueHeader, len(pending), formatAge(adminMsgs, now.Sub(time.Unix(pending[0].CreatedAt, 0))))
+	if len(pending) > pendingListLimit {
+		header += "\n" + fmt.Sprintf(adminMsgs.Rating.QueueShownFirst, pendingListLimit)
+	}
+	shown := pending[:min(len(pending), pendingListLimit)]
+	all := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{
+		{Data: fmt.Sprintf("rate_bulk_upto_%d", shown[len(shown)-1].ID), Text: fmt.Sprintf(adminMsgs.Rating.BtnApproveShown, len(shown))},
+	}}}
+	_, _ = rh.bot.Send(c.Chat(), header, all)
+
+	for _, r := range shown {
+		text := reviewAdminText(adminMsgs, r) + rh.similarityWarning(adminMsgs, r) + "\n\n" + fmt.Sprintf(adminMsgs.Rating.Waiting, formatAge(adminMsgs, now.Sub(time.Unix(r.CreatedAt, 0))))
+		kb := reviewKeyboard(adminMsgs, r.ID)
+		kb.InlineKeyboard = append(kb.InlineKeyboard, []tb.InlineButton{{Data: fmt.Sprintf("rate_bulk_user_%d", r.UserID), Text: adminMsgs.Rating.BtnApproveAuthor}})
+		if r.PhotoID != "" {
+			rh.sendReviewPhoto(c.Chat(), r.PhotoID, fmt.Sprintf(adminMsgs.Rating.CardPhoto, r.ID))
+		}
+		_, _ = rh.bot.Send(c.Chat(), text, kb)
+	}
+	return nil
+}
+
+// handleBulkApprove approves every pending review up to the last one /pending showed, or every pending review of one author
+func (rh *RatingHandler) handleBulkApprove(c tb.Context) error {
+	if !rh.adminHandler.canReview(c.Sender()) {
+		return rh.denyReview(c)
+	}
+	adminMsgs := rh.adminMsgs()
+	data := c.Callback().Data
+	var id int64
+	var match func(Review) bool
+	var verdict string
+	if n, _ := fmt.Sscanf(data, "rate_bulk_upto_%d", &id); n == 1 {
+		match = func(r Review) bool { return int64(r.ID) <= id }
+		verdict = adminMsgs.Rating.ApprovedShown
+	} else if n, _ := fmt.Sscanf(data, "rate_bulk_user_%d", &id); n == 1 {
+		match = func(r Review) bool { return r.UserID == id }
+		verdict = adminMsgs.Rating.ApprovedAuthor
+	} else {
+		return rh.bot.Respond(c.Callback())
+	}
+
+	approved := rh.store.ApproveReviews(match, c.Sender().ID)
+	if len(approved) == 0 {
+		_, _ = rh.bot.EditReplyMarkup(c.Message(), nil)
+		return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: adminMsgs.Rating.NothingToApprove})
+	}
+	by := c.Sender()
+	byAuthor := make(map[int64][]Review)
+	var authors []int64
+	for _, r := range approved {
+		if _, ok := byAuthor[r.UserID]; !ok {
+			authors = append(authors, r.UserID)
+		}
+		byAuthor[r.UserID] = append(byAuthor[r.UserID], r)
+		author := &tb.User{ID: r.UserID, Username: r.Username}
+		rh.adminHandler.actions.Record(ModAction{Kind: actionApprove, ByID: by.ID, By: rh.adminHandler.GetUserDisplayName(by), UserID: r.UserID, UserName: rh.adminHandler.GetUserDisplayName(author), Reason: fmt.Sprintf("отзыв #%d", r.ID)})
+	}
+	rh.adminHandler.RecordDecision(c, fmt.Sprintf("%s: %d", verdict, len(approved)), "", nil, nil, "")
+
+	for _, userID := range authors {
+		reviews := byAuthor[userID]
+		userMsgs := rh.authorMsgs(reviews[0])
+		notice := fmt.Sprintf(userMsgs.Rating.ReviewApproved, reviews[0].Professor)
+		if len(reviews) > 1 {
+			names := make([]string, len(reviews))
+			for i, r := range reviews {
+				names[i] = r.Professor
+			}
+			notice = fmt.Sprintf(userMsgs.Rating.ReviewsApproved, len(reviews), strings.Join(names, ", "))
+		}
+		if _, err := rh.bot.Send(&tb.Chat{ID: userID}, notice); err != nil {
+			logrus.WithError(err).WithField("userID", userID).Warn("Failed to notify user about approved reviews")
+		}
+	}
+	logrus.WithFields(logrus.Fields{"count": len(approved), "admin_id": by.ID}).Info("Reviews approved in bulk")
+	return rh.bot.Respond(c.Callback())
+}
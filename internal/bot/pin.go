@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// requireModerator replies with the moderation denial to users who are neither chat admins nor bot moderators and reports whether the command may proceed
+func (ah *AdminHandler) requireModerator(c tb.Context, msgs *i18n.Messages) bool {
+	if c.Message() == nil || c.Sender() == nil || !ah.IsModerator(c.Chat(), c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Moderation.AdminOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return false
+	}
+	return true
+}
+
+// HandlePin pins the replied message, without a notification with /pin silent
+func (ah *AdminHandler) HandlePin(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if !ah.requireModerator(c, msgs) {
+		return nil
+	}
+	reply := c.Message().ReplyTo
+	if reply == nil {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Moderation.ReplyRequired)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	var opts []interface{}
+	silent := strings.EqualFold(strings.TrimSpace(c.Message().Payload), "silent")
+	if silent {
+		opts = append(opts, tb.Silent)
+	}
+	if err := ah.bot.Pin(reply, opts...); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": c.Chat().ID, "message_id": reply.ID}).Error("Failed to pin message")
+		return nil
+	}
+	_ = ah.bot.Delete(c.Message())
+	ah.recordPin(c, actionPin, fmt.Sprintf("message %d", reply.ID))
+	ah.LogToAdmin(fmt.Sprintf("📌 Закреплено сообщение\n\nАдмин: %s\nЧат: %s\nБез уведомления: %t\nСообщение: %s", ah.GetUserDisplayName(c.Sender()), c.Chat().Title, silent, truncateText(messageText(reply), 200)))
+	return nil
+}
+
+// HandleUnpin unpins the replied message or, without a reply, the latest pinned one
+func (ah *AdminHandler) HandleUnpin(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if !ah.requireModerator(c, msgs) {
+		return nil
+	}
+	var ids []int
+	target := "latest"
+	if reply := c.Message().ReplyTo; reply != nil {
+		ids = append(ids, reply.ID)
+		target = fmt.Sprintf("message %d", reply.ID)
+	}
+	if err := ah.bot.Unpin(c.Chat(), ids...); err != nil {
+		logrus.WithError(err).WithField("chat_id", c.Chat().ID).Error("Failed to unpin message")
+		return nil
+	}
+	_ = ah.bot.Delete(c.Message())
+	ah.recordPin(c, actionUnpin, target)
+	ah.LogToAdmin(fmt.Sprintf("📍 Откреплено сообщение\n\nАдмин: %s\nЧат: %s", ah.GetUserDisplayName(c.Sender()), c.Chat().Title))
+	return nil
+}
+
+// HandleUnpinAll unpins every message in the chat
+func (ah *AdminHandler) HandleUnpinAll(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if !ah.requireModerator(c, msgs) {
+		return nil
+	}
+	if err := ah.bot.UnpinAll(c.Chat()); err != nil {
+		logrus.WithError(err).WithField("chat_id", c.Chat().ID).Error("Failed to unpin all messages")
+		return nil
+	}
+	_ = ah.bot.Delete(c.Message())
+	ah.recordPin(c, actionUnpinAll, "")
+	ah.LogToAdmin(fmt.Sprintf("📍 Откреплены все сообщения\n\nАдмин: %s\nЧат: %s", ah.GetUserDisplayName(c.Sender()), c.Chat().Title))
+	return nil
+}
+
+// recordPin stores a pin action in the action log
+func (ah *AdminHandler) recordPin(c tb.Context, kind, target string) {
+	ah.actions.Record(ModAction{Kind: kind, ChatID: c.Chat().ID, ByID: c.Sender().ID, By: ah.GetUserDisplayName(c.Sender()), Reason: target})
+}
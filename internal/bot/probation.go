@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"capybot/internal/core"
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// onProbation reports whether the user has not yet sent the chat's probation number of messages since verification
+func (fh *FeatureHandler) onProbation(chat *tb.Chat, user *tb.User) bool {
+	limit := fh.settings.Get(chat.ID).ProbationMessages
+	if limit <= 0 {
+		return false
+	}
+	if _, ok := fh.state.VerifiedAt(int(user.ID)); !ok {
+		return false
+	}
+	return fh.state.PostsSinceVerified(int(user.ID)) < limit
+}
+
+// checkProbation applies stricter rules to the first messages of a new member and reports whether the message was handled
+func (fh *FeatureHandler) checkProbation(c tb.Context) bool {
+	m := c.Message()
+	if !fh.onProbation(c.Chat(), m.Sender) {
+		return false
+	}
+	fh.state.CountPost(int(m.Sender.ID))
+	switch {
+	case len(messageLinks(m)) > 0:
+		fh.applyFilterAction(c, "probation:link", core.LevelDelete)
+	case m.IsForwarded():
+		fh.applyFilterAction(c, "probation:forward", core.LevelDelete)
+	case fh.settings.Get(c.Chat().ID).ProbationPremod:
+		fh.premoderate(c)
+	default:
+		return false
+	}
+	return true
+}
+
+// premoderate moves a message to the admin chat and publishes it only after approval
+func (fh *FeatureHandler) premoderate(c tb.Context) {
+	m := c.Message()
+	fields := logrus.Fields{"chat_id": c.Chat().ID, "user_id": m.Sender.ID}
+	held, err := fh.bot.Copy(&tb.Chat{ID: fh.adminChatID}, m)
+	if err != nil {
+		logrus.WithError(err).WithFields(fields).Error("Failed to hold message for premoderation")
+		return
+	}
+	if err := fh.bot.Delete(m); err != nil {
+		logrus.WithError(err).WithFields(fields).Warn("Failed to delete premoderated message")
+	}
+
+	payload := fmt.Sprintf("%d:%d:%d", c.Chat().ID, held.ID, m.Sender.ID)
+	kb := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{
+		{Unique: "premod_approve", Data: payload, Text: "✅ Опубликовать"},
+		{Unique: "premod_reject", Data: payload, Text: "❌ Отклонить"},
+	}}}
+	card := fmt.Sprintf("🕵️ Премодерация: сообщение участника на испытательном сроке.\n\nПользователь: %s\nЧат: %s", fh.adminHandler.GetUserDisplayName(m.Sender), c.Chat().Title)
+	if _, err := fh.bot.Send(&tb.Chat{ID: fh.adminChatID}, card, &tb.SendOptions{ReplyTo: held, ReplyMarkup: kb}); err != nil {
+		logrus.WithError(err).WithFields(fields).Error("Failed to send premoderation card")
+	}
+
+	msgs := i18n.Get().T(fh.getLangForUser(m.Sender))
+	notice, _ := fh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Filter.Premoderation, fh.adminHandler.GetUserDisplayName(m.Sender)))
+	fh.adminHandler.DeleteAfter(notice, 30*time.Second)
+}
+
+// parsePremodPayload extracts the chat, the held message and the author from callback data
+func parsePremodPayload(data string) (chatID int64, heldID int, userID int64, ok bool) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 3 {
+		return 0, 0, 0, false
+	}
+	chatID, err1 := strconv.ParseInt(parts[0], 10, 64)
+	heldID, err2 := strconv.Atoi(parts[1])
+	userID, err3 := strconv.ParseInt(parts[2], 10, 64)
+	return chatID, heldID, userID, err1 == nil && err2 == nil && err3 == nil
+}
+
+// HandlePremodApprove publishes a held message in the chat it was sent to
+func (fh *FeatureHandler) HandlePremodApprove(c tb.Context) error {
+	chatID, heldID, userID, ok := parsePremodPayload(c.Callback().Data)
+	if !ok {
+		return fh.bot.Respond(c.Callback())
+	}
+	chat := &tb.Chat{ID: chatID}
+	if !fh.adminHandler.IsModerator(chat, c.Sender()) {
+		msgs := i18n.Get().T(fh.getLangForUser(c.Sender()))
+		return fh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Moderation.AdminOnly})
+	}
+	user := &tb.User{ID: userID}
+	if m, err := fh.bot.ChatMemberOf(chat, user); err == nil && m.User != nil {
+		user = m.User
+	}
+	msgs := i18n.Get().T(i18n.Get().GetDefault())
+	if _, err := fh.bot.Send(chat, fmt.Sprintf(msgs.Filter.PremodApproved, fh.adminHandler.GetUserDisplayName(user))); err != nil {
+		logrus.WithError(err).WithField("chat_id", chatID).Error("Failed to publish premoderated message")
+		return fh.bot.Respond(c.Callback())
+	}
+	if _, err := fh.bot.Copy(chat, &tb.StoredMessage{MessageID: strconv.Itoa(heldID), ChatID: fh.adminChatID}); err != nil {
+		logrus.WithError(err).WithField("chat_id", chatID).Error("Failed to publish premoderated message")
+	}
+	fh.adminHandler.RecordDecision(c, "✅ Опубликовано", actionApprove, chat, user, "премодерация")
+	return fh.bot.Respond(c.Callback())
+}
+
+// HandlePremodReject drops a held message
+func (fh *FeatureHandler) HandlePremodReject(c tb.Context) error {
+	chatID, _, userID, ok := parsePremodPayload(c.Callback().Data)
+	if !ok {
+		return fh.bot.Respond(c.Callback())
+	}
+	chat, user := &tb.Chat{ID: chatID}, &tb.User{ID: userID}
+	if !fh.adminHandler.IsModerator(chat, c.Sender()) {
+		msgs := i18n.Get().T(fh.getLangForUser(c.Sender()))
+		return fh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Moderation.AdminOnly})
+	}
+	fh.adminHandler.RecordDecision(c, "❌ Отклонено", actionReject, chat, user, "премодерация")
+	return fh.bot.Respond(c.Callback())
+}
@@ -0,0 +1,300 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// maxSuggestions is the number of similar professors offered when a name has no exact match
+const maxSuggestions = 3
+
+// directoryPageSize is the number of professors per page of the directory shown when rating
+const directoryPageSize = 8
+
+// professorWords splits a professor name into lowercase words without diacritics, initials lose their dots
+func professorWords(name string) []string {
+	return strings.FieldsFunc(normalizeText(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '-'
+	})
+}
+
+// professorKey normalizes a professor name for comparing reviews, ignoring case, diacritics, word order and the dots of initials
+func professorKey(name string) string {
+	words := professorWords(name)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// nameMatches reports whether every word of the query starts a different word of the name, so "J. Kowalski" and "kowalski jan" match "Jan Kowalski"
+func nameMatches(query, name string) bool {
+	queryWords, nameWords := professorWords(query), professorWords(name)
+	if len(queryWords) == 0 || len(queryWords) > len(nameWords) {
+		return false
+	}
+	used := make([]bool, len(nameWords))
+	for _, q := range queryWords {
+		found := false
+		for i, w := range nameWords {
+			if !used[i] && strings.HasPrefix(w, q) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// initialsMatch reports whether two names have the same words, where an initial on either side stands for a word starting with it
+func initialsMatch(a, b string) bool {
+	wordsA, wordsB := professorWords(a), professorWords(b)
+	if len(wordsA) != len(wordsB) {
+		return false
+	}
+	used := make([]bool, len(wordsB))
+	for _, wa := range wordsA {
+		found := false
+		for i, wb := range wordsB {
+			if used[i] {
+				continue
+			}
+			if wa == wb || utf8.RuneCountInString(wa) == 1 && strings.HasPrefix(wb, wa) || utf8.RuneCountInString(wb) == 1 && strings.HasPrefix(wa, wb) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueInitialsMatch returns the only name that matches with initials expanded, false when none or several do
+func uniqueInitialsMatch(names []string, name string) (string, bool) {
+	found, key := "", ""
+	for _, n := range names {
+		if !initialsMatch(name, n) {
+			continue
+		}
+		if k := professorKey(n); found != "" && k != key {
+			return "", false
+		} else if found == "" {
+			found, key = n, k
+		}
+	}
+	return found, found != ""
+}
+
+// levenshtein returns the edit distance between two strings in runes
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// nameDistance returns how far apart two names are, or -1 if they are not similar enough to suggest
+func nameDistance(a, b string) int {
+	if nameMatches(a, b) || nameMatches(b, a) {
+		return 0
+	}
+	ka, kb := professorKey(a), professorKey(b)
+	d := levenshtein(ka, kb)
+	if d > max(1, min(len([]rune(ka)), len([]rune(kb)))/5) {
+		return -1
+	}
+	return d
+}
+
+// suggestProfessors returns the professors with names close to the given one, closest first, without an exact match
+func suggestProfessors(cards []professorCard, name string) []professorCard {
+	key := professorKey(name)
+	type candidate struct {
+		card     professorCard
+		distance int
+	}
+	var found []candidate
+	for _, card := range cards {
+		if professorKey(card.Professor) == key {
+			continue
+		}
+		if d := nameDistance(name, card.Professor); d >= 0 {
+			found = append(found, candidate{card, d})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].distance < found[j].distance })
+	var result []professorCard
+	for _, c := range found[:min(len(found), maxSuggestions)] {
+		result = append(result, c.card)
+	}
+	return result
+}
+
+// canonicalProfessor returns the spelling already used for a professor with the same normalized name, or the only one
+// whose full name the initials of the given one stand for
+func canonicalProfessor(cards []professorCard, name string) (string, bool) {
+	key := professorKey(name)
+	names := make([]string, 0, len(cards))
+	for _, card := range cards {
+		if professorKey(card.Professor) == key {
+			return card.Professor, true
+		}
+		names = append(names, card.Professor)
+	}
+	return uniqueInitialsMatch(names, name)
+}
+
+// Directory returns the known professors sorted by name
+func (rs *RatingStore) Directory() []string {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	names := slices.Clone(rs.Professors)
+	sort.Slice(names, func(i, j int) bool { return professorKey(names[i]) < professorKey(names[j]) })
+	return names
+}
+
+// DirectoryName returns the directory spelling of a professor with the same normalized name or matching initials
+func (rs *RatingStore) DirectoryName(name string) (string, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.directoryName(name)
+}
+
+func (rs *RatingStore) directoryName(name string) (string, bool) {
+	key := professorKey(name)
+	for _, p := range rs.Professors {
+		if professorKey(p) == key {
+			return p, true
+		}
+	}
+	return uniqueInitialsMatch(rs.Professors, name)
+}
+
+// AddProfessor adds a professor to the directory, returning the existing spelling and false if they are already there
+func (rs *RatingStore) AddProfessor(name string) (string, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if p, ok := rs.directoryName(name); ok {
+		return p, false
+	}
+	rs.Professors = append(rs.Professors, name)
+	rs.save()
+	return name, true
+}
+
+// RemoveProfessor removes a professor from the directory and returns the removed spelling
+func (rs *RatingStore) RemoveProfessor(name string) (string, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	key := professorKey(name)
+	for i, p := range rs.Professors {
+		if professorKey(p) == key {
+			rs.Professors = slices.Delete(rs.Professors, i, i+1)
+			rs.save()
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// showDirectory shows a page of the professor directory, or asks for the name when the directory is empty
+func (rh *RatingHandler) showDirectory(c tb.Context, msgs *i18n.Messages, page int) {
+	cancel := []tb.InlineButton{{Unique: "rate_cancel", Text: msgs.Rating.BtnCancel}}
+	directory := rh.store.Directory()
+	if len(directory) == 0 {
+		_, _ = rh.bot.Edit(c.Message(), msgs.Rating.EnterName, &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{cancel}})
+		return
+	}
+	pages := (len(directory) + directoryPageSize - 1) / directoryPageSize
+	page = max(0, min(page, pages-1))
+	from := page * directoryPageSize
+
+	var rows [][]tb.InlineButton
+	for i := from; i < min(from+directoryPageSize, len(directory)); i++ {
+		rows = append(rows, []tb.InlineButton{{Data: fmt.Sprintf("rate_prof_%d", i), Text: directory[i]}})
+	}
+	if pages > 1 {
+		var nav []tb.InlineButton
+		if page > 0 {
+			nav = append(nav, tb.InlineButton{Data: fmt.Sprintf("rate_dir_%d", page-1), Text: msgs.Rating.BtnPrev})
+		}
+		if page < pages-1 {
+			nav = append(nav, tb.InlineButton{Data: fmt.Sprintf("rate_dir_%d", page+1), Text: msgs.Rating.BtnNext})
+		}
+		rows = append(rows, nav)
+	}
+	rows = append(rows, []tb.InlineButton{{Unique: "rate_manual", Text: msgs.Rating.BtnManual}}, cancel)
+	_, _ = rh.bot.Edit(c.Message(), msgs.Rating.ChooseProfessor, &tb.ReplyMarkup{InlineKeyboard: rows})
+}
+
+// HandleAddProfessor adds a professor to the directory, like /addprof Anna Kowalska
+func (rh *RatingHandler) HandleAddProfessor(c tb.Context) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	if !rh.adminHandler.canReview(c.Sender()) {
+		msg, _ := rh.bot.Send(c.Chat(), msgs.Roles.ReviewDenied)
+		rh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	name := strings.Join(strings.Fields(c.Message().Payload), " ")
+	if !professorNameRegex.MatchString(name) {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.AddProfUsage)
+		return nil
+	}
+	name, added := rh.store.AddProfessor(name)
+	if !added {
+		_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.ProfExists, name))
+		return nil
+	}
+	_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.ProfAdded, name))
+	rh.adminHandler.LogToAdmin(fmt.Sprintf("📚 Преподаватель добавлен\n\nАдмин: %s\nПреподаватель: %s", rh.adminHandler.GetUserDisplayName(c.Sender()), name))
+	return nil
+}
+
+// HandleDelProfessor removes a professor from the directory, keeping their reviews
+func (rh *RatingHandler) HandleDelProfessor(c tb.Context) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	if !rh.adminHandler.canReview(c.Sender()) {
+		msg, _ := rh.bot.Send(c.Chat(), msgs.Roles.ReviewDenied)
+		rh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	name := strings.Join(strings.Fields(c.Message().Payload), " ")
+	if name == "" {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.DelProfUsage)
+		return nil
+	}
+	removed, ok := rh.store.RemoveProfessor(name)
+	if !ok {
+		_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.ProfNotFound, name))
+		return nil
+	}
+	_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.ProfRemoved, removed))
+	rh.adminHandler.LogToAdmin(fmt.Sprintf("📚 Преподаватель удалён\n\nАдмин: %s\nПреподаватель: %s", rh.adminHandler.GetUserDisplayName(c.Sender()), removed))
+	return nil
+}
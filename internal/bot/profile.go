@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"capybot/internal/core"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// Actions for joining users whose profile looks like spam
+const (
+	profileKick = "kick"
+	profileQuiz = "quiz"
+)
+
+// profileActions lists valid profile screening actions
+var profileActions = []string{profileKick, profileQuiz}
+
+// profileLinkPattern finds links and mentions written as plain text in names and bios
+var profileLinkPattern = regexp.MustCompile(`(?i)https?://\S+|(?:[a-z0-9-]+\.)+[a-z]{2,}(?:/\S*)?|@[a-z0-9_]{5,}`)
+
+// profileAction returns what happens to users with a suspicious profile
+func (cs ChatSettings) profileAction() string {
+	if cs.ProfileAction == "" {
+		return profileKick
+	}
+	return cs.ProfileAction
+}
+
+// profileText returns the name and, if it can be fetched, the bio of a user
+func (fh *FeatureHandler) profileText(u *tb.User) string {
+	text := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	full, err := fh.bot.ChatByID(u.ID)
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", u.ID).Debug("Failed to fetch user profile")
+		return text
+	}
+	return text + "\n" + full.Bio
+}
+
+// inspectProfile runs the name and bio of a user through the link and blacklist filters and returns the rule they break
+func (fh *FeatureHandler) inspectProfile(u *tb.User) (string, bool) {
+	text := fh.profileText(u)
+	for _, found := range profileLinkPattern.FindAllString(text, -1) {
+		if mention, ok := strings.CutPrefix(found, "@"); ok {
+			if key := promoKey(mention); fh.isPromoTarget(key) {
+				return "promo:" + key, true
+			}
+			continue
+		}
+		keys := linkKeys(found, false)
+		if len(keys) > 0 && strings.HasPrefix(keys[0], "t.me") {
+			return "link:" + keys[0], true
+		}
+		if domain, _, ok := fh.domains.Match(keys); ok {
+			return "domain:" + domain, true
+		}
+	}
+	if fh.blacklist != nil {
+		if entry, ok := fh.blacklist.Match(text); ok && entry.Severity() != core.LevelMonitor {
+			return entry.String(), true
+		}
+	}
+	return "", false
+}
+
+// screenProfile checks a joining user's name patterns and profile; it kicks matching users, declining their join request if there is one, or reports that they need the strict quiz
+func (fh *FeatureHandler) screenProfile(chat *tb.Chat, u *tb.User, viaRequest bool) (kicked, suspicious bool) {
+	cs := fh.settings.Get(chat.ID)
+	pattern, action, found := fh.namePatterns.Match(u)
+	rule := "name:" + pattern
+	if !found && cs.ProfileCheck {
+		rule, found = fh.inspectProfile(u)
+		action = cs.profileAction()
+	}
+	if !found {
+		return false, false
+	}
+	fields := logrus.Fields{"chat_id": chat.ID, "user_id": u.ID, "rule": rule}
+	if action == profileQuiz {
+		fh.adminHandler.LogToAdmin(fmt.Sprintf("🪪 Подозрительный профиль, назначена полная проверка.\n\nПользователь: %s\nЧат: %s\nПравило: `%s`", fh.adminHandler.GetUserDisplayName(u), chat.Title, rule))
+		logrus.WithFields(fields).Info("Suspicious profile sent to the strict quiz")
+		return false, true
+	}
+	if viaRequest {
+		if err := fh.bot.DeclineJoinRequest(chat, u); err != nil {
+			logrus.WithError(err).WithFields(fields).Warn("Failed to decline join request of suspicious profile")
+		}
+	} else {
+		fh.kickUser(chat, u)
+	}
+	fh.recordVerification(chat, u, VerifyEvent{Outcome: verifyProfileKicked, Score: rule})
+	fh.adminHandler.LogToAdmin(fmt.Sprintf("🪪 Подозрительный профиль, пользователь удалён при входе.\n\nПользователь: %s\nЧат: %s\nПравило: `%s`", fh.adminHandler.GetUserDisplayName(u), chat.Title, rule))
+	logrus.WithFields(fields).Info("User with suspicious profile kicked")
+	return true, false
+}
+
+// kickUser removes a user from the chat, leaving them free to come back
+func (fh *FeatureHandler) kickUser(chat *tb.Chat, u *tb.User) {
+	fields := logrus.Fields{"chat_id": chat.ID, "user_id": u.ID}
+	if err := fh.adminHandler.BanUser(chat, u); err != nil {
+		logrus.WithError(err).WithFields(fields).Error("Failed to kick user")
+	} else if err := fh.bot.Unban(chat, u); err != nil {
+		logrus.WithError(err).WithFields(fields).Warn("Failed to unban kicked user")
+	}
+}
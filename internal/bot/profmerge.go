@@ -0,0 +1,184 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// Reasons MoveProfessor refuses to rename or merge
+var (
+	errProfessorUnknown = errors.New("professor has no reviews and is not in the directory")
+	errTargetUnknown    = errors.New("professor to merge into has no reviews and is not in the directory")
+	errProfessorTaken   = errors.New("new name belongs to another professor")
+	errSameProfessor    = errors.New("names belong to the same professor")
+)
+
+// professorMove describes a rename or merge done by MoveProfessor
+type professorMove struct {
+	From, To string
+	Moved    int // Reviews now under the new name
+	Retired  int // Reviews of the merged name by authors who already reviewed the target
+}
+
+// professorSpelling returns the spelling of a professor used in reviews or in the directory, the lock must be held
+func (rs *RatingStore) professorSpelling(name string) (string, bool) {
+	if positions := rs.professorReviews(name); len(positions) > 0 {
+		return rs.Reviews[positions[len(positions)-1]].Professor, true
+	}
+	return rs.directoryName(name)
+}
+
+// MoveProfessor puts the reviews, directory entry, drafts and verified accounts of one professor under another name in one save;
+// renaming needs a name no other professor has, merging needs one that does, and an author who reviewed both keeps only the review of the target
+func (rs *RatingStore) MoveProfessor(from, to string, merge bool) (professorMove, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	from, ok := rs.professorSpelling(from)
+	if !ok {
+		return professorMove{}, errProfessorUnknown
+	}
+	fromKey, toKey := professorKey(from), professorKey(to)
+	if merge {
+		if fromKey == toKey {
+			return professorMove{}, errSameProfessor
+		}
+		if to, ok = rs.professorSpelling(to); !ok {
+			return professorMove{}, errTargetUnknown
+		}
+	} else if _, taken := rs.professorSpelling(to); taken && fromKey != toKey {
+		return professorMove{}, errProfessorTaken
+	}
+	move := professorMove{From: from, To: to}
+
+	reviewed := make(map[int64]bool)
+	for _, i := range rs.professorReviews(to) {
+		if r := rs.Reviews[i]; fromKey != toKey && (r.Status == "pending" || r.Status == "approved") {
+			reviewed[r.UserID] = true
+		}
+	}
+	for _, i := range rs.professorReviews(from) {
+		r := &rs.Reviews[i]
+		r.Professor = to
+		move.Moved++
+		if reviewed[r.UserID] && (r.Status == "pending" || r.Status == "approved") {
+			rs.setStatus(r, "replaced", 0)
+			move.Retired++
+		}
+	}
+
+	for i := 0; i < len(rs.Professors); i++ {
+		if professorKey(rs.Professors[i]) != fromKey {
+			continue
+		}
+		if _, listed := rs.directoryName(to); listed && fromKey != toKey {
+			rs.Professors = slices.Delete(rs.Professors, i, i+1)
+			i--
+		} else {
+			rs.Professors[i] = to
+		}
+	}
+	for id, draft := range rs.Drafts {
+		if professorKey(draft.Professor) == fromKey {
+			draft.Professor = to
+			rs.Drafts[id] = draft
+		}
+	}
+	for id, professor := range rs.Accounts {
+		if professorKey(professor) == fromKey {
+			rs.Accounts[id] = to
+		}
+	}
+	rs.reindex()
+	rs.save()
+	return move, nil
+}
+
+// move lets the page of a renamed professor keep its address, or queues the emptied page of a merged one for republishing
+func (tp *TelegraphPages) move(from, to string) {
+	tp.mu.Lock()
+	fromKey, toKey := professorKey(from), professorKey(to)
+	if path, ok := tp.Pages[fromKey]; ok && fromKey != toKey {
+		if _, taken := tp.Pages[toKey]; taken {
+			tp.dirty[fromKey] = from
+		} else {
+			delete(tp.Pages, fromKey)
+			tp.Pages[toKey] = path
+			tp.save()
+		}
+	}
+	tp.dirty[toKey] = to
+	tp.mu.Unlock()
+}
+
+// parseProfessorMove splits a payload like "Kowlaski -> Jan Kowalski" into both names
+func parseProfessorMove(payload string) (from, to string, ok bool) {
+	from, to, ok = strings.Cut(payload, "->")
+	from, to = strings.Join(strings.Fields(from), " "), strings.Join(strings.Fields(to), " ")
+	return from, to, ok && from != "" && professorNameRegex.MatchString(to)
+}
+
+// HandleRenameProfessor fixes the name of a professor in all their reviews, like /renameprof Jan Kowlaski -> Jan Kowalski
+func (rh *RatingHandler) HandleRenameProfessor(c tb.Context) error {
+	return rh.moveProfessor(c, false)
+}
+
+// HandleMergeProfessor moves the reviews of a duplicate professor to the right one, like /mergeprof Kowlaski -> Jan Kowalski
+func (rh *RatingHandler) HandleMergeProfessor(c tb.Context) error {
+	return rh.moveProfessor(c, true)
+}
+
+func (rh *RatingHandler) moveProfessor(c tb.Context, merge bool) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	if !rh.adminHandler.canReview(c.Sender()) {
+		msg, _ := rh.bot.Send(c.Chat(), msgs.Roles.ReviewDenied)
+		rh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	usage := msgs.Rating.RenameProfUsage
+	if merge {
+		usage = msgs.Rating.MergeProfUsage
+	}
+	from, to, ok := parseProfessorMove(c.Message().Payload)
+	if !ok {
+		_, _ = rh.bot.Send(c.Chat(), usage)
+		return nil
+	}
+	move, err := rh.store.MoveProfessor(from, to, merge)
+	switch {
+	case errors.Is(err, errProfessorTaken):
+		_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.ProfNameTaken, to))
+		return nil
+	case errors.Is(err, errSameProfessor):
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.SameProfessor)
+		return nil
+	case errors.Is(err, errTargetUnknown):
+		_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.ProfUnknown, to))
+		return nil
+	case err != nil:
+		_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.ProfUnknown, from))
+		return nil
+	}
+	if rh.pages != nil {
+		rh.pages.move(move.From, move.To)
+	}
+	if rh.subs != nil {
+		rh.subs.move(move.From, move.To)
+	}
+
+	admin := rh.adminHandler.GetUserDisplayName(c.Sender())
+	if merge {
+		_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.ProfMerged, move.From, move.To, move.Moved, move.Retired))
+		rh.adminHandler.LogToAdmin(fmt.Sprintf("📚 Преподаватели объединены\n\nАдмин: %s\nДубликат: %s\nПреподаватель: %s\nПеренесено отзывов: %d\nСнято повторных: %d", admin, move.From, move.To, move.Moved, move.Retired))
+		return nil
+	}
+	_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.ProfRenamed, move.From, move.To, move.Moved))
+	rh.adminHandler.LogToAdmin(fmt.Sprintf("📚 Преподаватель переименован\n\nАдмин: %s\nБыло: %s\nСтало: %s\nОтзывов: %d", admin, move.From, move.To, move.Moved))
+	return nil
+}
@@ -0,0 +1,152 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"capybot/internal/core"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// chatTypeTTL is how long a resolved chat type is reused
+const chatTypeTTL = 24 * time.Hour
+
+// maxChatTypes caps the number of cached usernames
+const maxChatTypes = 4096
+
+// chatTypeEntry is a cached chat type, empty for usernames that belong to no chat
+type chatTypeEntry struct {
+	t  tb.ChatType
+	at time.Time
+}
+
+// chatTypeCache remembers what kind of chat a public username belongs to
+type chatTypeCache struct {
+	mu    sync.Mutex
+	types map[string]chatTypeEntry
+}
+
+// get returns a cached type that has not expired
+func (cc *chatTypeCache) get(username string) (tb.ChatType, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	e, ok := cc.types[username]
+	if !ok || time.Since(e.at) >= chatTypeTTL {
+		return "", false
+	}
+	return e.t, true
+}
+
+// put caches a type, dropping expired entries and then arbitrary ones when the cache is full
+func (cc *chatTypeCache) put(username string, t tb.ChatType) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if len(cc.types) >= maxChatTypes {
+		maps.DeleteFunc(cc.types, func(_ string, e chatTypeEntry) bool { return time.Since(e.at) >= chatTypeTTL })
+		for key := range cc.types {
+			if len(cc.types) < maxChatTypes {
+				break
+			}
+			delete(cc.types, key)
+		}
+	}
+	cc.types[username] = chatTypeEntry{t: t, at: time.Now()}
+}
+
+// promoKey reduces @name, t.me/name and invite links to a comparable key
+func promoKey(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "@")
+	if keys := linkKeys(s, false); strings.Contains(s, "/") && len(keys) > 0 && strings.HasPrefix(keys[0], "t.me/") {
+		u := strings.TrimPrefix(keys[0], "t.me/")
+		if u == "joinchat" {
+			// Keep the hash of legacy invite links
+			if _, rest, ok := strings.Cut(s, "joinchat/"); ok {
+				hash, _, _ := strings.Cut(rest, "/")
+				return "joinchat/" + hash
+			}
+		}
+		return u
+	}
+	return s
+}
+
+// isInviteKey reports whether a key is a private invite link
+func isInviteKey(key string) bool {
+	return strings.HasPrefix(key, "+") || strings.HasPrefix(key, "joinchat")
+}
+
+// chatType resolves the type of a public chat by its username; lookup failures other than a missing chat are not cached
+func (fh *FeatureHandler) chatType(username string) tb.ChatType {
+	if t, ok := fh.chatTypes.get(username); ok {
+		return t
+	}
+	chat, err := fh.bot.ChatByUsername("@" + username)
+	if err != nil {
+		logrus.WithError(err).WithField("username", username).Debug("Failed to resolve mentioned chat")
+		if errors.Is(err, tb.ErrChatNotFound) {
+			fh.chatTypes.put(username, "")
+		}
+		return ""
+	}
+	fh.chatTypes.put(username, chat.Type)
+	return chat.Type
+}
+
+// isPromoTarget reports whether a key is an invite link or a public channel or group
+func (fh *FeatureHandler) isPromoTarget(key string) bool {
+	if isInviteKey(key) {
+		return true
+	}
+	switch fh.chatType(key) {
+	case tb.ChatChannel, tb.ChatChannelPrivate, tb.ChatGroup, tb.ChatSuperGroup:
+		return true
+	}
+	return false
+}
+
+// promoTargets collects invite links, t.me links and mentions found in the message
+func promoTargets(m *tb.Message) []string {
+	var targets []string
+	for _, link := range messageLinks(m) {
+		keys := linkKeys(link, false)
+		if len(keys) > 0 && strings.HasPrefix(keys[0], "t.me/") {
+			targets = append(targets, promoKey(link))
+		}
+	}
+	for _, e := range append(slices.Clone(m.Entities), m.CaptionEntities...) {
+		if e.Type == tb.EntityMention {
+			targets = append(targets, promoKey(m.EntityText(e)))
+		}
+	}
+	return targets
+}
+
+// matchPromo finds invite links and promotion of other channels and groups
+func (fh *FeatureHandler) matchPromo(in filterInput) (filterHit, bool) {
+	if in.cs.PromoFilterDisabled {
+		return filterHit{}, false
+	}
+	own := strings.ToLower(in.chat.Username)
+	for _, key := range promoTargets(in.msg) {
+		if key == "" || key == own || slices.Contains(in.cs.PromoAllowlist, key) {
+			continue
+		}
+		if !fh.isPromoTarget(key) {
+			continue
+		}
+		level := in.cs.PromoLevel
+		if level == "" {
+			level = core.LevelDeleteWarn
+		}
+		return filterHit{rule: fmt.Sprintf("promo:%s", key), level: level}, true
+	}
+	return filterHit{}, false
+}
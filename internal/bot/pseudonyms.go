@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pseudonymPrefix starts the name shown on anonymous reviews, followed by a short code unique to the author
+const pseudonymPrefix = "Anon-Capy-"
+
+// pseudonymBytes is the length of the code; 4 bytes keep two authors sharing one unlikely even among thousands
+const pseudonymBytes = 4
+
+// ensureSecret creates the key pseudonyms are derived with, kept in the store so they survive restarts
+func (rs *RatingStore) ensureSecret() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.Secret != "" {
+		return
+	}
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	rs.Secret = hex.EncodeToString(key)
+	rs.save()
+}
+
+// Pseudonym returns the stable name of a user on anonymous reviews, like Anon-Capy-3F2A91C0; readers can tell
+// two reviews come from the same person but not who they are without the secret
+func (rs *RatingStore) Pseudonym(userID int64) string {
+	rs.mu.RLock()
+	secret := rs.Secret
+	rs.mu.RUnlock()
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(userID, 10)))
+	return pseudonymPrefix + strings.ToUpper(fmt.Sprintf("%x", mac.Sum(nil)[:pseudonymBytes]))
+}
+
+// Author returns how a review is signed: the username of a public one, the pseudonym of an anonymous one
+func (rs *RatingStore) Author(r Review) string {
+	if r.IsAnonymous {
+		return rs.Pseudonym(r.UserID)
+	}
+	return "@" + r.Username
+}
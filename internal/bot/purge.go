@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// Purge limits: Telegram deletes at most 100 messages per request
+const (
+	maxPurge       = 1000
+	purgeBatchSize = 100
+)
+
+// purgeRange returns the span of message IDs a /purge command covers, up to and including the command itself
+func purgeRange(m *tb.Message) (from, to int, ok bool) {
+	if m.ReplyTo != nil {
+		return max(m.ReplyTo.ID, m.ID-maxPurge), m.ID, true
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(m.Payload))
+	if err != nil || n <= 0 {
+		return 0, 0, false
+	}
+	return max(1, m.ID-min(n, maxPurge)), m.ID, true
+}
+
+// deleteRange removes messages from..to in batches and returns how many batches failed
+func (ah *AdminHandler) deleteRange(chat *tb.Chat, from, to int) int {
+	failed := 0
+	for start := from; start <= to; start += purgeBatchSize {
+		batch := make([]tb.Editable, 0, purgeBatchSize)
+		for id := start; id <= min(to, start+purgeBatchSize-1); id++ {
+			batch = append(batch, &tb.StoredMessage{MessageID: strconv.Itoa(id), ChatID: chat.ID})
+		}
+		if err := ah.bot.DeleteMany(batch); err != nil {
+			failed++
+			logrus.WithError(err).WithFields(logrus.Fields{"chat_id": chat.ID, "from": start}).Warn("Failed to delete a batch of messages")
+		}
+	}
+	return failed
+}
+
+// HandlePurge deletes the messages from the replied one up to the command, or the last N with /purge N
+func (ah *AdminHandler) HandlePurge(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if !ah.requireModerator(c, msgs) {
+		return nil
+	}
+	from, to, ok := purgeRange(c.Message())
+	if !ok {
+		msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Moderation.PurgeUsage, maxPurge))
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	count := to - from + 1
+	return ah.confirmAction(c, msgs, fmt.Sprintf(msgs.Moderation.ConfirmPurge.Form(msgs.Lang(), count), count), func() {
+		failed := ah.deleteRange(c.Chat(), from, to)
+		msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Moderation.Purged.Form(msgs.Lang(), count), count))
+		ah.DeleteAfter(msg, 5*time.Second)
+		ah.LogToAdmin(fmt.Sprintf("🧹 Очистка чата\n\nАдмин: %s\nЧат: %s\nСообщения: %d–%d\nНеудачных пакетов: %d", ah.GetUserDisplayName(c.Sender()), c.Chat().Title, from, to, failed))
+	})
+}
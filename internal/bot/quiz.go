@@ -2,6 +2,8 @@ package bot
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"capybot/internal/core"
@@ -32,64 +34,137 @@ func AdsButton() tb.InlineButton {
 // HandleStudent starts quiz
 func (fh *FeatureHandler) HandleStudent(c tb.Context) error {
 	fh.state.InitUser(int(c.Sender().ID))
-	questions := fh.quiz.GetQuestions()
+	questions := fh.quizFor(fh.targetChat(c.Chat(), c.Sender()).ID).GetQuestions()
 	if len(questions) > 0 {
-		q := questions[0]
-		_ = fh.SendOrEdit(c.Chat(), c.Message(), q.GetText(), &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{q.GetButtons()}})
+		s := fh.startQuizSession(c.Sender(), c.Message(), questions)
+		_ = fh.showQuestion(c, s, 0)
 	}
 	return nil
 }
 
-// RegisterQuizHandlers registers quiz buttons
-func (fh *FeatureHandler) RegisterQuizHandlers(bot *tb.Bot) {
-	questions := fh.quiz.GetQuestions()
-	for i, q := range questions {
-		for _, btn := range q.GetButtons() {
-			bot.Handle(&btn, fh.OnlyNewbies(fh.CreateQuizHandler(i, q, btn)))
+// HandleQuizAnswer routes an answer button to the handler of its question
+func (fh *FeatureHandler) HandleQuizAnswer(c tb.Context) error {
+	idx, unique, ok := strings.Cut(c.Callback().Data, ":")
+	i, err := strconv.Atoi(idx)
+	if !ok || err != nil {
+		return fh.bot.Respond(c.Callback())
+	}
+	var questions []core.QuestionInterface
+	if s := fh.quizSession(c.Sender().ID); s != nil {
+		questions = s.questions
+	} else {
+		questions = fh.quizFor(fh.targetChat(c.Chat(), c.Sender()).ID).GetQuestions()
+	}
+	if i < 0 || i >= len(questions) {
+		return fh.bot.Respond(c.Callback())
+	}
+	q := questions[i]
+	for _, btn := range q.GetButtons() {
+		if btn.Unique == unique {
+			_ = fh.bot.Respond(c.Callback())
+			return fh.CreateQuizHandler(i, q, btn)(c)
 		}
 	}
+	return fh.bot.Respond(c.Callback())
+}
+
+// RegisterQuizHandlers registers welcome, quiz and approval buttons
+func (fh *FeatureHandler) RegisterQuizHandlers(bot *tb.Bot) {
+	bot.Handle(&tb.InlineButton{Unique: "student"}, fh.OnlyNewbies(fh.HandleStudent))
+	bot.Handle(&tb.InlineButton{Unique: "guest"}, fh.OnlyNewbies(fh.HandleGuest))
+	bot.Handle(&tb.InlineButton{Unique: "ads"}, fh.OnlyNewbies(fh.HandleAds))
+	bot.Handle(&tb.InlineButton{Unique: "confirm"}, fh.OnlyNewbies(fh.HandleConfirm))
+	bot.Handle(&tb.InlineButton{Unique: "rules_accept"}, fh.OnlyNewbies(fh.HandleRulesAccept))
+	bot.Handle(&tb.InlineButton{Unique: "quiz_back"}, fh.OnlyNewbies(fh.HandleQuizBack))
+	bot.Handle(&tb.InlineButton{Unique: "quiz_skip"}, fh.OnlyNewbies(fh.HandleQuizSkip))
+	bot.Handle(&tb.InlineButton{Unique: "quiz_answer"}, fh.OnlyNewbies(fh.HandleQuizAnswer))
+	bot.Handle(&tb.InlineButton{Unique: "verify_approve"}, fh.HandleVerifyApprove)
+	bot.Handle(&tb.InlineButton{Unique: "verify_decline"}, fh.HandleVerifyDecline)
+	bot.Handle(&tb.InlineButton{Unique: "bayes_spam"}, fh.HandleSpamVerdict)
+	bot.Handle(&tb.InlineButton{Unique: "bayes_ham"}, fh.HandleSpamVerdict)
+	bot.Handle(&tb.InlineButton{Unique: "premod_approve"}, fh.HandlePremodApprove)
+	bot.Handle(&tb.InlineButton{Unique: "premod_reject"}, fh.HandlePremodReject)
 }
 
 // CreateQuizHandler builds handler for quiz button
 func (fh *FeatureHandler) CreateQuizHandler(i int, q core.QuestionInterface, btn tb.InlineButton) func(tb.Context) error {
 	return func(c tb.Context) error {
-		lang := fh.getLangForUser(c.Sender())
-		msgs := i18n.Get().T(lang)
-
-		userID := int(c.Sender().ID)
-		if btn.Unique == q.GetAnswer() {
-			fh.state.IncCorrect(userID)
+		s := fh.quizSession(c.Sender().ID)
+		if s == nil {
+			// Quiz started before a restart, resume it with a fresh countdown
+			s = fh.startQuizSession(c.Sender(), c.Message(), fh.quizFor(fh.targetChat(c.Chat(), c.Sender()).ID).GetQuestions())
 		}
-		questions := fh.quiz.GetQuestions()
-		if i+1 < len(questions) {
-			next := questions[i+1]
-			_ = fh.SendOrEdit(c.Chat(), c.Message(), next.GetText(), &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{next.GetButtons()}})
+		s.record(i, btn.Unique)
+		if i+1 < len(s.questions) {
+			_ = fh.showQuestion(c, s, i+1)
 			return nil
 		}
-		totalCorrect := fh.state.TotalCorrect(userID)
-		totalQuestions := len(questions)
-		if totalCorrect >= 2 {
-			fh.SetUserRestriction(c.Chat(), c.Sender(), true)
-			fh.state.ClearNewbie(userID)
-			msg := fh.SendOrEdit(c.Chat(), c.Message(), msgs.Quiz.VerificationPassed, nil)
-			if fh.adminHandler != nil {
-				fh.adminHandler.DeleteAfter(msg, 5*time.Second)
-			}
-			logMsg := fmt.Sprintf("✅ Пользователь успешно прошёл верификацию.\n\nПользователь: %s\nПравильных ответов: %d/%d", fh.adminHandler.GetUserDisplayName(c.Sender()), totalCorrect, totalQuestions)
-			fh.adminHandler.LogToAdmin(logMsg)
-		} else {
-			msg := fh.SendOrEdit(c.Chat(), c.Message(), msgs.Quiz.VerificationFailed, nil)
-			if fh.adminHandler != nil {
-				fh.adminHandler.DeleteAfter(msg, 5*time.Second)
-			}
-			logMsg := fmt.Sprintf("❌ Пользователь не прошёл верификацию.\n\nПользователь: %s\nПравильных ответов: %d/%d", fh.adminHandler.GetUserDisplayName(c.Sender()), totalCorrect, totalQuestions)
-			fh.adminHandler.LogToAdmin(logMsg)
-		}
-		fh.state.Reset(userID)
+		fh.finishQuiz(c, s)
 		return nil
 	}
 }
 
+// finishQuiz scores the quiz after its last question and admits, hands over or rejects the user
+func (fh *FeatureHandler) finishQuiz(c tb.Context, s *quizSession) {
+	lang := fh.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	userID := int(c.Sender().ID)
+	fh.endQuizSession(c.Sender().ID)
+	totalCorrect := s.score()
+	totalQuestions := len(s.questions)
+	fh.state.SetCorrect(userID, totalCorrect)
+	cs := fh.settings.Get(fh.targetChat(c.Chat(), c.Sender()).ID)
+	passed := cs.quizComplete(s.answered(), totalQuestions) && cs.quizPassed(totalCorrect, totalQuestions)
+	outcome := verifyQuizFailed
+	if passed {
+		outcome = verifyQuizPassed
+	}
+	fh.recordVerification(c.Chat(), c.Sender(), VerifyEvent{Outcome: outcome, Score: fmt.Sprintf("%d/%d", totalCorrect, totalQuestions), Answers: s.chosen()})
+	if passed {
+		if fh.needsRules(c.Chat(), c.Sender()) {
+			fh.showRules(c, "quiz")
+			return
+		}
+		fh.passQuiz(c, totalCorrect, totalQuestions)
+	} else if cs.QuizPartialReview && totalCorrect > 0 {
+		fh.recordVerification(c.Chat(), c.Sender(), VerifyEvent{Outcome: verifyAwaiting})
+		fh.requestManualApproval(c, "🕵️ Пользователь ответил правильно не на все вопросы и ждёт решения админа.", totalCorrect, totalQuestions)
+	} else {
+		fh.rejectUser(c.Sender())
+		fh.dropWelcome(c.Sender().ID, c.Message().ID)
+		msg := fh.SendOrEdit(c.Chat(), c.Message(), msgs.Quiz.VerificationFailed, nil)
+		if fh.adminHandler != nil {
+			fh.adminHandler.DeleteAfter(msg, 5*time.Second)
+		}
+		logMsg := fmt.Sprintf("❌ Пользователь не прошёл верификацию.\n\nПользователь: %s\nПравильных ответов: %d/%d", fh.adminHandler.GetUserDisplayName(c.Sender()), totalCorrect, totalQuestions)
+		fh.adminHandler.LogToAdmin(logMsg)
+	}
+	fh.state.Reset(userID)
+}
+
+// passQuiz admits a user who answered enough questions or hands them over to admins
+func (fh *FeatureHandler) passQuiz(c tb.Context, totalCorrect, totalQuestions int) {
+	lang := fh.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	userID := int(c.Sender().ID)
+	chat := fh.targetChat(c.Chat(), c.Sender())
+	if RiskLevel(fh.state.Risk(userID)) == RiskHigh {
+		fh.recordVerification(c.Chat(), c.Sender(), VerifyEvent{Outcome: verifyAwaiting})
+		fh.requestManualApproval(c, "🕵️ Пользователь с высоким риском прошёл квиз и ждёт одобрения.", totalCorrect, totalQuestions)
+		return
+	}
+	fh.admitUser(c.Chat(), c.Sender())
+	fh.finishNewbie(c.Sender().ID, c.Message().ID)
+	msg := fh.SendOrEdit(c.Chat(), c.Message(), msgs.Quiz.VerificationPassed, nil)
+	if fh.adminHandler != nil {
+		fh.adminHandler.DeleteAfter(msg, 5*time.Second)
+	}
+	logMsg := fmt.Sprintf("✅ Пользователь успешно прошёл верификацию.\n\nПользователь: %s\nПравильных ответов: %d/%d", fh.adminHandler.GetUserDisplayName(c.Sender()), totalCorrect, totalQuestions)
+	fh.adminHandler.LogEvent(chat, eventVerified, c.Sender(), logMsg)
+}
+
 // Question holds quiz data
 type Question struct {
 	Text    string
@@ -0,0 +1,245 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"capybot/internal/core"
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+const (
+	// quizTimeout is how long a newcomer has to answer all questions
+	quizTimeout = 3 * time.Minute
+	// quizTick is how often the countdown in the quiz message is refreshed
+	quizTick = 30 * time.Second
+)
+
+// quizSession tracks a running quiz of a single user
+type quizSession struct {
+	mu        sync.Mutex
+	user      *tb.User
+	msg       *tb.Message
+	questions []core.QuestionInterface
+	index     int
+	answers   map[int]string
+	deadline  time.Time
+	done      chan struct{}
+}
+
+// record stores the answer to a question, replacing an earlier one after going back
+func (s *quizSession) record(index int, answer string) {
+	s.mu.Lock()
+	s.answers[index] = answer
+	s.mu.Unlock()
+}
+
+// skip drops the answer to a question, leaving it unanswered
+func (s *quizSession) skip(index int) {
+	s.mu.Lock()
+	delete(s.answers, index)
+	s.mu.Unlock()
+}
+
+// answered counts the questions that were not skipped
+func (s *quizSession) answered() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.answers)
+}
+
+// score counts correct answers
+func (s *quizSession) score() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	correct := 0
+	for i, q := range s.questions {
+		if s.answers[i] == q.GetAnswer() {
+			correct++
+		}
+	}
+	return correct
+}
+
+// startQuizSession begins the countdown for the user's quiz shown in msg; questions are fixed for the whole session
+func (fh *FeatureHandler) startQuizSession(user *tb.User, msg *tb.Message, questions []core.QuestionInterface) *quizSession {
+	s := &quizSession{user: user, msg: msg, questions: questions, answers: make(map[int]string), deadline: time.Now().Add(quizTimeout), done: make(chan struct{})}
+	fh.quizMu.Lock()
+	if old, ok := fh.quizSessions[user.ID]; ok {
+		close(old.done)
+	}
+	fh.quizSessions[user.ID] = s
+	fh.quizMu.Unlock()
+	fh.tasks.Cancel(taskQuizTimeout, func(t Task) bool { return t.UserID == user.ID })
+	timeout := Task{Kind: taskQuizTimeout, At: s.deadline, UserID: user.ID}
+	if msg != nil && msg.Chat != nil {
+		timeout.ChatID, timeout.MessageID = msg.Chat.ID, msg.ID
+	}
+	fh.tasks.Add(timeout)
+	go fh.runQuizTimer(s)
+	return s
+}
+
+// endQuizSession stops the countdown of the user's quiz and reports whether one was running
+func (fh *FeatureHandler) endQuizSession(userID int64) bool {
+	fh.tasks.Cancel(taskQuizTimeout, func(t Task) bool { return t.UserID == userID })
+	fh.quizMu.Lock()
+	defer fh.quizMu.Unlock()
+	s, ok := fh.quizSessions[userID]
+	if ok {
+		close(s.done)
+		delete(fh.quizSessions, userID)
+	}
+	return ok
+}
+
+// quizSession returns the running session of the user
+func (fh *FeatureHandler) quizSession(userID int64) *quizSession {
+	fh.quizMu.Lock()
+	defer fh.quizMu.Unlock()
+	return fh.quizSessions[userID]
+}
+
+// questionView renders a question of the session with progress and remaining time
+func (fh *FeatureHandler) questionView(s *quizSession, index int) (string, *tb.ReplyMarkup) {
+	msgs := i18n.Get().T(fh.getLangForUser(s.user))
+	q := s.questions[index]
+	left := max(int(time.Until(s.deadline).Round(time.Second).Seconds()), 0)
+	header := fmt.Sprintf(msgs.Quiz.Progress, index+1, len(s.questions), left)
+	row := make([]tb.InlineButton, 0, len(q.GetButtons()))
+	for _, btn := range q.GetButtons() {
+		// All answers share one callback, the payload identifies the question and the option
+		row = append(row, tb.InlineButton{Unique: "quiz_answer", Data: fmt.Sprintf("%d:%s", index, btn.Unique), Text: btn.Text})
+	}
+	var nav []tb.InlineButton
+	if index > 0 {
+		nav = append(nav, tb.InlineButton{Unique: "quiz_back", Text: msgs.Buttons.Prev})
+	}
+	nav = append(nav, tb.InlineButton{Unique: "quiz_skip", Data: strconv.Itoa(index), Text: msgs.Buttons.Skip})
+	return header + "\n\n" + q.GetText(), &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{row, nav}}
+}
+
+// HandleQuizBack returns the user to the previous question
+func (fh *FeatureHandler) HandleQuizBack(c tb.Context) error {
+	s := fh.quizSession(c.Sender().ID)
+	if s == nil {
+		return fh.bot.Respond(c.Callback())
+	}
+	s.mu.Lock()
+	index := s.index
+	s.mu.Unlock()
+	if index > 0 {
+		_ = fh.showQuestion(c, s, index-1)
+	}
+	return fh.bot.Respond(c.Callback())
+}
+
+// HandleQuizSkip moves on from the current question without an answer, finishing the quiz after the last one
+func (fh *FeatureHandler) HandleQuizSkip(c tb.Context) error {
+	s := fh.quizSession(c.Sender().ID)
+	index, err := strconv.Atoi(c.Callback().Data)
+	if s == nil || err != nil {
+		return fh.bot.Respond(c.Callback())
+	}
+	s.mu.Lock()
+	current := s.index
+	s.mu.Unlock()
+	if index != current {
+		return fh.bot.Respond(c.Callback())
+	}
+	s.skip(index)
+	if index+1 < len(s.questions) {
+		_ = fh.showQuestion(c, s, index+1)
+	} else {
+		fh.finishQuiz(c, s)
+	}
+	return fh.bot.Respond(c.Callback())
+}
+
+// showQuestion edits the quiz message to the given question and remembers the position
+func (fh *FeatureHandler) showQuestion(c tb.Context, s *quizSession, index int) *tb.Message {
+	text, kb := fh.questionView(s, index)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index = index
+	msg := fh.SendOrEdit(c.Chat(), c.Message(), text, kb)
+	if msg != nil {
+		s.msg = msg
+	}
+	return msg
+}
+
+// runQuizTimer refreshes the countdown until the quiz ends or its time is up
+func (fh *FeatureHandler) runQuizTimer(s *quizSession) {
+	ticker := time.NewTicker(quizTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if time.Now().After(s.deadline) {
+				return
+			}
+			s.mu.Lock()
+			text, kb := fh.questionView(s, s.index)
+			if s.msg != nil {
+				_ = fh.SendOrEdit(s.msg.Chat, s.msg, text, kb)
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// quizTimeout fails the quiz of a timeout task, also one whose session was lost in a restart
+func (fh *FeatureHandler) quizTimeout(t Task) {
+	s := fh.quizSession(t.UserID)
+	if s == nil {
+		if !fh.state.IsNewbie(int(t.UserID)) {
+			return
+		}
+		s = &quizSession{user: &tb.User{ID: t.UserID}, answers: make(map[int]string), done: make(chan struct{})}
+		if t.MessageID != 0 {
+			s.msg = &tb.Message{ID: t.MessageID, Chat: &tb.Chat{ID: t.ChatID}}
+		}
+		fh.quizMu.Lock()
+		fh.quizSessions[t.UserID] = s
+		fh.quizMu.Unlock()
+	} else if time.Now().Before(s.deadline) {
+		return
+	}
+	fh.expireQuiz(s)
+}
+
+// expireQuiz fails the verification of a user who ran out of time
+func (fh *FeatureHandler) expireQuiz(s *quizSession) {
+	// The user may have answered the last question while the timer fired
+	if fh.quizSession(s.user.ID) != s || !fh.endQuizSession(s.user.ID) {
+		return
+	}
+	msgs := i18n.Get().T(fh.getLangForUser(s.user))
+
+	s.mu.Lock()
+	msg := s.msg
+	s.mu.Unlock()
+	keepID := 0
+	var chat *tb.Chat
+	if msg != nil {
+		chat = msg.Chat
+	}
+	fh.recordVerification(chat, s.user, VerifyEvent{Outcome: verifyTimedOut, Answers: s.chosen()})
+	if msg != nil {
+		keepID = msg.ID
+		msg = fh.SendOrEdit(msg.Chat, msg, msgs.Quiz.TimedOut, nil)
+		fh.adminHandler.DeleteAfter(msg, 5*time.Second)
+	}
+	fh.rejectUser(s.user)
+	fh.dropWelcome(s.user.ID, keepID)
+	fh.state.Reset(int(s.user.ID))
+	logMsg := fmt.Sprintf("⌛ Пользователь не успел пройти верификацию.\n\nПользователь: %s", fh.adminHandler.GetUserDisplayName(s.user))
+	fh.adminHandler.LogToAdmin(logMsg)
+}
@@ -0,0 +1,237 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"capybot/internal/core"
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// CustomQuestion is a quiz question defined by chat admins
+type CustomQuestion struct {
+	Text    string   `json:"text"`
+	Options []string `json:"options"`
+	Answer  int      `json:"answer"`
+}
+
+// toQuestion converts a stored question into a quiz question
+func (cq CustomQuestion) toQuestion() Question {
+	buttons := make([]tb.InlineButton, len(cq.Options))
+	for i, opt := range cq.Options {
+		buttons[i] = tb.InlineButton{Unique: fmt.Sprintf("opt%d", i), Text: opt}
+	}
+	return Question{Text: cq.Text, Buttons: buttons, Answer: fmt.Sprintf("opt%d", cq.Answer)}
+}
+
+// QuizStore persists per-chat custom quizzes
+type QuizStore struct {
+	mu    sync.RWMutex
+	Chats map[int64][]CustomQuestion `json:"chats"`
+	file  string
+}
+
+// NewQuizStore creates a quiz store backed by a JSON file
+func NewQuizStore(file string) *QuizStore {
+	_ = os.MkdirAll("data", 0755)
+	qs := &QuizStore{
+		Chats: make(map[int64][]CustomQuestion),
+		file:  file,
+	}
+	qs.load()
+	return qs
+}
+
+func (qs *QuizStore) load() {
+	data, err := os.ReadFile(qs.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, qs)
+	if qs.Chats == nil {
+		qs.Chats = make(map[int64][]CustomQuestion)
+	}
+}
+
+func (qs *QuizStore) save() {
+	data, err := json.MarshalIndent(qs, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error("quiz store marshal")
+		return
+	}
+	if err := os.WriteFile(qs.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("quiz store write")
+	}
+}
+
+// Add appends a question to the chat quiz and returns its number
+func (qs *QuizStore) Add(chatID int64, q CustomQuestion) int {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	qs.Chats[chatID] = append(qs.Chats[chatID], q)
+	qs.save()
+	return len(qs.Chats[chatID])
+}
+
+// Remove deletes the question with the given zero-based index
+func (qs *QuizStore) Remove(chatID int64, index int) bool {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	questions := qs.Chats[chatID]
+	if index < 0 || index >= len(questions) {
+		return false
+	}
+	questions = slices.Delete(questions, index, index+1)
+	if len(questions) == 0 {
+		delete(qs.Chats, chatID)
+	} else {
+		qs.Chats[chatID] = questions
+	}
+	qs.save()
+	return true
+}
+
+// List returns a copy of the chat questions
+func (qs *QuizStore) List(chatID int64) []CustomQuestion {
+	qs.mu.RLock()
+	defer qs.mu.RUnlock()
+	return slices.Clone(qs.Chats[chatID])
+}
+
+// Quiz returns the chat quiz if admins defined one
+func (qs *QuizStore) Quiz(chatID int64) (core.QuizInterface, bool) {
+	questions := qs.List(chatID)
+	if len(questions) == 0 {
+		return nil, false
+	}
+	quiz := Quiz{Questions: make([]Question, len(questions))}
+	for i, q := range questions {
+		quiz.Questions[i] = q.toQuestion()
+	}
+	return quiz, true
+}
+
+// quizFor returns the quiz used in the chat
+func (fh *FeatureHandler) quizFor(chatID int64) core.QuizInterface {
+	if quiz, ok := fh.quizzes.Quiz(chatID); ok {
+		return quiz
+	}
+	return fh.quiz
+}
+
+// parseCustomQuestion parses "question | *right | wrong | ..." into a question
+func parseCustomQuestion(payload string) (CustomQuestion, error) {
+	parts := strings.Split(payload, "|")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if len(parts) < 3 || parts[0] == "" {
+		return CustomQuestion{}, errors.New("question and at least two options are required")
+	}
+	if len(parts) > 7 {
+		return CustomQuestion{}, errors.New("at most six options are allowed")
+	}
+	q := CustomQuestion{Text: parts[0], Answer: -1}
+	for _, opt := range parts[1:] {
+		if strings.HasPrefix(opt, "*") {
+			if q.Answer >= 0 {
+				return CustomQuestion{}, errors.New("only one option can be marked as correct")
+			}
+			q.Answer = len(q.Options)
+			opt = strings.TrimSpace(strings.TrimPrefix(opt, "*"))
+		}
+		if opt == "" {
+			return CustomQuestion{}, errors.New("empty option")
+		}
+		q.Options = append(q.Options, opt)
+	}
+	if q.Answer < 0 {
+		return CustomQuestion{}, errors.New("mark the correct option with *")
+	}
+	return q, nil
+}
+
+// HandleAddQuestion adds a question to the chat quiz
+func (fh *FeatureHandler) HandleAddQuestion(c tb.Context) error {
+	lang := fh.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if !fh.requireAdmin(c, msgs.QuizAdmin.AdminOnly) {
+		return nil
+	}
+	q, err := parseCustomQuestion(c.Message().Payload)
+	if err != nil {
+		msg, _ := fh.bot.Send(c.Chat(), fmt.Sprintf(msgs.QuizAdmin.AddUsage, err))
+		fh.adminHandler.DeleteAfter(msg, 30*time.Second)
+		return nil
+	}
+	n := fh.quizzes.Add(c.Chat().ID, q)
+	msg, _ := fh.bot.Send(c.Chat(), fmt.Sprintf(msgs.QuizAdmin.Added, n))
+	fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+	fh.adminHandler.LogToAdmin(fmt.Sprintf("❓ Добавлен вопрос квиза #%d.\n\nАдмин: %s\nЧат: %s\nВопрос: %s", n, fh.adminHandler.GetUserDisplayName(c.Sender()), c.Chat().Title, q.Text))
+	return nil
+}
+
+// HandleDelQuestion removes a question from the chat quiz
+func (fh *FeatureHandler) HandleDelQuestion(c tb.Context) error {
+	lang := fh.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if !fh.requireAdmin(c, msgs.QuizAdmin.AdminOnly) {
+		return nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(c.Message().Payload))
+	if err != nil {
+		msg, _ := fh.bot.Send(c.Chat(), msgs.QuizAdmin.DelUsage)
+		fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	if !fh.quizzes.Remove(c.Chat().ID, n-1) {
+		msg, _ := fh.bot.Send(c.Chat(), msgs.QuizAdmin.NotFound)
+		fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	msg, _ := fh.bot.Send(c.Chat(), fmt.Sprintf(msgs.QuizAdmin.Deleted, n))
+	fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+	fh.adminHandler.LogToAdmin(fmt.Sprintf("🗑 Удалён вопрос квиза #%d.\n\nАдмин: %s\nЧат: %s", n, fh.adminHandler.GetUserDisplayName(c.Sender()), c.Chat().Title))
+	return nil
+}
+
+// HandleListQuestions shows the chat quiz
+func (fh *FeatureHandler) HandleListQuestions(c tb.Context) error {
+	lang := fh.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if !fh.requireAdmin(c, msgs.QuizAdmin.AdminOnly) {
+		return nil
+	}
+	questions := fh.quizzes.List(c.Chat().ID)
+	if len(questions) == 0 {
+		_, err := fh.bot.Send(c.Chat(), msgs.QuizAdmin.ListEmpty)
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteString(msgs.QuizAdmin.ListHeader)
+	for i, q := range questions {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, q.Text))
+		for j, opt := range q.Options {
+			mark := "▫️"
+			if j == q.Answer {
+				mark = "✅"
+			}
+			sb.WriteString(fmt.Sprintf("   %s %s\n", mark, opt))
+		}
+	}
+	_, err := fh.bot.Send(c.Chat(), sb.String())
+	return err
+}
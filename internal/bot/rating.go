@@ -3,8 +3,10 @@ package bot
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,6 +19,15 @@ import (
 	tb "gopkg.in/telebot.v4"
 )
 
+// Professors per page of /ratings and reviews per page of one professor
+const (
+	cardsPerPage   = 10
+	reviewsPerPage = 5
+)
+
+// professorNameRegex accepts two to four names or initials, like Jan Kowalski, J. Kowalski or Anna Nowak-Kowalska
+var professorNameRegex = regexp.MustCompile(`^(?:\p{L}\.|\p{L}+(?:-\p{L}+)*)(?:\s+(?:\p{L}\.|\p{L}+(?:-\p{L}+)*)){1,3}$`)
+
 // RatingStep represents the current step in the rating flow
 type RatingStep int
 
@@ -24,51 +35,114 @@ const (
 	StepNone RatingStep = iota
 	StepChooseType
 	StepEnterName
+	StepChooseName
 	StepChooseScore
+	StepChooseCriteria
+	StepChooseTag
+	StepEnterCourse
 	StepEnterReview
 	StepConfirm
+	StepAttachPhoto
+	StepEnterResponse
 )
 
 // Review represents a single professor review
 type Review struct {
-	ID          int    `json:"id"`
-	UserID      int64  `json:"user_id"`
-	Username    string `json:"username"`
-	IsAnonymous bool   `json:"is_anonymous"`
-	Professor   string `json:"professor"`
-	Score       int    `json:"score"`
-	Text        string `json:"text"`
-	Status      string `json:"status"` // Pending, approved, rejected
-	CreatedAt   int64  `json:"created_at"`
+	ID          int                `json:"id"`
+	UserID      int64              `json:"user_id"`
+	Username    string             `json:"username"`
+	IsAnonymous bool               `json:"is_anonymous"`
+	Professor   string             `json:"professor"`
+	Score       int                `json:"score"`
+	Text        string             `json:"text"`
+	Status      string             `json:"status"` // Pending, approved, rejected, replaced, removed
+	CreatedAt   int64              `json:"created_at"`
+	Replaces    int                `json:"replaces,omitempty"` // ID of the review this one is an edit of
+	Criteria    map[string]int     `json:"criteria,omitempty"` // Scores of ratingCriteria, a missing one was skipped
+	Course      string             `json:"course,omitempty"`
+	Tag         string             `json:"tag,omitempty"`    // One of reviewTags
+	Votes       map[int64]int      `json:"votes,omitempty"`  // 1 or -1 by the ID of the voter, replaced as a whole on every vote
+	Reason      string             `json:"reason,omitempty"` // One of rejectReasons for a rejected review
+	PhotoID     string             `json:"photo_id,omitempty"`
+	ModeratedAt int64              `json:"moderated_at,omitempty"` // When a moderator took the review out of the queue
+	ModeratedBy int64              `json:"moderated_by,omitempty"` // ID of the reviewer who approved or rejected it
+	Response    *ProfessorResponse `json:"response,omitempty"`     // Replaced as a whole when it changes
+	Semester    string             `json:"semester,omitempty"`     // Like 2025W, see semesterOf
+	Archived    bool               `json:"archived,omitempty"`     // Left out of listings unless a search asks for the archive
+	Lang        i18n.Lang          `json:"lang,omitempty"`         // Language the author is notified in
+}
+
+// helpful returns the number of helpful and not helpful votes
+func (r Review) helpful() (up, down int) {
+	for _, v := range r.Votes {
+		if v > 0 {
+			up++
+		} else {
+			down++
+		}
+	}
+	return up, down
 }
 
 // RatingSession holds a user's current rating session
 type RatingSession struct {
-	Step        RatingStep
-	IsAnonymous bool
-	Professor   string
-	Score       int
-	Text        string
-	MessageID   int
+	Step         RatingStep     `json:"step"`
+	IsAnonymous  bool           `json:"is_anonymous"`
+	Professor    string         `json:"professor"`
+	Score        int            `json:"score,omitempty"`
+	Text         string         `json:"text,omitempty"`
+	MessageID    int            `json:"-"`
+	EditingID    int            `json:"editing_id,omitempty"` // ID of the approved review being edited, 0 for a new review
+	Criteria     map[string]int `json:"criteria,omitempty"`
+	Criterion    int            `json:"criterion,omitempty"` // Index of the criterion being scored
+	Tag          string         `json:"tag,omitempty"`
+	Course       string         `json:"course,omitempty"`
+	Revising     bool           `json:"-"` // Set when a step is redone from the preview, which is shown again right after it
+	PhotoID      string         `json:"photo_id,omitempty"`
+	RespondingTo int            `json:"-"` // ID of the review a verified professor is answering
 }
 
 // RatingStore manages reviews persistence
 type RatingStore struct {
 	mu           sync.RWMutex
-	Reviews      []Review `json:"reviews"`
-	BlockedUsers []int64  `json:"blocked_users"`
-	NextID       int      `json:"next_id"`
+	Reviews      []Review                `json:"reviews"`
+	BlockedUsers []int64                 `json:"blocked_users"`
+	Professors   []string                `json:"professors"` // Directory offered when rating, managed by reviewers
+	Drafts       map[int64]RatingSession `json:"drafts,omitempty"`
+	NextID       int                     `json:"next_id"`
+	Secret       string                  `json:"pseudonym_secret,omitempty"`   // Key of the pseudonyms on anonymous reviews
+	Accounts     map[int64]string        `json:"professor_accounts,omitempty"` // Professor verified users answer as, by user ID
 	file         string
+	version      int                                // Bumped on every save, lets caches notice changes
+	listeners    []func(r Review, oldStatus string) // Called after a review changes status
+	index        reviewIndex
 }
 
+// ReviewLimits throttles how often one user can send reviews for moderation, a zero value disables a limit
+type ReviewLimits struct {
+	Cooldown time.Duration // Minimum time between two submissions
+	Daily    int           // Maximum submissions in 24 hours
+}
+
+// DefaultReviewLimits are used when the limits are not configured
+var DefaultReviewLimits = ReviewLimits{Cooldown: 10 * time.Minute, Daily: 5}
+
 // RatingHandler manages rating feature
 type RatingHandler struct {
 	bot          *tb.Bot
 	store        *RatingStore
+	limits       ReviewLimits
 	sessions     map[int64]*RatingSession
 	sessionsMu   sync.RWMutex
 	adminChatID  int64
+	adminLang    i18n.Lang // Language of the review cards in the admin chat
 	adminHandler *AdminHandler
+	reported     map[int]bool // Reviews reported by readers and waiting for a decision
+	reportedMu   sync.Mutex
+	top          topCache
+	pages        *TelegraphPages // Nil when Telegraph pages are disabled
+	subs         *Subscriptions
+	searches     searchTokens // Queries behind the tokens in ratings_page_ buttons
 }
 
 // NewRatingStore creates a new rating store
@@ -77,10 +151,13 @@ func NewRatingStore(file string) *RatingStore {
 	rs := &RatingStore{
 		Reviews:      make([]Review, 0),
 		BlockedUsers: make([]int64, 0),
+		Professors:   make([]string, 0),
 		NextID:       1,
 		file:         file,
 	}
 	rs.load()
+	rs.reindex()
+	rs.ensureSecret()
 	return rs
 }
 
@@ -96,9 +173,13 @@ func (rs *RatingStore) load() {
 	if rs.BlockedUsers == nil {
 		rs.BlockedUsers = make([]int64, 0)
 	}
+	if rs.Professors == nil {
+		rs.Professors = make([]string, 0)
+	}
 }
 
 func (rs *RatingStore) save() {
+	rs.version++
 	data, err := json.MarshalIndent(rs, "", "  ")
 	if err != nil {
 		logrus.WithError(err).Error("rating store marshal")
@@ -109,68 +190,209 @@ func (rs *RatingStore) save() {
 	}
 }
 
-// AddReview adds a new review
-func (rs *RatingStore) AddReview(r Review) int {
+// findUserReview returns the user's pending or approved review of a professor, skipping the review being edited and its other edits
+func (rs *RatingStore) findUserReview(userID int64, professor string, editing int) *Review {
+	for _, i := range rs.professorReviews(professor) {
+		r := &rs.Reviews[i]
+		if r.UserID != userID || (r.Status != "pending" && r.Status != "approved") {
+			continue
+		}
+		if editing != 0 && (r.ID == editing || r.Replaces == editing) {
+			continue
+		}
+		return r
+	}
+	return nil
+}
+
+// UserReview returns the user's pending or approved review of a professor
+func (rs *RatingStore) UserReview(userID int64, professor string) (Review, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	if r := rs.findUserReview(userID, professor, 0); r != nil {
+		return *r, true
+	}
+	return Review{}, false
+}
+
+// AddReview adds a new review unless the user already has one for the professor, in which case its ID is returned with false
+func (rs *RatingStore) AddReview(r Review) (int, bool) {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
+	if old := rs.findUserReview(r.UserID, r.Professor, r.Replaces); old != nil {
+		return old.ID, false
+	}
 	r.ID = rs.NextID
 	rs.NextID++
 	r.CreatedAt = time.Now().Unix()
+	r.Semester = semesterOf(time.Unix(r.CreatedAt, 0))
 	rs.Reviews = append(rs.Reviews, r)
+	rs.indexAdd(len(rs.Reviews) - 1)
 	rs.save()
-	return r.ID
+	return r.ID, true
 }
 
 // GetReview returns review by ID
 func (rs *RatingStore) GetReview(id int) *Review {
 	rs.mu.RLock()
 	defer rs.mu.RUnlock()
-	for i := range rs.Reviews {
-		if rs.Reviews[i].ID == id {
-			return &rs.Reviews[i]
-		}
+	return rs.review(id)
+}
+
+// OnStatusChange registers a function called in the background after a review changes status
+func (rs *RatingStore) OnStatusChange(fn func(r Review, oldStatus string)) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.listeners = append(rs.listeners, fn)
+}
+
+// setStatus changes the status of a review and notifies the listeners, the lock must be held;
+// a review a moderator takes out of the queue records when and by whom it was moderated
+func (rs *RatingStore) setStatus(r *Review, status string, by int64) {
+	old := r.Status
+	if old == status {
+		return
+	}
+	r.Status = status
+	rs.indexStatus(r.ID, old, status)
+	if old == "pending" && by != 0 {
+		r.ModeratedAt, r.ModeratedBy = time.Now().Unix(), by
+	}
+	for _, fn := range rs.listeners {
+		go fn(*r, old)
 	}
-	return nil
 }
 
-// UpdateReviewStatus updates review status
-func (rs *RatingStore) UpdateReviewStatus(id int, status string) bool {
+// UpdateReviewStatus updates review status on behalf of a reviewer
+func (rs *RatingStore) UpdateReviewStatus(id int, status string, by int64) bool {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
-	for i := range rs.Reviews {
-		if rs.Reviews[i].ID == id {
-			rs.Reviews[i].Status = status
-			rs.save()
-			return true
-		}
+	r := rs.review(id)
+	if r == nil {
+		return false
 	}
-	return false
+	rs.setStatus(r, status, by)
+	rs.save()
+	return true
+}
+
+// PendingReviews returns the reviews waiting for moderation, oldest first
+func (rs *RatingStore) PendingReviews() []Review {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.withStatus("pending")
+}
+
+// RejectReview marks a review rejected for one of rejectReasons
+func (rs *RatingStore) RejectReview(id int, reason string, by int64) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	r := rs.review(id)
+	if r == nil {
+		return false
+	}
+	r.Reason = reason
+	rs.setStatus(r, "rejected", by)
+	rs.save()
+	return true
 }
 
 // GetApprovedReviews returns all approved reviews
 func (rs *RatingStore) GetApprovedReviews() []Review {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.withStatus("approved")
+}
+
+// Vote records a user's helpful (1) or not helpful (-1) vote on an approved review, repeating a vote takes it back; it returns the vote now held
+func (rs *RatingStore) Vote(reviewID int, userID int64, vote int) (int, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if r := rs.review(reviewID); r != nil {
+		if r.Status != "approved" || r.UserID == userID {
+			return 0, false
+		}
+		votes := make(map[int64]int, len(r.Votes)+1)
+		for id, v := range r.Votes {
+			votes[id] = v
+		}
+		if votes[userID] == vote {
+			delete(votes, userID)
+			vote = 0
+		} else {
+			votes[userID] = vote
+		}
+		r.Votes = votes
+		rs.save()
+		return vote, true
+	}
+	return 0, false
+}
+
+// UserReviews returns the reviews written by a user, newest first, without the replaced ones
+func (rs *RatingStore) UserReviews(userID int64) []Review {
 	rs.mu.RLock()
 	defer rs.mu.RUnlock()
 	result := make([]Review, 0)
-	for _, r := range rs.Reviews {
-		if r.Status == "approved" {
+	for i := len(rs.Reviews) - 1; i >= 0; i-- {
+		if r := rs.Reviews[i]; r.UserID == userID && r.Status != "replaced" {
 			result = append(result, r)
 		}
 	}
 	return result
 }
 
-// SearchReviews searches reviews by professor name
-func (rs *RatingStore) SearchReviews(query string) []Review {
+// ReplaceReview retires an edited review and any earlier approved edit of it once the edit with newID is approved
+func (rs *RatingStore) ReplaceReview(oldID, newID int) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.replaceReview(oldID, newID)
+	rs.save()
+}
+
+func (rs *RatingStore) replaceReview(oldID, newID int) {
+	for i := range rs.Reviews {
+		r := &rs.Reviews[i]
+		if r.ID == oldID || (r.Replaces == oldID && r.ID != newID && r.Status == "approved") {
+			rs.setStatus(r, "replaced", 0)
+		}
+	}
+}
+
+// ApproveReviews approves the pending reviews that match, replacing the reviews they are edits of, and returns them
+func (rs *RatingStore) ApproveReviews(match func(Review) bool, by int64) []Review {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	var approved []Review
+	// setStatus moves reviews out of the pending list, so walk a copy of it
+	for _, i := range slices.Clone(rs.index.byStatus["pending"]) {
+		if r := &rs.Reviews[i]; match(*r) {
+			rs.setStatus(r, "approved", by)
+			approved = append(approved, *r)
+		}
+	}
+	for _, r := range approved {
+		if r.Replaces != 0 {
+			rs.replaceReview(r.Replaces, r.ID)
+		}
+	}
+	if len(approved) > 0 {
+		rs.save()
+	}
+	return approved
+}
+
+// Submissions returns the creation times of the reviews a user sent since the given time, oldest first
+func (rs *RatingStore) Submissions(userID int64, since time.Time) []time.Time {
 	rs.mu.RLock()
 	defer rs.mu.RUnlock()
-	query = strings.ToLower(query)
-	result := make([]Review, 0)
+	var result []time.Time
 	for _, r := range rs.Reviews {
-		if r.Status == "approved" && strings.Contains(strings.ToLower(r.Professor), query) {
-			result = append(result, r)
+		if at := time.Unix(r.CreatedAt, 0); r.UserID == userID && at.After(since) {
+			result = append(result, at)
 		}
 	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
 	return result
 }
 
@@ -199,15 +421,53 @@ func (rs *RatingStore) BlockUser(userID int64) {
 	rs.save()
 }
 
+// UnblockUser lets a blocked user write reviews again
+func (rs *RatingStore) UnblockUser(userID int64) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	i := slices.Index(rs.BlockedUsers, userID)
+	if i < 0 {
+		return false
+	}
+	rs.BlockedUsers = slices.Delete(rs.BlockedUsers, i, i+1)
+	rs.save()
+	return true
+}
+
+// Blocked returns the blocked users with the name from their latest review
+func (rs *RatingStore) Blocked() map[int64]string {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	blocked := make(map[int64]string, len(rs.BlockedUsers))
+	for _, id := range rs.BlockedUsers {
+		blocked[id] = ""
+	}
+	for _, r := range rs.Reviews {
+		if _, ok := blocked[r.UserID]; ok {
+			blocked[r.UserID] = r.Username
+		}
+	}
+	return blocked
+}
+
 // NewRatingHandler creates a new rating handler
-func NewRatingHandler(bot *tb.Bot, adminChatID int64, adminHandler *AdminHandler) *RatingHandler {
-	return &RatingHandler{
+func NewRatingHandler(bot *tb.Bot, adminChatID int64, adminLang i18n.Lang, adminHandler *AdminHandler, store *RatingStore, limits ReviewLimits, pages *TelegraphPages, subs *Subscriptions) *RatingHandler {
+	rh := &RatingHandler{
 		bot:          bot,
-		store:        NewRatingStore("data/ratings.json"),
+		store:        store,
+		limits:       limits,
+		pages:        pages,
 		sessions:     make(map[int64]*RatingSession),
+		reported:     make(map[int]bool),
 		adminChatID:  adminChatID,
+		adminLang:    adminLang,
 		adminHandler: adminHandler,
+		subs:         subs,
+	}
+	if subs != nil {
+		store.OnStatusChange(rh.notifySubscribers)
 	}
+	return rh
 }
 
 // getSession returns or creates session
@@ -252,6 +512,37 @@ func (rh *RatingHandler) getLangForUser(user *tb.User) i18n.Lang {
 	return i18n.Get().GetDefault()
 }
 
+// adminMsgs returns the messages in the language of the admin chat
+func (rh *RatingHandler) adminMsgs() *i18n.Messages {
+	return i18n.Get().T(rh.adminLang)
+}
+
+// authorMsgs returns the messages in the language of the author of a review, reviews saved without one fall back to the author's Telegram language
+func (rh *RatingHandler) authorMsgs(r Review) *i18n.Messages {
+	if r.Lang != "" {
+		return i18n.Get().T(r.Lang)
+	}
+	user, _ := rh.adminHandler.users.Find(strconv.FormatInt(r.UserID, 10))
+	return i18n.Get().T(rh.getLangForUser(user))
+}
+
+// submissionWait returns a notice when the user has to wait before sending another review
+func (rh *RatingHandler) submissionWait(userID int64, msgs *i18n.Messages, now time.Time) (string, bool) {
+	recent := rh.store.Submissions(userID, now.Add(-24*time.Hour))
+	if len(recent) == 0 {
+		return "", false
+	}
+	if last := recent[len(recent)-1]; rh.limits.Cooldown > 0 && now.Sub(last) < rh.limits.Cooldown {
+		minutes := int((rh.limits.Cooldown - now.Sub(last) + time.Minute - 1) / time.Minute)
+		return fmt.Sprintf(msgs.Rating.Cooldown, minutes), true
+	}
+	if rh.limits.Daily > 0 && len(recent) >= rh.limits.Daily {
+		freed := recent[len(recent)-rh.limits.Daily].Add(24 * time.Hour)
+		return fmt.Sprintf(msgs.Rating.DailyLimit.Form(msgs.Lang(), rh.limits.Daily), rh.limits.Daily, freed.Format("02.01 15:04")), true
+	}
+	return "", false
+}
+
 // HandleRate starts rating flow
 func (rh *RatingHandler) HandleRate(c tb.Context) error {
 	lang := rh.getLangForUser(c.Sender())
@@ -267,8 +558,25 @@ func (rh *RatingHandler) HandleRate(c tb.Context) error {
 		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.Blocked)
 		return nil
 	}
+	if notice, wait := rh.submissionWait(userID, msgs, time.Now()); wait {
+		_, _ = rh.bot.Send(c.Chat(), notice)
+		return nil
+	}
 
-	session := rh.getSession(userID)
+	if draft, ok := rh.store.Draft(userID); ok {
+		kb := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{
+			{{Unique: "rate_draft_resume", Text: msgs.Rating.BtnDraftResume}, {Unique: "rate_draft_discard", Text: msgs.Rating.BtnDraftDiscard}},
+		}}
+		_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.DraftFound, draft.Professor), kb)
+		return nil
+	}
+	rh.startRating(c, msgs)
+	return nil
+}
+
+// startRating begins a new review with the choice between a public and an anonymous one
+func (rh *RatingHandler) startRating(c tb.Context, msgs *i18n.Messages) {
+	session := rh.getSession(c.Sender().ID)
 	session.Step = StepChooseType
 
 	kb := &tb.ReplyMarkup{
@@ -277,9 +585,9 @@ func (rh *RatingHandler) HandleRate(c tb.Context) error {
 			{{Unique: "rate_cancel", Text: msgs.Rating.BtnCancel}},
 		},
 	}
-	msg, _ := rh.bot.Send(c.Chat(), msgs.Rating.ChooseType, kb)
-	session.MessageID = msg.ID
-	return nil
+	if msg, _ := rh.bot.Send(c.Chat(), msgs.Rating.ChooseType, kb); msg != nil {
+		session.MessageID = msg.ID
+	}
 }
 
 // HandleRateCallback handles rate button callbacks
@@ -309,17 +617,16 @@ func (rh *RatingHandler) HandleRateCallback(c tb.Context) error {
 	case data == "rate_public":
 		session.IsAnonymous = false
 		session.Step = StepEnterName
-		kb := &tb.ReplyMarkup{
-			InlineKeyboard: [][]tb.InlineButton{
-				{{Unique: "rate_cancel", Text: msgs.Rating.BtnCancel}},
-			},
-		}
-		_, _ = rh.bot.Edit(c.Message(), msgs.Rating.EnterName, kb)
+		rh.showDirectory(c, msgs, 0)
 		return rh.bot.Respond(c.Callback())
 
 	case data == "rate_anonymous":
 		session.IsAnonymous = true
 		session.Step = StepEnterName
+		rh.showDirectory(c, msgs, 0)
+		return rh.bot.Respond(c.Callback())
+
+	case data == "rate_manual":
 		kb := &tb.ReplyMarkup{
 			InlineKeyboard: [][]tb.InlineButton{
 				{{Unique: "rate_cancel", Text: msgs.Rating.BtnCancel}},
@@ -328,17 +635,143 @@ func (rh *RatingHandler) HandleRateCallback(c tb.Context) error {
 		_, _ = rh.bot.Edit(c.Message(), msgs.Rating.EnterName, kb)
 		return rh.bot.Respond(c.Callback())
 
+	case strings.HasPrefix(data, "rate_dir_"):
+		page, _ := strconv.Atoi(strings.TrimPrefix(data, "rate_dir_"))
+		rh.showDirectory(c, msgs, page)
+		return rh.bot.Respond(c.Callback())
+
+	case strings.HasPrefix(data, "rate_prof_"):
+		i, _ := strconv.Atoi(strings.TrimPrefix(data, "rate_prof_"))
+		directory := rh.store.Directory()
+		if session.Step != StepEnterName || i < 0 || i >= len(directory) {
+			return rh.bot.Respond(c.Callback())
+		}
+		_, _ = rh.bot.Edit(c.Message(), "👨‍🏫 "+directory[i])
+		rh.acceptProfessor(c, session, directory[i], msgs)
+		return rh.bot.Respond(c.Callback())
+
 	case strings.HasPrefix(data, "rate_score_"):
 		scoreStr := strings.TrimPrefix(data, "rate_score_")
 		score, _ := strconv.Atoi(scoreStr)
 		session.Score = score
+		if session.Revising {
+			_, _ = rh.bot.Edit(c.Message(), fmt.Sprintf("%d ⭐", score))
+			rh.showPreview(c, session, msgs)
+			return rh.bot.Respond(c.Callback())
+		}
+		session.Criteria = make(map[string]int)
+		session.Criterion = 0
+		session.Step = StepChooseCriteria
+		_, _ = rh.bot.Edit(c.Message(), criterionPrompt(msgs, session), criterionKeyboard(msgs))
+		return rh.bot.Respond(c.Callback())
+
+	case strings.HasPrefix(data, "rate_crit_"):
+		score, err := strconv.Atoi(strings.TrimPrefix(data, "rate_crit_"))
+		if session.Step != StepChooseCriteria || err != nil || score < 0 || score > 5 {
+			return rh.bot.Respond(c.Callback())
+		}
+		if score > 0 {
+			session.Criteria[ratingCriteria[session.Criterion]] = score
+		}
+		if session.Criterion++; session.Criterion < len(ratingCriteria) {
+			_, _ = rh.bot.Edit(c.Message(), criterionPrompt(msgs, session), criterionKeyboard(msgs))
+			return rh.bot.Respond(c.Callback())
+		}
+		session.Step = StepChooseTag
+		_, _ = rh.bot.Edit(c.Message(), msgs.Rating.ChooseTag, tagKeyboard(msgs))
+		return rh.bot.Respond(c.Callback())
+
+	case strings.HasPrefix(data, "rate_tag_"):
+		tag := strings.TrimPrefix(data, "rate_tag_")
+		if session.Step != StepChooseTag || (tag != "skip" && !slices.Contains(reviewTags, tag)) {
+			return rh.bot.Respond(c.Callback())
+		}
+		session.Tag = ""
+		if tag != "skip" {
+			session.Tag = tag
+		}
+		session.Step = StepEnterCourse
+		_, _ = rh.bot.Edit(c.Message(), msgs.Rating.EnterCourse, courseKeyboard(msgs))
+		return rh.bot.Respond(c.Callback())
+
+	case data == "rate_course_skip":
+		if session.Step != StepEnterCourse {
+			return rh.bot.Respond(c.Callback())
+		}
+		session.Course = ""
 		session.Step = StepEnterReview
-		kb := &tb.ReplyMarkup{
-			InlineKeyboard: [][]tb.InlineButton{
-				{{Unique: "rate_cancel", Text: msgs.Rating.BtnCancel}},
-			},
+		_, _ = rh.bot.Edit(c.Message(), msgs.Rating.EnterReview, cancelKeyboard(msgs))
+		return rh.bot.Respond(c.Callback())
+
+	case strings.HasPrefix(data, "rate_name_"):
+		if session.Step != StepChooseName {
+			return rh.bot.Respond(c.Callback())
 		}
-		_, _ = rh.bot.Edit(c.Message(), msgs.Rating.EnterReview, kb)
+		name := session.Professor
+		if id, err := strconv.Atoi(strings.TrimPrefix(data, "rate_name_")); err == nil {
+			if r := rh.store.GetReview(id); r != nil {
+				name = r.Professor
+			}
+		}
+		if name == "" {
+			return rh.bot.Respond(c.Callback())
+		}
+		_, _ = rh.bot.EditReplyMarkup(c.Message(), nil)
+		rh.acceptProfessor(c, session, name, msgs)
+		return rh.bot.Respond(c.Callback())
+
+	case strings.HasPrefix(data, "rate_edit_"):
+		return rh.startEdit(c, session, msgs, strings.TrimPrefix(data, "rate_edit_"))
+
+	case data == "rate_photo_add":
+		if session.Step != StepConfirm {
+			return rh.bot.Respond(c.Callback())
+		}
+		session.Step = StepAttachPhoto
+		_, _ = rh.bot.EditReplyMarkup(c.Message(), nil)
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.SendPhoto, photoKeyboard(msgs))
+		return rh.bot.Respond(c.Callback())
+
+	case data == "rate_photo_remove":
+		if session.Step != StepConfirm && session.Step != StepAttachPhoto {
+			return rh.bot.Respond(c.Callback())
+		}
+		session.PhotoID = ""
+		_, _ = rh.bot.EditReplyMarkup(c.Message(), nil)
+		rh.showPreview(c, session, msgs)
+		return rh.bot.Respond(c.Callback())
+
+	case data == "rate_fix_name" || data == "rate_fix_score" || data == "rate_fix_text":
+		if session.Step != StepConfirm {
+			return rh.bot.Respond(c.Callback())
+		}
+		session.Revising = true
+		_, _ = rh.bot.EditReplyMarkup(c.Message(), nil)
+		switch data {
+		case "rate_fix_name":
+			session.Step = StepEnterName
+			_, _ = rh.bot.Send(c.Chat(), msgs.Rating.EnterName, cancelKeyboard(msgs))
+		case "rate_fix_score":
+			session.Step = StepChooseScore
+			_, _ = rh.bot.Send(c.Chat(), msgs.Rating.ChooseScore, scoreKeyboard(msgs))
+		default:
+			session.Step = StepEnterReview
+			_, _ = rh.bot.Send(c.Chat(), msgs.Rating.EnterReview, cancelKeyboard(msgs))
+		}
+		return rh.bot.Respond(c.Callback())
+
+	case data == "rate_draft_save":
+		return rh.saveDraft(c, session, msgs)
+
+	case data == "rate_draft_resume":
+		_, _ = rh.bot.EditReplyMarkup(c.Message(), nil)
+		rh.resumeDraft(c, msgs)
+		return rh.bot.Respond(c.Callback())
+
+	case data == "rate_draft_discard":
+		rh.store.DeleteDraft(userID)
+		_, _ = rh.bot.EditReplyMarkup(c.Message(), nil)
+		rh.startRating(c, msgs)
 		return rh.bot.Respond(c.Callback())
 
 	case data == "rate_submit":
@@ -351,8 +784,29 @@ func (rh *RatingHandler) HandleRateCallback(c tb.Context) error {
 
 	case strings.HasPrefix(data, "rate_reject_"):
 		logrus.WithField("data", data).Info("Admin reject action")
+		return rh.askRejectReason(c)
+
+	case strings.HasPrefix(data, "rate_reason_"):
 		return rh.handleAdminAction(c, "rejected")
 
+	case strings.HasPrefix(data, "rate_bulk_"):
+		return rh.handleBulkApprove(c)
+
+	case strings.HasPrefix(data, "rate_unblock_"):
+		return rh.handleUnblockButton(c)
+
+	case strings.HasPrefix(data, "rate_back_"):
+		return rh.restoreReviewButtons(c)
+
+	case strings.HasPrefix(data, "rate_resp_"):
+		return rh.handleResponseDecision(c)
+
+	case strings.HasPrefix(data, "rate_remove_"):
+		return rh.handleReportDecision(c, true)
+
+	case strings.HasPrefix(data, "rate_keep_"):
+		return rh.handleReportDecision(c, false)
+
 	case strings.HasPrefix(data, "rate_block_"):
 		logrus.WithField("data", data).Info("Admin block action")
 		return rh.handleAdminBlock(c)
@@ -375,29 +829,49 @@ func (rh *RatingHandler) HandleRateText(c tb.Context) bool {
 	text := strings.TrimSpace(c.Text())
 
 	switch session.Step {
-	case StepEnterName:
-		// Validate name format (Name Surname)
-		nameRegex := regexp.MustCompile(`^[A-Za-zĄĆĘŁŃÓŚŹŻąćęłńóśźż]+\s+[A-Za-zĄĆĘŁŃÓŚŹŻąćęłńóśźż]+$`)
-		if !nameRegex.MatchString(text) {
+	case StepEnterName, StepChooseName:
+		// known names are matched before the format check, so a spelling the check rejects still finds its professor
+		if name, ok := rh.store.DirectoryName(text); ok {
+			rh.acceptProfessor(c, session, name, msgs)
+			return true
+		}
+		cards := professorCards(rh.store.GetApprovedReviews())
+		if name, ok := canonicalProfessor(cards, text); ok {
+			rh.acceptProfessor(c, session, name, msgs)
+			return true
+		}
+		valid := professorNameRegex.MatchString(text)
+		if suggestions := suggestProfessors(cards, text); len(suggestions) > 0 {
+			session.Professor = ""
+			if valid {
+				session.Professor = text
+			}
+			session.Step = StepChooseName
+			var rows [][]tb.InlineButton
+			for _, card := range suggestions {
+				rows = append(rows, []tb.InlineButton{{Data: fmt.Sprintf("rate_name_%d", card.ReviewID), Text: card.Professor}})
+			}
+			if valid {
+				rows = append(rows, []tb.InlineButton{{Unique: "rate_name_keep", Text: fmt.Sprintf(msgs.Rating.BtnKeepName, text)}})
+			}
+			_, _ = rh.bot.Send(c.Chat(), msgs.Rating.DidYouMean, &tb.ReplyMarkup{InlineKeyboard: rows})
+			return true
+		}
+		if !valid {
 			_, _ = rh.bot.Send(c.Chat(), msgs.Rating.InvalidName)
 			return true
 		}
-		session.Professor = text
-		session.Step = StepChooseScore
+		rh.acceptProfessor(c, session, text, msgs)
+		return true
 
-		kb := &tb.ReplyMarkup{
-			InlineKeyboard: [][]tb.InlineButton{
-				{
-					{Unique: "rate_score_1", Text: "1 ⭐"},
-					{Unique: "rate_score_2", Text: "2 ⭐"},
-					{Unique: "rate_score_3", Text: "3 ⭐"},
-					{Unique: "rate_score_4", Text: "4 ⭐"},
-					{Unique: "rate_score_5", Text: "5 ⭐"},
-				},
-				{{Unique: "rate_cancel", Text: msgs.Rating.BtnCancel}},
-			},
+	case StepEnterCourse:
+		if !validCourse(text) {
+			_, _ = rh.bot.Send(c.Chat(), msgs.Rating.InvalidCourse)
+			return true
 		}
-		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.ChooseScore, kb)
+		session.Course = text
+		session.Step = StepEnterReview
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.EnterReview, cancelKeyboard(msgs))
 		return true
 
 	case StepEnterReview:
@@ -410,17 +884,11 @@ func (rh *RatingHandler) HandleRateText(c tb.Context) bool {
 			return true
 		}
 		session.Text = text
-		session.Step = StepConfirm
+		rh.showPreview(c, session, msgs)
+		return true
 
-		// Show preview
-		preview := rh.formatReview(c.Sender(), session, 0, msgs)
-		kb := &tb.ReplyMarkup{
-			InlineKeyboard: [][]tb.InlineButton{
-				{{Unique: "rate_submit", Text: msgs.Rating.BtnSubmit}},
-				{{Unique: "rate_cancel", Text: msgs.Rating.BtnCancel}},
-			},
-		}
-		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.ConfirmReview+"\n\n"+preview, kb, tb.ModeMarkdown)
+	case StepEnterResponse:
+		rh.submitResponse(c, session, text, msgs)
 		return true
 
 	default:
@@ -432,10 +900,151 @@ func (rh *RatingHandler) HandleRateText(c tb.Context) bool {
 	}
 }
 
+// acceptProfessor moves on to the score unless the user already reviewed the professor
+func (rh *RatingHandler) acceptProfessor(c tb.Context, session *RatingSession, name string, msgs *i18n.Messages) {
+	if old, ok := rh.store.UserReview(c.Sender().ID, name); ok {
+		rh.clearSession(c.Sender().ID)
+		notice, kb := duplicateNotice(msgs, old)
+		_, _ = rh.bot.Send(c.Chat(), notice, kb)
+		return
+	}
+	session.Professor = name
+	if session.Revising {
+		rh.showPreview(c, session, msgs)
+		return
+	}
+	session.Step = StepChooseScore
+	_, _ = rh.bot.Send(c.Chat(), msgs.Rating.ChooseScore, scoreKeyboard(msgs))
+}
+
+// showPreview shows the finished review with buttons to submit it or go back to a step
+func (rh *RatingHandler) showPreview(c tb.Context, session *RatingSession, msgs *i18n.Messages) {
+	session.Step = StepConfirm
+	session.Revising = false
+	preview := rh.formatReview(c.Sender(), session, 0, msgs)
+	fix := []tb.InlineButton{{Unique: "rate_fix_score", Text: msgs.Rating.BtnFixScore}, {Unique: "rate_fix_text", Text: msgs.Rating.BtnFixText}}
+	if session.EditingID == 0 {
+		fix = append([]tb.InlineButton{{Unique: "rate_fix_name", Text: msgs.Rating.BtnFixName}}, fix...)
+	}
+	photo := tb.InlineButton{Unique: "rate_photo_add", Text: msgs.Rating.BtnAddPhoto}
+	if session.PhotoID != "" {
+		photo = tb.InlineButton{Unique: "rate_photo_remove", Text: msgs.Rating.BtnRemovePhoto}
+		rh.sendReviewPhoto(c.Chat(), session.PhotoID, "")
+	}
+	kb := &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{
+			{{Unique: "rate_submit", Text: msgs.Rating.BtnSubmit}},
+			fix,
+			{photo},
+			draftRow(msgs),
+		},
+	}
+	_, _ = rh.bot.Send(c.Chat(), msgs.Rating.ConfirmReview+"\n\n"+preview, kb, tb.ModeMarkdown)
+}
+
+// cancelKeyboard returns the draft and cancel buttons shown while waiting for text
+func cancelKeyboard(msgs *i18n.Messages) *tb.ReplyMarkup {
+	return &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{
+			draftRow(msgs),
+		},
+	}
+}
+
+// scoreKeyboard returns the buttons for choosing a score
+func scoreKeyboard(msgs *i18n.Messages) *tb.ReplyMarkup {
+	return &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{
+			{
+				{Unique: "rate_score_1", Text: "1 ⭐"},
+				{Unique: "rate_score_2", Text: "2 ⭐"},
+				{Unique: "rate_score_3", Text: "3 ⭐"},
+				{Unique: "rate_score_4", Text: "4 ⭐"},
+				{Unique: "rate_score_5", Text: "5 ⭐"},
+			},
+			draftRow(msgs),
+		},
+	}
+}
+
+// duplicateNotice tells the user they already reviewed the professor, with a button to edit a published review
+func duplicateNotice(msgs *i18n.Messages, old Review) (string, *tb.ReplyMarkup) {
+	if old.Status != "approved" {
+		return fmt.Sprintf(msgs.Rating.AlreadyPending, old.Professor), nil
+	}
+	kb := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{{Data: fmt.Sprintf("rate_edit_%d", old.ID), Text: fmt.Sprintf(msgs.Rating.BtnEdit, old.ID)}}}}
+	return fmt.Sprintf(msgs.Rating.AlreadyReviewed, old.Professor), kb
+}
+
+// HandleMyReviews lists the user's reviews with a button to edit each published one
+func (rh *RatingHandler) HandleMyReviews(c tb.Context) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	if c.Chat().Type != tb.ChatPrivate {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Common.PrivateOnly)
+		return nil
+	}
+
+	reviews := rh.store.UserReviews(c.Sender().ID)
+	if len(reviews) == 0 {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.MyReviewsEmpty)
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(msgs.Rating.MyReviewsHeader, len(reviews)))
+	var rows [][]tb.InlineButton
+	for _, r := range reviews {
+		status := msgs.Rating.MyStatusPending
+		switch r.Status {
+		case "approved":
+			status = msgs.Rating.MyStatusApproved
+			rows = append(rows, []tb.InlineButton{{Data: fmt.Sprintf("rate_edit_%d", r.ID), Text: fmt.Sprintf(msgs.Rating.BtnEdit, r.ID)}})
+		case "rejected":
+			status = msgs.Rating.MyStatusRejected
+			if r.Reason != "" {
+				status += " (" + rejectReasonLabel(msgs, r.Reason) + ")"
+			}
+		case "removed":
+			status = msgs.Rating.MyStatusRemoved
+		}
+		sb.WriteString(fmt.Sprintf("\n\n#%d 👨‍🏫 %s · [%d/5] · %s\n💬 %s", r.ID, r.Professor, r.Score, status, r.Text))
+	}
+	_, _ = rh.bot.Send(c.Chat(), sb.String(), &tb.ReplyMarkup{InlineKeyboard: rows})
+	return nil
+}
+
+// startEdit restarts the score and text steps for one of the user's published reviews
+func (rh *RatingHandler) startEdit(c tb.Context, session *RatingSession, msgs *i18n.Messages, idText string) error {
+	id, _ := strconv.Atoi(idText)
+	review := rh.store.GetReview(id)
+	if review == nil || review.UserID != c.Sender().ID || review.Status != "approved" {
+		return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Rating.EditDenied})
+	}
+	if rh.store.IsBlocked(c.Sender().ID) {
+		return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Rating.Blocked})
+	}
+	if notice, wait := rh.submissionWait(c.Sender().ID, msgs, time.Now()); wait {
+		return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: notice, ShowAlert: true})
+	}
+
+	session.Step = StepChooseScore
+	session.IsAnonymous = review.IsAnonymous
+	session.Professor = review.Professor
+	session.EditingID = review.ID
+	session.PhotoID = review.PhotoID
+	msg, _ := rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.EditStarted, review.Professor)+"\n\n"+msgs.Rating.ChooseScore, scoreKeyboard(msgs))
+	if msg != nil {
+		session.MessageID = msg.ID
+	}
+	return rh.bot.Respond(c.Callback())
+}
+
 // formatReview formats a review for display
 func (rh *RatingHandler) formatReview(user *tb.User, session *RatingSession, reviewID int, msgs *i18n.Messages) string {
 	sender := msgs.Rating.Anonymous
-	if !session.IsAnonymous && user != nil {
+	if session.IsAnonymous && user != nil {
+		sender = rh.store.Pseudonym(user.ID)
+	} else if user != nil {
 		if user.Username != "" {
 			sender = "@" + user.Username
 		} else {
@@ -448,24 +1057,19 @@ func (rh *RatingHandler) formatReview(user *tb.User, session *RatingSession, rev
 		reviewNum = fmt.Sprintf("#%d", reviewID)
 	}
 
-	return fmt.Sprintf("👨‍🏫 *%s*\n🔸 %s: [%d/5]\n\n💬 %s %s от %s: %s",
+	return fmt.Sprintf("👨‍🏫 *%s*\n🔸 %s: [%d/5]%s\n\n💬 %s %s от %s: %s",
 		session.Professor,
-		msgs.Rating.Score, session.Score,
+		msgs.Rating.Score, session.Score, criteriaLine(msgs, session.Criteria)+courseLine(msgs, session.Course, session.Tag),
 		msgs.Rating.ReviewLabel, reviewNum, sender, session.Text,
 	)
 }
 
 // formatReviewFromData formats review from stored data
 func (rh *RatingHandler) formatReviewFromData(r Review, msgs *i18n.Messages) string {
-	sender := msgs.Rating.Anonymous
-	if !r.IsAnonymous {
-		sender = "@" + r.Username
-	}
-
-	return fmt.Sprintf("👨‍🏫 *%s*\n🔸 %s: [%d/5]\n\n💬 %s #%d от %s: %s",
+	return fmt.Sprintf("👨‍🏫 *%s*\n🔸 %s: [%d/5]%s\n\n💬 %s #%d от %s: %s",
 		r.Professor,
-		msgs.Rating.Score, r.Score,
-		msgs.Rating.ReviewLabel, r.ID, sender, r.Text,
+		msgs.Rating.Score, r.Score, criteriaLine(msgs, r.Criteria)+courseLine(msgs, r.Course, r.Tag)+archivedLine(msgs, r),
+		msgs.Rating.ReviewLabel, r.ID, rh.store.Author(r), r.Text,
 	)
 }
 
@@ -487,56 +1091,58 @@ func (rh *RatingHandler) submitReview(c tb.Context, session *RatingSession) erro
 		Score:       session.Score,
 		Text:        session.Text,
 		Status:      "pending",
+		Replaces:    session.EditingID,
+		Criteria:    session.Criteria,
+		Course:      session.Course,
+		Tag:         session.Tag,
+		PhotoID:     session.PhotoID,
+		Lang:        rh.getLangForUser(c.Sender()),
 	}
 
-	reviewID := rh.store.AddReview(review)
+	reviewID, ok := rh.store.AddReview(review)
 	rh.clearSession(c.Sender().ID)
+	rh.store.DeleteDraft(c.Sender().ID)
+	if !ok {
+		if old := rh.store.GetReview(reviewID); old != nil {
+			notice, kb := duplicateNotice(msgs, *old)
+			_, _ = rh.bot.Edit(c.Message(), notice, kb)
+		}
+		return rh.bot.Respond(c.Callback())
+	}
 
 	_, _ = rh.bot.Edit(c.Message(), msgs.Rating.Submitted)
 
 	// Send it to the admin channel
-	adminMsgs := i18n.Get().T(i18n.RU)
-	adminText := fmt.Sprintf("📝 %s\n\n%s: @%s (ID: %d)\n%s: %s\n%s: %s\n%s: [%d/5] %s\n\n%s: %s",
-		adminMsgs.Rating.NewReviewAdmin,
-		adminMsgs.Rating.Sender, username, c.Sender().ID,
-		adminMsgs.Rating.TypeLabel, func() string {
-			if session.IsAnonymous {
-				return adminMsgs.Rating.Anonymous
-			}
-			return adminMsgs.Rating.Public
-		}(),
-		adminMsgs.Rating.Professor, session.Professor,
-		adminMsgs.Rating.Score, session.Score, strings.Repeat("⭐", session.Score),
-		adminMsgs.Rating.ReviewLabel, session.Text,
-	)
-
-	kb := &tb.ReplyMarkup{
-		InlineKeyboard: [][]tb.InlineButton{
-			{
-				{Data: fmt.Sprintf("rate_approve_%d", reviewID), Text: adminMsgs.Rating.BtnApprove},
-				{Data: fmt.Sprintf("rate_reject_%d", reviewID), Text: adminMsgs.Rating.BtnReject},
-			},
-			{{Data: fmt.Sprintf("rate_block_%d", reviewID), Text: adminMsgs.Rating.BtnBlock}},
-		},
+	adminMsgs := rh.adminMsgs()
+	review.ID = reviewID
+	if review.PhotoID != "" {
+		rh.sendReviewPhoto(&tb.Chat{ID: rh.adminChatID}, review.PhotoID, fmt.Sprintf(adminMsgs.Rating.CardPhoto, reviewID))
 	}
-	_, _ = rh.bot.Send(&tb.Chat{ID: rh.adminChatID}, adminText, kb)
+	_, _ = rh.bot.Send(&tb.Chat{ID: rh.adminChatID}, reviewAdminText(adminMsgs, review)+rh.similarityWarning(adminMsgs, review), reviewKeyboard(adminMsgs, reviewID))
 
 	return rh.bot.Respond(c.Callback())
 }
 
 // handleAdminAction handles approve/reject
 func (rh *RatingHandler) handleAdminAction(c tb.Context, status string) error {
+	if !rh.adminHandler.canReview(c.Sender()) {
+		return rh.denyReview(c)
+	}
 	data := c.Callback().Data
 	if data == "" {
 		data = c.Callback().Unique
 	}
 	var reviewID int
 	var n int
+	var reason string
 
 	if status == "approved" {
 		n, _ = fmt.Sscanf(data, "rate_approve_%d", &reviewID)
 	} else {
-		n, _ = fmt.Sscanf(data, "rate_reject_%d", &reviewID)
+		reviewID, reason, _ = parseRejectReason(data)
+		if reviewID > 0 {
+			n = 1
+		}
 	}
 
 	logrus.WithFields(logrus.Fields{
@@ -556,6 +1162,12 @@ func (rh *RatingHandler) handleAdminAction(c tb.Context, status string) error {
 		logrus.WithField("reviewID", reviewID).Warn("Review not found")
 		return rh.bot.Respond(c.Callback())
 	}
+	if review.Status != "pending" {
+		// Decided already, e.g. from another copy posted by /pending
+		_ = rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: rh.adminMsgs().Rating.AlreadyDecided})
+		_, _ = rh.bot.EditReplyMarkup(c.Message(), nil)
+		return nil
+	}
 
 	logrus.WithFields(logrus.Fields{
 		"reviewID":  reviewID,
@@ -563,29 +1175,45 @@ func (rh *RatingHandler) handleAdminAction(c tb.Context, status string) error {
 		"userID":    review.UserID,
 	}).Info("Review found, updating status")
 
-	rh.store.UpdateReviewStatus(reviewID, status)
+	if status == "rejected" {
+		rh.store.RejectReview(reviewID, reason, c.Sender().ID)
+	} else {
+		rh.store.UpdateReviewStatus(reviewID, status, c.Sender().ID)
+	}
+	if status == "approved" && review.Replaces != 0 {
+		rh.store.ReplaceReview(review.Replaces, reviewID)
+	}
 
-	adminMsgs := i18n.Get().T(i18n.RU)
+	adminMsgs := rh.adminMsgs()
 	statusText := adminMsgs.Rating.StatusApproved
 	if status == "rejected" {
 		statusText = adminMsgs.Rating.StatusRejected
 	}
-	_, err := rh.bot.Edit(c.Message(), c.Message().Text+"\n\n"+statusText)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to edit admin message")
+	kind := actionApprove
+	if status == "rejected" {
+		kind = actionReject
 	}
+	author := &tb.User{ID: review.UserID, Username: review.Username}
+	decisionReason := fmt.Sprintf("отзыв #%d", reviewID)
+	if reason != "" {
+		decisionReason += ": " + rejectReasonLabel(adminMsgs, reason)
+	}
+	rh.adminHandler.RecordDecision(c, statusText, kind, nil, author, decisionReason)
 
 	// Notify user
 	userChat := &tb.Chat{ID: review.UserID}
-	userMsgs := i18n.Get().T(i18n.RU)
+	userMsgs := rh.authorMsgs(*review)
 	var notifMsg string
 	if status == "approved" {
 		notifMsg = fmt.Sprintf(userMsgs.Rating.ReviewApproved, review.Professor)
 	} else {
 		notifMsg = fmt.Sprintf(userMsgs.Rating.ReviewRejected, review.Professor)
+		if reason != "" {
+			notifMsg += "\n" + fmt.Sprintf(userMsgs.Rating.RejectReason, rejectReasonLabel(userMsgs, reason))
+		}
 	}
 
-	_, err = rh.bot.Send(userChat, notifMsg)
+	_, err := rh.bot.Send(userChat, notifMsg)
 	if err != nil {
 		logrus.WithError(err).WithField("userID", review.UserID).Error("Failed to notify user")
 	} else {
@@ -597,6 +1225,9 @@ func (rh *RatingHandler) handleAdminAction(c tb.Context, status string) error {
 
 // handleAdminBlock blocks user
 func (rh *RatingHandler) handleAdminBlock(c tb.Context) error {
+	if !rh.adminHandler.canReview(c.Sender()) {
+		return rh.denyReview(c)
+	}
 	data := c.Callback().Data
 	if data == "" {
 		data = c.Callback().Unique
@@ -613,15 +1244,22 @@ func (rh *RatingHandler) handleAdminBlock(c tb.Context) error {
 		return rh.bot.Respond(c.Callback())
 	}
 
-	rh.store.UpdateReviewStatus(reviewID, "rejected")
+	rh.store.UpdateReviewStatus(reviewID, "rejected", c.Sender().ID)
 	rh.store.BlockUser(review.UserID)
 
-	adminMsgs := i18n.Get().T(i18n.RU)
-	_, _ = rh.bot.Edit(c.Message(), c.Message().Text+"\n\n"+adminMsgs.Rating.StatusBlocked)
+	adminMsgs := rh.adminMsgs()
+	author := &tb.User{ID: review.UserID, Username: review.Username}
+	rh.adminHandler.RecordDecision(c, adminMsgs.Rating.StatusBlocked, actionBlock, nil, author, fmt.Sprintf("отзыв #%d", reviewID))
 
 	return rh.bot.Respond(c.Callback())
 }
 
+// denyReview answers a review button pressed by someone without the reviewer role
+func (rh *RatingHandler) denyReview(c tb.Context) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Roles.ReviewDenied})
+}
+
 // HandleRatings shows the ratings list
 func (rh *RatingHandler) HandleRatings(c tb.Context) error {
 	lang := rh.getLangForUser(c.Sender())
@@ -634,128 +1272,228 @@ func (rh *RatingHandler) HandleRatings(c tb.Context) error {
 	return rh.showRatingsPage(c, 0, "")
 }
 
-// showRatingsPage shows paginated ratings (edits the message if called from callback)
+// professorCard sums up the approved reviews of one professor
+type professorCard struct {
+	Professor string
+	Average   float64
+	Count     int
+	ReviewID  int   // Any review of the professor, used to refer to them in buttons
+	Latest    int64 // Time of the newest review
+	Helpful   int   // Helpful votes minus not helpful ones over all reviews
+	Photos    int   // Reviews with a photo attached
+
+	criteriaSum   map[string]int
+	criteriaCount map[string]int
+}
+
+// professorCards groups reviews by professor, sorted by name
+func professorCards(reviews []Review) []professorCard {
+	index := make(map[string]int)
+	var cards []professorCard
+	for _, r := range reviews {
+		key := professorKey(r.Professor)
+		i, ok := index[key]
+		if !ok {
+			i = len(cards)
+			index[key] = i
+			cards = append(cards, professorCard{Professor: r.Professor, ReviewID: r.ID, criteriaSum: make(map[string]int), criteriaCount: make(map[string]int)})
+		}
+		card := &cards[i]
+		card.Average = (card.Average*float64(card.Count) + float64(r.Score)) / float64(card.Count+1)
+		card.Count++
+		card.Latest = max(card.Latest, r.CreatedAt)
+		up, down := r.helpful()
+		card.Helpful += up - down
+		if r.PhotoID != "" {
+			card.Photos++
+		}
+		for c, n := range r.Criteria {
+			card.criteriaSum[c] += n
+			card.criteriaCount[c]++
+		}
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		return strings.ToLower(cards[i].Professor) < strings.ToLower(cards[j].Professor)
+	})
+	return cards
+}
+
+// sendOrEdit edits the message of a callback or sends a new one for a command
+func (rh *RatingHandler) sendOrEdit(c tb.Context, text string, kb *tb.ReplyMarkup) {
+	if c.Callback() != nil {
+		_, _ = rh.bot.Edit(c.Message(), text, kb, tb.ModeMarkdown)
+		return
+	}
+	_, _ = rh.bot.Send(c.Chat(), text, kb, tb.ModeMarkdown)
+}
+
+// showRatingsPage shows a page of professor score cards with a button to open each one
 func (rh *RatingHandler) showRatingsPage(c tb.Context, page int, search string) error {
 	lang := rh.getLangForUser(c.Sender())
 	msgs := i18n.Get().T(lang)
-	editMode := c.Callback() != nil // If callback exists, we're editing
-
-	var reviews []Review
-	if search != "" {
-		reviews = rh.store.SearchReviews(search)
-	} else {
-		reviews = rh.store.GetApprovedReviews()
-	}
 
-	// Sort reviews by professor name alphabetically
-	sort.Slice(reviews, func(i, j int) bool {
-		return strings.ToLower(reviews[i].Professor) < strings.ToLower(reviews[j].Professor)
-	})
+	query := parseReviewQuery(search)
+	reviews := rh.store.QueryReviews(query)
 
 	if len(reviews) == 0 {
 		text := msgs.Rating.NoReviews
-		if search != "" {
+		var kb *tb.ReplyMarkup
+		if query.filtered() {
 			text = fmt.Sprintf(msgs.Rating.NoSearchResults, search)
+			if suggestions := suggestProfessors(professorCards(rh.store.GetApprovedReviews()), query.Text); query.Text != "" && len(suggestions) > 0 {
+				text += "\n\n" + msgs.Rating.DidYouMean
+				kb = &tb.ReplyMarkup{}
+				for _, card := range suggestions {
+					kb.InlineKeyboard = append(kb.InlineKeyboard, []tb.InlineButton{{Data: fmt.Sprintf("ratings_prof_%d_0", card.ReviewID), Text: card.Professor}})
+				}
+			}
 		}
-		_, _ = rh.bot.Send(c.Chat(), text)
+		_, _ = rh.bot.Send(c.Chat(), text, kb)
 		return nil
 	}
 
-	// Group reviews by professor
-	professorGroups := make(map[string][]Review)
-	var professorOrder []string
-	for _, r := range reviews {
-		if _, exists := professorGroups[r.Professor]; !exists {
-			professorOrder = append(professorOrder, r.Professor)
+	cards := professorCards(reviews)
+	sortCards(cards, query.Sort)
+	totalPages := (len(cards) + cardsPerPage - 1) / cardsPerPage
+	page = max(0, min(page, totalPages-1))
+	start := page * cardsPerPage
+	end := min(start+cardsPerPage, len(cards))
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📊 %s (%d/%d)\n", msgs.Rating.ListHeader, page+1, totalPages))
+	var buttons [][]tb.InlineButton
+	var row []tb.InlineButton
+	for i, card := range cards[start:end] {
+		n := start + i + 1
+		sb.WriteString(fmt.Sprintf("\n%d. *%s*: %.1f★ (%d)", n, card.Professor, card.Average, card.Count))
+		if averages := formatCriteriaAverages(msgs, card); averages != "" {
+			sb.WriteString("\n    " + averages)
 		}
-		professorGroups[r.Professor] = append(professorGroups[r.Professor], r)
+		row = append(row, tb.InlineButton{Data: fmt.Sprintf("ratings_prof_%d_0", card.ReviewID), Text: strconv.Itoa(n)})
+		if len(row) == 5 {
+			buttons, row = append(buttons, row), nil
+		}
+	}
+	if len(row) > 0 {
+		buttons = append(buttons, row)
 	}
 
-	// Pagination by professor groups (not individual reviews)
-	perPage := 3 // Show 3 professors per page
-	totalPages := (len(professorOrder) + perPage - 1) / perPage
-	if page < 0 {
-		page = 0
+	// Circular pagination
+	if totalPages > 1 {
+		prevPage := (page - 1 + totalPages) % totalPages
+		nextPage := (page + 1) % totalPages
+		buttons = append(buttons, []tb.InlineButton{
+			{Data: fmt.Sprintf("ratings_page_%d_%s", prevPage, rh.searches.token(search)), Text: msgs.Rating.BtnPrev},
+			{Data: fmt.Sprintf("ratings_page_%d_%s", nextPage, rh.searches.token(search)), Text: msgs.Rating.BtnNext},
+		})
 	}
-	if page >= totalPages {
-		page = totalPages - 1
+	var sorts []tb.InlineButton
+	for _, order := range reviewSorts {
+		label := sortLabel(msgs, order)
+		if order == query.Sort {
+			label = "✓ " + label
+		}
+		sorted := query
+		sorted.Sort = order
+		sorts = append(sorts, tb.InlineButton{Data: "ratings_page_0_" + rh.searches.token(sorted.String()), Text: label})
 	}
+	buttons = append(buttons, sorts, []tb.InlineButton{{Data: "ratings_search", Text: msgs.Rating.BtnSearch}})
 
-	start := page * perPage
-	end := start + perPage
-	if end > len(professorOrder) {
-		end = len(professorOrder)
-	}
+	rh.sendOrEdit(c, sb.String(), &tb.ReplyMarkup{InlineKeyboard: buttons})
+	return nil
+}
 
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("📊 %s (%d/%d)\n\n", msgs.Rating.ListHeader, page+1, totalPages))
+// showProfessor shows a page of the approved reviews of the professor behind a review ID
+func (rh *RatingHandler) showProfessor(c tb.Context, reviewID, page int) error {
+	rh.renderProfessor(c, reviewID, page)
+	return rh.bot.Respond(c.Callback())
+}
 
-	// Display grouped reviews
-	for i, professor := range professorOrder[start:end] {
-		professorReviews := professorGroups[professor]
+// renderProfessor sends or updates a page of the reviews of a professor, the most helpful first, with voting buttons
+func (rh *RatingHandler) renderProfessor(c tb.Context, reviewID, page int) {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	ref := rh.store.GetReview(reviewID)
+	if ref == nil {
+		return
+	}
+	key := professorKey(ref.Professor)
+	reviews := rh.store.ProfessorReviews(ref.Professor)
+	// Archived reviews are only shown for professors reached through an archive search
+	if current := slices.DeleteFunc(slices.Clone(reviews), func(r Review) bool { return r.Archived }); len(current) > 0 {
+		reviews = current
+	}
+	if len(reviews) == 0 {
+		_ = rh.showRatingsPage(c, 0, "")
+		return
+	}
+	card := professorCards(reviews)[0]
+	sort.SliceStable(reviews, func(i, j int) bool {
+		iu, id := reviews[i].helpful()
+		ju, jd := reviews[j].helpful()
+		return iu-id > ju-jd
+	})
 
-		// Show professor name once
-		sb.WriteString(fmt.Sprintf("*%s*\n", professor))
+	totalPages := (len(reviews) + reviewsPerPage - 1) / reviewsPerPage
+	page = max(0, min(page, totalPages-1))
+	start := page * reviewsPerPage
+	end := min(start+reviewsPerPage, len(reviews))
 
-		// Show all reviews for this professor
-		for _, r := range professorReviews {
-			sender := msgs.Rating.Anonymous
-			if !r.IsAnonymous {
-				sender = "@" + r.Username
-			}
-			sb.WriteString(fmt.Sprintf("🔸 %s: [%d/5]\n💬 %s #%d от %s: %s\n",
-				msgs.Rating.Score, r.Score,
-				msgs.Rating.ReviewLabel, r.ID, sender, r.Text,
-			))
-			if r.ID != professorReviews[len(professorReviews)-1].ID {
-				sb.WriteString("\n")
-			}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("👨‍🏫 *%s*\n%s\n", card.Professor, fmt.Sprintf(msgs.Rating.ProfessorSummary, card.Average, card.Count)))
+	if averages := formatCriteriaAverages(msgs, card); averages != "" {
+		sb.WriteString("📐 " + averages + "\n")
+	}
+	professor, _ := rh.store.ProfessorOf(c.Sender().ID)
+	ownCard := professorKey(professor) == key
+	var buttons [][]tb.InlineButton
+	for _, r := range reviews[start:end] {
+		sender := rh.store.Author(r)
+		sb.WriteString(fmt.Sprintf("\n🔸 %s: [%d/5]%s\n💬 %s #%d от %s: %s\n",
+			msgs.Rating.Score, r.Score, criteriaLine(msgs, r.Criteria)+courseLine(msgs, r.Course, r.Tag)+archivedLine(msgs, r),
+			msgs.Rating.ReviewLabel, r.ID, sender, r.Text,
+		))
+		sb.WriteString(responseLine(msgs, r))
+		up, down := r.helpful()
+		row := []tb.InlineButton{
+			{Data: fmt.Sprintf("ratings_vote_%d_1_%d", r.ID, page), Text: fmt.Sprintf("#%d 👍 %d", r.ID, up)},
+			{Data: fmt.Sprintf("ratings_vote_%d_-1_%d", r.ID, page), Text: fmt.Sprintf("#%d 👎 %d", r.ID, down)},
+			{Data: fmt.Sprintf("ratings_report_%d", r.ID), Text: "🚩"},
 		}
-
-		// Add separator between professors
-		if i < len(professorOrder[start:end])-1 {
-			sb.WriteString("\n━━━━━━━━━━\n\n")
+		if ownCard && canRespond(r, card.Professor) {
+			row = append(row, tb.InlineButton{Data: fmt.Sprintf("ratings_reply_%d", r.ID), Text: msgs.Rating.BtnReply})
 		}
+		buttons = append(buttons, row)
 	}
 
-	// Build keyboard
-	var buttons [][]tb.InlineButton
-
-	// Circular pagination
-	prevPage := page - 1
-	if prevPage < 0 {
-		prevPage = totalPages - 1
+	if totalPages > 1 {
+		sb.WriteString("\n" + fmt.Sprintf("(%d/%d)", page+1, totalPages))
+		prevPage := (page - 1 + totalPages) % totalPages
+		nextPage := (page + 1) % totalPages
+		buttons = append(buttons, []tb.InlineButton{
+			{Data: fmt.Sprintf("ratings_prof_%d_%d", card.ReviewID, prevPage), Text: msgs.Rating.BtnPrev},
+			{Data: fmt.Sprintf("ratings_prof_%d_%d", card.ReviewID, nextPage), Text: msgs.Rating.BtnNext},
+		})
 	}
-
-	nextPage := page + 1
-	if nextPage >= totalPages {
-		nextPage = 0
+	if card.Photos > 0 {
+		buttons = append(buttons, []tb.InlineButton{{Data: fmt.Sprintf("ratings_photos_%d", card.ReviewID), Text: fmt.Sprintf(msgs.Rating.BtnPhotos, card.Photos)}})
 	}
-
-	navRow := []tb.InlineButton{
-		{
-			Data: fmt.Sprintf("ratings_page_%d_%s", prevPage, search),
-			Text: msgs.Rating.BtnPrev,
-		},
-		{
-			Data: fmt.Sprintf("ratings_page_%d_%s", nextPage, search),
-			Text: msgs.Rating.BtnNext,
-		},
+	if rh.pages != nil {
+		if page, ok := rh.pages.URL(card.Professor); ok {
+			buttons = append(buttons, []tb.InlineButton{{URL: page, Text: msgs.Rating.BtnAllReviews}})
+		}
 	}
-	buttons = append(buttons, navRow)
-
-	buttons = append(buttons, []tb.InlineButton{{Data: "ratings_search", Text: msgs.Rating.BtnSearch}})
-
-	kb := &tb.ReplyMarkup{InlineKeyboard: buttons}
-
-	if editMode {
-		// Edit existing message when navigating pages
-		_, _ = rh.bot.Edit(c.Message(), sb.String(), kb, tb.ModeMarkdown)
-	} else {
-		// Send a new message when initially opening /ratings
-		_, _ = rh.bot.Send(c.Chat(), sb.String(), kb, tb.ModeMarkdown)
+	if rh.subs != nil {
+		text := msgs.Rating.BtnSubscribe
+		if rh.subs.Subscribed(card.Professor, c.Sender().ID) {
+			text = msgs.Rating.BtnUnsubscribe
+		}
+		buttons = append(buttons, []tb.InlineButton{{Data: fmt.Sprintf("ratings_sub_%d_%d", card.ReviewID, page), Text: text}})
 	}
-	return nil
+	share := "https://t.me/share/url?url=" + url.QueryEscape(professorDeepLink(rh.botLink(), card.Professor))
+	buttons = append(buttons, []tb.InlineButton{{URL: share, Text: msgs.Rating.BtnShare}})
+	buttons = append(buttons, []tb.InlineButton{{Data: "ratings_page_0_", Text: msgs.Rating.BtnBackToList}})
+
+	rh.sendOrEdit(c, sb.String(), &tb.ReplyMarkup{InlineKeyboard: buttons})
 }
 
 // HandleRatingsCallback handles ratings pagination
@@ -775,12 +1513,59 @@ func (rh *RatingHandler) HandleRatingsCallback(c tb.Context) error {
 		_, _ = rh.bot.Edit(c.Message(), msgs.Rating.SearchPrompt)
 		return rh.bot.Respond(c.Callback())
 
+	case strings.HasPrefix(data, "ratings_prof_"):
+		var reviewID, page int
+		if n, _ := fmt.Sscanf(data, "ratings_prof_%d_%d", &reviewID, &page); n != 2 {
+			return rh.bot.Respond(c.Callback())
+		}
+		return rh.showProfessor(c, reviewID, page)
+
+	case strings.HasPrefix(data, "ratings_vote_"):
+		var reviewID, vote, page int
+		if n, _ := fmt.Sscanf(data, "ratings_vote_%d_%d_%d", &reviewID, &vote, &page); n != 3 || (vote != 1 && vote != -1) {
+			return rh.bot.Respond(c.Callback())
+		}
+		held, ok := rh.store.Vote(reviewID, c.Sender().ID, vote)
+		if !ok {
+			return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Rating.VoteDenied})
+		}
+		rh.renderProfessor(c, reviewID, page)
+		text := msgs.Rating.VoteSaved
+		if held == 0 {
+			text = msgs.Rating.VoteRemoved
+		}
+		return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: text})
+
+	case strings.HasPrefix(data, "ratings_photos_"):
+		reviewID, _ := strconv.Atoi(strings.TrimPrefix(data, "ratings_photos_"))
+		return rh.showPhotos(c, reviewID)
+
+	case strings.HasPrefix(data, "ratings_sub_"):
+		var reviewID, page int
+		if n, _ := fmt.Sscanf(data, "ratings_sub_%d_%d", &reviewID, &page); n != 2 {
+			return rh.bot.Respond(c.Callback())
+		}
+		return rh.toggleSubscription(c, reviewID, page, msgs)
+
+	case strings.HasPrefix(data, "ratings_unsub_"):
+		reviewID, _ := strconv.Atoi(strings.TrimPrefix(data, "ratings_unsub_"))
+		return rh.unsubscribe(c, reviewID, msgs)
+
+	case strings.HasPrefix(data, "ratings_reply_"):
+		reviewID, _ := strconv.Atoi(strings.TrimPrefix(data, "ratings_reply_"))
+		return rh.startResponse(c, reviewID)
+
+	case strings.HasPrefix(data, "ratings_report_"):
+		reviewID, _ := strconv.Atoi(strings.TrimPrefix(data, "ratings_report_"))
+		return rh.reportReview(c, reviewID)
+
 	case strings.HasPrefix(data, "ratings_page_"):
-		parts := strings.SplitN(strings.TrimPrefix(data, "ratings_page_"), "_", 2)
-		page, _ := strconv.Atoi(parts[0])
-		search := ""
-		if len(parts) > 1 {
-			search = parts[1]
+		pageNum, token, _ := strings.Cut(strings.TrimPrefix(data, "ratings_page_"), "_")
+		page, _ := strconv.Atoi(pageNum)
+		search, ok := rh.searches.query(token)
+		if !ok {
+			// The search was forgotten, so start over from the full list
+			page = 0
 		}
 		return rh.showRatingsPage(c, page, search)
 	}
@@ -807,7 +1592,10 @@ func (rh *RatingHandler) HandleSearchText(c tb.Context) bool {
 func (rh *RatingHandler) RegisterHandlers(bot *tb.Bot) {
 	// Rate flow buttons - register specific handlers
 	rateButtons := []string{
-		"rate_cancel", "rate_public", "rate_anonymous", "rate_submit",
+		"rate_cancel", "rate_public", "rate_anonymous", "rate_submit", "rate_name_keep", "rate_manual",
+		"rate_fix_name", "rate_fix_score", "rate_fix_text",
+		"rate_draft_save", "rate_draft_resume", "rate_draft_discard",
+		"rate_photo_add", "rate_photo_remove",
 		"rate_score_1", "rate_score_2", "rate_score_3", "rate_score_4", "rate_score_5",
 	}
 	for _, unique := range rateButtons {
@@ -846,12 +1634,25 @@ func (rh *RatingHandler) RegisterHandlers(bot *tb.Bot) {
 
 		if strings.HasPrefix(callbackID, "rate_approve_") ||
 			strings.HasPrefix(callbackID, "rate_reject_") ||
-			strings.HasPrefix(callbackID, "rate_block_") {
+			strings.HasPrefix(callbackID, "rate_block_") ||
+			strings.HasPrefix(callbackID, "rate_reason_") ||
+			strings.HasPrefix(callbackID, "rate_back_") ||
+			strings.HasPrefix(callbackID, "rate_bulk_") ||
+			strings.HasPrefix(callbackID, "rate_unblock_") ||
+			strings.HasPrefix(callbackID, "rate_remove_") ||
+			strings.HasPrefix(callbackID, "rate_keep_") ||
+			strings.HasPrefix(callbackID, "rate_edit_") ||
+			strings.HasPrefix(callbackID, "rate_name_") ||
+			strings.HasPrefix(callbackID, "rate_dir_") ||
+			strings.HasPrefix(callbackID, "rate_prof_") ||
+			strings.HasPrefix(callbackID, "rate_crit_") ||
+			strings.HasPrefix(callbackID, "rate_tag_") ||
+			callbackID == "rate_course_skip" {
 			logrus.WithField("callbackID", callbackID).Info("Admin button callback detected")
 			return rh.HandleRateCallback(c)
 		}
 
-		if strings.HasPrefix(callbackID, "ratings_page_") || callbackID == "ratings_search" {
+		if strings.HasPrefix(callbackID, "ratings_page_") || strings.HasPrefix(callbackID, "ratings_prof_") || strings.HasPrefix(callbackID, "ratings_vote_") || strings.HasPrefix(callbackID, "ratings_report_") || strings.HasPrefix(callbackID, "ratings_photos_") || callbackID == "ratings_search" {
 			logrus.WithField("callbackID", callbackID).Debug("Ratings pagination/search callback detected")
 			return rh.HandleRatingsCallback(c)
 		}
@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// blockedName returns how to show a user blocked from reviews
+func blockedName(id int64, username string) string {
+	if username == "" {
+		return strconv.FormatInt(id, 10)
+	}
+	return fmt.Sprintf("@%s (ID: %d)", username, id)
+}
+
+// HandleUnblockRating lets a user blocked from reviews write them again, like /unblockrating 12345, or lists the blocked users without an ID
+func (rh *RatingHandler) HandleUnblockRating(c tb.Context) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	if !rh.adminHandler.canReview(c.Sender()) {
+		msg, _ := rh.bot.Send(c.Chat(), msgs.Roles.ReviewDenied)
+		rh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	payload := strings.TrimSpace(c.Message().Payload)
+	if payload == "" {
+		text, kb := rh.renderBlocked(msgs)
+		_, _ = rh.bot.Send(c.Chat(), text, kb)
+		return nil
+	}
+	id, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.UnblockUsage)
+		return nil
+	}
+	if !rh.unblock(c.Sender(), id) {
+		_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.NotBlocked, id))
+		return nil
+	}
+	_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.Unblocked, id))
+	return nil
+}
+
+// renderBlocked lists the users blocked from reviews with an unblock button for each
+func (rh *RatingHandler) renderBlocked(msgs *i18n.Messages) (string, *tb.ReplyMarkup) {
+	blocked := rh.store.Blocked()
+	if len(blocked) == 0 {
+		return msgs.Rating.BlockedEmpty, nil
+	}
+	ids := make([]int64, 0, len(blocked))
+	for id := range blocked {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var sb strings.Builder
+	sb.WriteString(msgs.Rating.BlockedHeader)
+	var rows [][]tb.InlineButton
+	for _, id := range ids {
+		name := blockedName(id, blocked[id])
+		sb.WriteString("\n• " + name)
+		rows = append(rows, []tb.InlineButton{{Data: fmt.Sprintf("rate_unblock_%d", id), Text: "🔓 " + name}})
+	}
+	return sb.String(), &tb.ReplyMarkup{InlineKeyboard: rows}
+}
+
+// handleUnblockButton unblocks the user behind a button of the blocked list and refreshes it
+func (rh *RatingHandler) handleUnblockButton(c tb.Context) error {
+	if !rh.adminHandler.canReview(c.Sender()) {
+		return rh.denyReview(c)
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(c.Callback().Data, "rate_unblock_"), 10, 64)
+	if err != nil {
+		return rh.bot.Respond(c.Callback())
+	}
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	rh.unblock(c.Sender(), id)
+	text, kb := rh.renderBlocked(msgs)
+	_, _ = rh.bot.Edit(c.Message(), text, kb)
+	return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: fmt.Sprintf(msgs.Rating.Unblocked, id)})
+}
+
+// unblock lifts the review block of a user and records who did it
+func (rh *RatingHandler) unblock(by *tb.User, userID int64) bool {
+	username := rh.store.Blocked()[userID]
+	if !rh.store.UnblockUser(userID) {
+		return false
+	}
+	name := blockedName(userID, username)
+	ah := rh.adminHandler
+	ah.actions.Record(ModAction{Kind: actionUnblock, ByID: by.ID, By: ah.GetUserDisplayName(by), UserID: userID, UserName: name})
+	ah.LogToAdmin(fmt.Sprintf("🔓 Доступ к отзывам возвращён\n\nАдмин: %s\nПользователь: %s", ah.adminSignature(by), name))
+	return true
+}
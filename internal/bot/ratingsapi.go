@@ -0,0 +1,151 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// A client can make apiRateLimit requests per apiRateWindow
+const (
+	apiRateLimit  = 60
+	apiRateWindow = time.Minute
+)
+
+// defaultAPIAddr is where the ratings API listens when no address is configured
+const defaultAPIAddr = ":8080"
+
+// RatingsAPI serves approved reviews as read-only JSON for the student council website and as a public RSS feed
+type RatingsAPI struct {
+	store   *RatingStore
+	addr    string
+	token   string
+	link    string // Link to the bot, used by the feed
+	clients *floodCounter
+	mux     *http.ServeMux
+}
+
+// apiProfessor is a professor as listed by /api/professors
+type apiProfessor struct {
+	Name     string             `json:"name"`
+	Average  float64            `json:"average"`
+	Reviews  int                `json:"reviews"`
+	Criteria map[string]float64 `json:"criteria,omitempty"`
+	Link     string             `json:"link"`
+}
+
+// NewRatingsAPI creates the server listening on addr with the JSON API when a token is set and the public feed when
+// feed is on, or returns nil when neither is enabled
+func NewRatingsAPI(store *RatingStore, addr, token, link string, feed bool) *RatingsAPI {
+	if token == "" && !feed {
+		return nil
+	}
+	if addr == "" {
+		addr = defaultAPIAddr
+	}
+	api := &RatingsAPI{store: store, addr: addr, token: token, link: link, clients: newFloodCounter(), mux: http.NewServeMux()}
+	if token != "" {
+		api.mux.HandleFunc("GET /api/professors", api.guard(api.handleProfessors))
+		api.mux.HandleFunc("GET /api/reviews", api.guard(api.handleReviews))
+	}
+	if feed {
+		api.mux.HandleFunc("GET /feed.xml", api.limit(api.handleFeed))
+	}
+	return api
+}
+
+// Start serves the API in the background
+func (api *RatingsAPI) Start() {
+	server := &http.Server{Addr: api.addr, Handler: api.mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		logrus.WithField("addr", api.addr).Info("Ratings API started")
+		if err := server.ListenAndServe(); err != nil {
+			logrus.WithError(err).Error("Ratings API stopped")
+		}
+	}()
+}
+
+// guard checks the rate limit of the client first, so guessing the token is limited too, then the bearer token
+func (api *RatingsAPI) guard(next http.HandlerFunc) http.HandlerFunc {
+	return api.limit(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(api.token)) != 1 {
+			writeAPIError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// limit rejects clients making more than apiRateLimit requests per apiRateWindow
+func (api *RatingsAPI) limit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		if len(api.clients.hit(floodKey{kind: "api " + host}, 0, apiRateWindow)) > apiRateLimit {
+			w.Header().Set("Retry-After", "60")
+			writeAPIError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleProfessors lists the professors with approved reviews and their averages
+func (api *RatingsAPI) handleProfessors(w http.ResponseWriter, _ *http.Request) {
+	professors := make([]apiProfessor, 0)
+	for _, card := range professorCards(api.store.GetApprovedReviews()) {
+		p := apiProfessor{Name: card.Professor, Average: card.Average, Reviews: card.Count, Link: professorDeepLink(api.link, card.Professor)}
+		for c, n := range card.criteriaCount {
+			if p.Criteria == nil {
+				p.Criteria = make(map[string]float64)
+			}
+			p.Criteria[c] = float64(card.criteriaSum[c]) / float64(n)
+		}
+		professors = append(professors, p)
+	}
+	writeAPIJSON(w, struct {
+		Professors []apiProfessor `json:"professors"`
+	}{professors})
+}
+
+// handleReviews lists the approved reviews of the professor given by the professor parameter, newest first
+func (api *RatingsAPI) handleReviews(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.URL.Query().Get("professor"))
+	if name == "" {
+		writeAPIError(w, http.StatusBadRequest, "professor is required")
+		return
+	}
+	reviews := api.store.ProfessorReviews(name)
+	slices.Reverse(reviews)
+	data, err := exportReviews(reviews, "json", api.link, api.store.Author)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "encoding failed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(data)
+}
+
+func writeAPIJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logrus.WithError(err).Warn("Failed to write ratings API response")
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{message})
+}
@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// statsTopReviewers is the number of busiest reviewers /ratingstats names
+const statsTopReviewers = 5
+
+// ratingStats sums up the reviews for /ratingstats
+type ratingStats struct {
+	total      int
+	byStatus   map[string]int
+	approved   int           // Approved by a moderator, including ones later replaced by an edit or removed
+	rejected   int           // Rejected by a moderator
+	latency    time.Duration // Average time in the queue of reviews a moderator decided on
+	timed      int           // Reviews the latency is averaged over
+	buckets    [4]int        // Professors with 1, 2-4, 5-9 and 10 or more approved reviews
+	moderators map[int64]int // Decisions by reviewer ID
+}
+
+// RatingStats computes the statistics of all reviews
+func (rs *RatingStore) RatingStats() ratingStats {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	st := ratingStats{byStatus: make(map[string]int), moderators: make(map[int64]int)}
+	perProfessor := make(map[string]int)
+	var waited time.Duration
+	for _, r := range rs.Reviews {
+		st.total++
+		st.byStatus[r.Status]++
+		if r.Status == "approved" {
+			perProfessor[professorKey(r.Professor)]++
+		}
+		// reviews retired without a moderator, like pending ones merged into another professor, are no decisions
+		if r.ModeratedBy == 0 {
+			continue
+		}
+		switch r.Status {
+		case "approved", "replaced", "removed":
+			st.approved++
+		case "rejected":
+			st.rejected++
+		}
+		if r.ModeratedAt > 0 {
+			waited += time.Duration(r.ModeratedAt-r.CreatedAt) * time.Second
+			st.timed++
+		}
+		st.moderators[r.ModeratedBy]++
+	}
+	if st.timed > 0 {
+		st.latency = waited / time.Duration(st.timed)
+	}
+	for _, n := range perProfessor {
+		switch {
+		case n == 1:
+			st.buckets[0]++
+		case n < 5:
+			st.buckets[1]++
+		case n < 10:
+			st.buckets[2]++
+		default:
+			st.buckets[3]++
+		}
+	}
+	return st
+}
+
+// HandleRatingStats shows reviewers how the rating system is used and moderated
+func (rh *RatingHandler) HandleRatingStats(c tb.Context) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	if !rh.adminHandler.canReview(c.Sender()) {
+		msg, _ := rh.bot.Send(c.Chat(), msgs.Roles.ReviewDenied)
+		rh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	st := rh.store.RatingStats()
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(msgs.Rating.StatsHeader, st.total))
+	for _, status := range []string{"pending", "approved", "rejected", "removed", "replaced"} {
+		if n := st.byStatus[status]; n > 0 {
+			sb.WriteString(fmt.Sprintf("\n• %s: %d", statusLabel(msgs, status), n))
+		}
+	}
+	if decided := st.approved + st.rejected; decided > 0 {
+		sb.WriteString("\n\n" + fmt.Sprintf(msgs.Rating.StatsApproval, float64(st.approved)*100/float64(decided)))
+	}
+	if st.timed > 0 {
+		sb.WriteString("\n" + fmt.Sprintf(msgs.Rating.StatsLatency, formatAge(msgs, st.latency), st.timed))
+	}
+	sb.WriteString("\n\n" + fmt.Sprintf(msgs.Rating.StatsBuckets, st.buckets[0], st.buckets[1], st.buckets[2], st.buckets[3]))
+
+	if len(st.moderators) > 0 {
+		ids := make([]int64, 0, len(st.moderators))
+		for id := range st.moderators {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return st.moderators[ids[i]] > st.moderators[ids[j]] })
+		sb.WriteString("\n\n" + msgs.Rating.StatsTopModerators)
+		for _, id := range ids[:min(len(ids), statsTopReviewers)] {
+			name := strconv.FormatInt(id, 10)
+			if user, ok := rh.adminHandler.users.Find(name); ok {
+				name = rh.adminHandler.GetUserDisplayName(user)
+			}
+			sb.WriteString(fmt.Sprintf("\n• %s: %d", name, st.moderators[id]))
+		}
+	}
+
+	_, _ = rh.bot.Send(c.Chat(), sb.String())
+	return nil
+}
@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// rejectReasons are the preset reasons a reviewer picks when rejecting a review
+var rejectReasons = []string{"insults", "personal_data", "off_topic", "low_effort", "false_info"}
+
+// rejectReasonLabel returns the localized text of a reject reason
+func rejectReasonLabel(msgs *i18n.Messages, reason string) string {
+	switch reason {
+	case "insults":
+		return msgs.Rating.ReasonInsults
+	case "personal_data":
+		return msgs.Rating.ReasonPersonalData
+	case "off_topic":
+		return msgs.Rating.ReasonOffTopic
+	case "low_effort":
+		return msgs.Rating.ReasonLowEffort
+	case "false_info":
+		return msgs.Rating.ReasonFalseInfo
+	}
+	return reason
+}
+
+// parseRejectReason reads the review ID and reason of a rate_reason_<id>_<reason> button
+func parseRejectReason(data string) (int, string, bool) {
+	idText, reason, _ := strings.Cut(strings.TrimPrefix(data, "rate_reason_"), "_")
+	id, err := strconv.Atoi(idText)
+	if err != nil || !slices.Contains(rejectReasons, reason) {
+		return 0, "", false
+	}
+	return id, reason, true
+}
+
+// reviewKeyboard returns the moderation buttons of a review waiting in the admin chat
+func reviewKeyboard(adminMsgs *i18n.Messages, reviewID int) *tb.ReplyMarkup {
+	return &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{
+			{
+				{Data: fmt.Sprintf("rate_approve_%d", reviewID), Text: adminMsgs.Rating.BtnApprove},
+				{Data: fmt.Sprintf("rate_reject_%d", reviewID), Text: adminMsgs.Rating.BtnReject},
+			},
+			{{Data: fmt.Sprintf("rate_block_%d", reviewID), Text: adminMsgs.Rating.BtnBlock}},
+		},
+	}
+}
+
+// askRejectReason replaces the moderation buttons of a review with the reject reasons
+func (rh *RatingHandler) askRejectReason(c tb.Context) error {
+	if !rh.adminHandler.canReview(c.Sender()) {
+		return rh.denyReview(c)
+	}
+	var reviewID int
+	if n, _ := fmt.Sscanf(c.Callback().Data, "rate_reject_%d", &reviewID); n != 1 {
+		return rh.bot.Respond(c.Callback())
+	}
+	adminMsgs := rh.adminMsgs()
+	var rows [][]tb.InlineButton
+	for _, reason := range rejectReasons {
+		rows = append(rows, []tb.InlineButton{{Data: fmt.Sprintf("rate_reason_%d_%s", reviewID, reason), Text: rejectReasonLabel(adminMsgs, reason)}})
+	}
+	rows = append(rows, []tb.InlineButton{{Data: fmt.Sprintf("rate_back_%d", reviewID), Text: adminMsgs.Rating.BtnPrev}})
+	_, _ = rh.bot.EditReplyMarkup(c.Message(), &tb.ReplyMarkup{InlineKeyboard: rows})
+	return rh.bot.Respond(c.Callback())
+}
+
+// restoreReviewButtons brings back the moderation buttons when the reviewer changes their mind about rejecting
+func (rh *RatingHandler) restoreReviewButtons(c tb.Context) error {
+	if !rh.adminHandler.canReview(c.Sender()) {
+		return rh.denyReview(c)
+	}
+	var reviewID int
+	if n, _ := fmt.Sscanf(c.Callback().Data, "rate_back_%d", &reviewID); n != 1 {
+		return rh.bot.Respond(c.Callback())
+	}
+	_, _ = rh.bot.EditReplyMarkup(c.Message(), reviewKeyboard(rh.adminMsgs(), reviewID))
+	return rh.bot.Respond(c.Callback())
+}
@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"capybot/internal/core"
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// RemoteSyncPeriod is how often the subscribed blacklist is fetched
+const RemoteSyncPeriod = time.Hour
+
+// RemoteBlacklist keeps the blacklist in sync with a shared list published at a URL
+type RemoteBlacklist struct {
+	mu        sync.Mutex
+	url       string
+	blacklist core.BlacklistInterface
+	client    *http.Client
+}
+
+// NewRemoteBlacklist subscribes the blacklist to an https URL, or returns nil when no URL is set or it is not https
+func NewRemoteBlacklist(rawURL string, blacklist core.BlacklistInterface) *RemoteBlacklist {
+	if rawURL == "" {
+		return nil
+	}
+	if u, err := url.Parse(rawURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		logrus.WithField("url", rawURL).Error("Remote blacklist URL must be an https URL, subscription disabled")
+		return nil
+	}
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return errors.New("redirect to a non-https URL")
+			}
+			if len(via) >= 10 {
+				return errors.New("too many redirects")
+			}
+			return nil
+		},
+	}
+	return &RemoteBlacklist{url: rawURL, blacklist: blacklist, client: client}
+}
+
+// Sync fetches the list unless it is unchanged since the last fetch and reports the number of entries loaded
+func (rb *RemoteBlacklist) Sync() (loaded int, changed bool, invalid []string, err error) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	req, err := http.NewRequest(http.MethodGet, rb.url, nil)
+	if err != nil {
+		return 0, false, nil, err
+	}
+	if etag := rb.blacklist.RemoteETag(); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := rb.client.Do(req)
+	if err != nil {
+		return 0, false, nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return 0, false, nil, nil
+	case http.StatusOK:
+	default:
+		return 0, false, nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImportSize+1))
+	if err != nil {
+		return 0, false, nil, err
+	}
+	if len(data) > maxImportSize {
+		return 0, false, nil, fmt.Errorf("list is larger than %d KB", maxImportSize>>10)
+	}
+	trimmed := bytes.TrimSpace(data)
+	isJSON := strings.Contains(resp.Header.Get("Content-Type"), "json") || bytes.HasPrefix(trimmed, []byte("[")) || bytes.HasPrefix(trimmed, []byte("{"))
+	entries, skipped := parseBanImport(data, isJSON)
+	if len(entries) == 0 {
+		return 0, false, skipped, errors.New("list has no entries, keeping the previous one")
+	}
+	invalid, err = rb.blacklist.SetRemote(entries, skipped, resp.Header.Get("ETag"))
+	if err != nil {
+		return 0, false, invalid, fmt.Errorf("%w, keeping the previous one", err)
+	}
+	return len(entries) + len(skipped) - len(invalid), true, invalid, nil
+}
+
+// Tick syncs the list on schedule
+func (rb *RemoteBlacklist) Tick(time.Time) {
+	loaded, changed, invalid, err := rb.Sync()
+	if err != nil {
+		logrus.WithError(err).WithField("url", rb.url).Warn("Failed to sync remote blacklist")
+		return
+	}
+	if changed {
+		logrus.WithFields(logrus.Fields{"url": rb.url, "entries": loaded, "invalid": len(invalid)}).Info("Remote blacklist updated")
+	}
+}
+
+// HandleSyncBan fetches the subscribed blacklist right away
+func (ah *AdminHandler) HandleSyncBan(c tb.Context) error {
+	lang := ah.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if c.Message() == nil || c.Sender() == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Admin.BanCommandAdminOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	if ah.remote == nil {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Admin.SyncNotConfigured)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	loaded, changed, invalid, err := ah.remote.Sync()
+	text := msgs.Admin.SyncUnchanged
+	switch {
+	case err != nil:
+		text = fmt.Sprintf(msgs.Admin.SyncFailed, err)
+	case changed:
+		text = fmt.Sprintf(msgs.Admin.SyncDone.Form(msgs.Lang(), loaded), loaded, len(invalid))
+		ah.LogToAdmin(fmt.Sprintf("🔄 Общий чёрный список обновлён\n\nАдмин: %s\nЗаписей: %d\nОшибок: %d", ah.GetUserDisplayName(c.Sender()), loaded, len(invalid)))
+	}
+	msg, _ := ah.bot.Send(c.Chat(), text)
+	ah.DeleteAfter(msg, 30*time.Second)
+	return nil
+}
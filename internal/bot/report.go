@@ -0,0 +1,190 @@
+package bot
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// A member can send reportLimit reports per reportWindow in one chat
+const (
+	reportLimit  = 3
+	reportWindow = 10 * time.Minute
+)
+
+// reportMentions turn a reply into a report the same way /report does
+var reportMentions = []string{"@admin", "@admins"}
+
+// reportTTL is how long a report waits for a decision before it is dropped
+const reportTTL = 48 * time.Hour
+
+// pendingReport is a reported message waiting for a moderator decision
+type pendingReport struct {
+	chat      *tb.Chat
+	messageID int
+	user      *tb.User
+	reporter  *tb.User
+	text      string
+	at        time.Time
+}
+
+// reports keeps reports sent to the admin chat
+type reports struct {
+	mu      sync.Mutex
+	next    int
+	pending map[int]pendingReport
+}
+
+// add stores the report unless the same message is already waiting for a decision, dropping reports nobody decided on in time
+func (rs *reports) add(r pendingReport) (int, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	maps.DeleteFunc(rs.pending, func(_ int, old pendingReport) bool { return time.Since(old.at) > reportTTL })
+	for _, old := range rs.pending {
+		if old.chat.ID == r.chat.ID && old.messageID == r.messageID {
+			return 0, false
+		}
+	}
+	rs.next++
+	r.at = time.Now()
+	rs.pending[rs.next] = r
+	return rs.next, true
+}
+
+func (rs *reports) get(id int) (pendingReport, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	r, ok := rs.pending[id]
+	return r, ok
+}
+
+func (rs *reports) take(id int) (pendingReport, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	r, ok := rs.pending[id]
+	delete(rs.pending, id)
+	return r, ok
+}
+
+// IsReportMention reports whether a group reply mentions @admin or @admins to call the admins like /report does
+func IsReportMention(m *tb.Message) bool {
+	if m.ReplyTo == nil {
+		return false
+	}
+	for _, e := range m.Entities {
+		if e.Type == tb.EntityMention && slices.Contains(reportMentions, strings.ToLower(m.EntityText(e))) {
+			return true
+		}
+	}
+	return false
+}
+
+// messageLink returns a link to the message, or an empty string for chats that cannot be linked
+func messageLink(chat *tb.Chat, messageID int) string {
+	if chat.Username != "" {
+		return fmt.Sprintf("https://t.me/%s/%d", chat.Username, messageID)
+	}
+	if id := strconv.FormatInt(chat.ID, 10); strings.HasPrefix(id, "-100") {
+		return fmt.Sprintf("https://t.me/c/%s/%d", id[4:], messageID)
+	}
+	return ""
+}
+
+// HandleReport sends the replied message to the admin chat with buttons to act on it
+func (ah *AdminHandler) HandleReport(c tb.Context) error {
+	if c.Chat().Type == tb.ChatPrivate || c.Sender() == nil {
+		return nil
+	}
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	reply := c.Message().ReplyTo
+	_ = ah.bot.Delete(c.Message())
+	notify := func(text string) error {
+		msg, _ := ah.bot.Send(c.Chat(), text)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	if reply == nil || reply.Sender == nil {
+		return notify(msgs.Report.ReplyRequired)
+	}
+	if reply.Sender.ID == c.Sender().ID || reply.Sender.IsBot || ah.IsChatPost(c.Chat(), reply) || ah.IsAdmin(c.Chat(), reply.Sender) {
+		return notify(msgs.Report.CannotReport)
+	}
+	if len(ah.reporters.hit(floodKey{chatID: c.Chat().ID, userID: c.Sender().ID, kind: "report"}, c.Message().ID, reportWindow)) > reportLimit {
+		return notify(msgs.Report.RateLimited)
+	}
+	text := reply.Text
+	if text == "" {
+		text = reply.Caption
+	}
+	id, ok := ah.reports.add(pendingReport{chat: c.Chat(), messageID: reply.ID, user: reply.Sender, reporter: c.Sender(), text: text})
+	if !ok {
+		return notify(msgs.Report.AlreadyReported)
+	}
+
+	admin := &tb.Chat{ID: ah.adminChatID}
+	if _, err := ah.bot.Forward(admin, reply); err != nil {
+		logrus.WithError(err).WithField("chat_id", c.Chat().ID).Warn("Failed to forward reported message")
+	}
+	data := strconv.Itoa(id)
+	kb := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{
+		{{Unique: "report_delete", Data: data, Text: "🗑 Удалить"}, {Unique: "report_warn", Data: data, Text: "⚠️ Предупредить"}},
+		{{Unique: "report_ban", Data: data, Text: "🔨 Забанить"}, {Unique: "report_ignore", Data: data, Text: "👌 Игнорировать"}},
+	}}
+	logMsg := fmt.Sprintf("🚩 Жалоба\n\nЧат: %s\nОтправил: %s\nНарушитель: %s\nСообщение: `%s`", c.Chat().Title, ah.GetUserDisplayName(c.Sender()), ah.GetUserDisplayName(reply.Sender), text)
+	if link := messageLink(c.Chat(), reply.ID); link != "" {
+		logMsg += "\n" + link
+	}
+	if _, err := ah.bot.Send(admin, logMsg, kb); err != nil {
+		logrus.WithError(err).WithField("chat_id", c.Chat().ID).Error("Failed to send report")
+	}
+	return notify(msgs.Report.Sent)
+}
+
+// HandleReportAction deletes the reported message, warns or bans its author, or dismisses the report
+func (ah *AdminHandler) HandleReportAction(c tb.Context) error {
+	cb := c.Callback()
+	id, err := strconv.Atoi(cb.Data)
+	if err != nil {
+		return ah.bot.Respond(cb)
+	}
+	r, ok := ah.reports.get(id)
+	if !ok {
+		return ah.bot.Respond(cb)
+	}
+	if !ah.IsModerator(r.chat, c.Sender()) {
+		msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+		return ah.bot.Respond(cb, &tb.CallbackResponse{Text: msgs.Report.ActionDenied})
+	}
+	if r, ok = ah.reports.take(id); !ok {
+		return ah.bot.Respond(cb)
+	}
+
+	reason := i18n.Get().T(ah.getLangForUser(r.user)).Report.Reason
+	verdict, kind := "👌 Жалоба отклонена", actionDismiss
+	if cb.Unique != "report_ignore" {
+		if err := ah.bot.Delete(&tb.StoredMessage{MessageID: strconv.Itoa(r.messageID), ChatID: r.chat.ID}); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{"chat_id": r.chat.ID, "user_id": r.user.ID}).Warn("Failed to delete reported message")
+		}
+	}
+	switch cb.Unique {
+	case "report_delete":
+		verdict, kind = "🗑 Удалено", actionDelete
+	case "report_warn":
+		verdict, kind = "⚠️ Предупреждение", ""
+		ah.warn(r.chat, c.Sender(), r.user, reason)
+	case "report_ban":
+		verdict, kind = "🔨 Бан", ""
+		ah.ban(r.chat, c.Sender(), r.user, 0, reason)
+	}
+	ah.RecordDecision(c, verdict, kind, r.chat, r.user, "жалоба")
+	return ah.bot.Respond(cb)
+}
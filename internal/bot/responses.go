@@ -0,0 +1,260 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// Limits of the text of a professor response
+const (
+	minResponseLength = 10
+	maxResponseLength = 1000
+)
+
+// ProfessorResponse is the official answer of a verified professor under a review
+type ProfessorResponse struct {
+	UserID    int64     `json:"user_id"`
+	Text      string    `json:"text"`
+	Status    string    `json:"status"` // Pending, approved or rejected
+	CreatedAt int64     `json:"created_at"`
+	Lang      i18n.Lang `json:"lang,omitempty"` // Language the professor is notified in
+}
+
+// VerifyProfessor lets a user answer reviews of a professor as them
+func (rs *RatingStore) VerifyProfessor(userID int64, professor string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.Accounts == nil {
+		rs.Accounts = make(map[int64]string)
+	}
+	rs.Accounts[userID] = professor
+	rs.save()
+}
+
+// UnverifyProfessor takes the professor account away from a user and returns the professor it was for
+func (rs *RatingStore) UnverifyProfessor(userID int64) (string, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	professor, ok := rs.Accounts[userID]
+	if !ok {
+		return "", false
+	}
+	delete(rs.Accounts, userID)
+	rs.save()
+	return professor, true
+}
+
+// ProfessorOf returns the professor a user is verified as
+func (rs *RatingStore) ProfessorOf(userID int64) (string, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	professor, ok := rs.Accounts[userID]
+	return professor, ok
+}
+
+// canRespond reports whether a review can get a response from the professor, which is one per review unless it was rejected
+func canRespond(r Review, professor string) bool {
+	return r.Status == "approved" && professorKey(r.Professor) == professorKey(professor) && (r.Response == nil || r.Response.Status == "rejected")
+}
+
+// SetResponse queues the response of a verified professor under a review for moderation
+func (rs *RatingStore) SetResponse(reviewID int, userID int64, lang i18n.Lang, text string) (Review, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	professor, ok := rs.Accounts[userID]
+	if !ok {
+		return Review{}, false
+	}
+	r := rs.review(reviewID)
+	if r == nil || !canRespond(*r, professor) {
+		return Review{}, false
+	}
+	r.Response = &ProfessorResponse{UserID: userID, Text: text, Status: "pending", CreatedAt: time.Now().Unix(), Lang: lang}
+	rs.save()
+	return *r, true
+}
+
+// DecideResponse approves or rejects the pending response under a review
+func (rs *RatingStore) DecideResponse(reviewID int, approve bool) (Review, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	r := rs.review(reviewID)
+	if r == nil || r.Response == nil || r.Response.Status != "pending" {
+		return Review{}, false
+	}
+	response := *r.Response
+	response.Status = "rejected"
+	if approve {
+		response.Status = "approved"
+	}
+	r.Response = &response
+	rs.save()
+	return *r, true
+}
+
+// PendingResponses returns the reviews with a response waiting for moderation
+func (rs *RatingStore) PendingResponses() []Review {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	var result []Review
+	for _, i := range rs.index.byStatus["approved"] {
+		if r := rs.Reviews[i]; r.Response != nil && r.Response.Status == "pending" {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// responseLine returns the approved response under a review as a line to append to it
+func responseLine(msgs *i18n.Messages, r Review) string {
+	if r.Response == nil || r.Response.Status != "approved" {
+		return ""
+	}
+	return fmt.Sprintf("↪️ %s: %s\n", msgs.Rating.ProfessorResponse, r.Response.Text)
+}
+
+// responseAdminText formats a response waiting for moderation for the admin chat
+func (rh *RatingHandler) responseAdminText(adminMsgs *i18n.Messages, r Review) string {
+	author := &tb.User{ID: r.Response.UserID}
+	if user, ok := rh.adminHandler.users.Find(fmt.Sprint(r.Response.UserID)); ok {
+		author = user
+	}
+	return fmt.Sprintf("%s\n\n%s: %s (ID: %d)\n%s: %s\n\n%s: %s\n\n%s: %s",
+		adminMsgs.Rating.ResponseHeader,
+		adminMsgs.Rating.ResponseFrom, rh.adminHandler.GetUserDisplayName(author), r.Response.UserID,
+		adminMsgs.Rating.Professor, r.Professor,
+		fmt.Sprintf(adminMsgs.Rating.ReviewNumber, r.ID), r.Text,
+		adminMsgs.Rating.ResponseLabel, r.Response.Text)
+}
+
+// responseKeyboard returns the moderation buttons of a response
+func responseKeyboard(adminMsgs *i18n.Messages, reviewID int) *tb.ReplyMarkup {
+	return &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{
+		{Data: fmt.Sprintf("rate_resp_ok_%d", reviewID), Text: adminMsgs.Rating.BtnApproveResponse},
+		{Data: fmt.Sprintf("rate_resp_no_%d", reviewID), Text: adminMsgs.Rating.BtnRejectResponse},
+	}}}
+}
+
+// startResponse asks a verified professor for the response to a review
+func (rh *RatingHandler) startResponse(c tb.Context, reviewID int) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	professor, ok := rh.store.ProfessorOf(c.Sender().ID)
+	review := rh.store.GetReview(reviewID)
+	if !ok || review == nil || !canRespond(*review, professor) {
+		return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Rating.ResponseDenied})
+	}
+	rh.sessionsMu.Lock()
+	rh.sessions[c.Sender().ID] = &RatingSession{Step: StepEnterResponse, Professor: professor, RespondingTo: reviewID}
+	rh.sessionsMu.Unlock()
+	kb := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{{Unique: "rate_cancel", Text: msgs.Rating.BtnCancel}}}}
+	_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.EnterResponse, reviewID), kb)
+	return rh.bot.Respond(c.Callback())
+}
+
+// submitResponse sends the response typed by a professor for moderation
+func (rh *RatingHandler) submitResponse(c tb.Context, session *RatingSession, text string, msgs *i18n.Messages) {
+	if n := utf8.RuneCountInString(text); n < minResponseLength {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.ReviewTooShort)
+		return
+	} else if n > maxResponseLength {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.ReviewTooLong)
+		return
+	}
+	rh.clearSession(c.Sender().ID)
+	review, ok := rh.store.SetResponse(session.RespondingTo, c.Sender().ID, rh.getLangForUser(c.Sender()), text)
+	if !ok {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.ResponseDenied)
+		return
+	}
+	_, _ = rh.bot.Send(c.Chat(), msgs.Rating.ResponseSubmitted)
+	adminMsgs := rh.adminMsgs()
+	_, _ = rh.bot.Send(&tb.Chat{ID: rh.adminChatID}, rh.responseAdminText(adminMsgs, review), responseKeyboard(adminMsgs, review.ID))
+}
+
+// handleResponseDecision approves or rejects a response and tells the professor
+func (rh *RatingHandler) handleResponseDecision(c tb.Context) error {
+	if !rh.adminHandler.canReview(c.Sender()) {
+		return rh.denyReview(c)
+	}
+	data := c.Callback().Data
+	approve := strings.HasPrefix(data, "rate_resp_ok_")
+	var reviewID int
+	if n, _ := fmt.Sscanf(data[len("rate_resp_ok_"):], "%d", &reviewID); n != 1 {
+		return rh.bot.Respond(c.Callback())
+	}
+	adminMsgs := rh.adminMsgs()
+	review, ok := rh.store.DecideResponse(reviewID, approve)
+	if !ok {
+		_, _ = rh.bot.EditReplyMarkup(c.Message(), nil)
+		return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: adminMsgs.Rating.ResponseDecided})
+	}
+	professorMsgs := i18n.Get().T(review.Response.Lang)
+	verdict, notice := adminMsgs.Rating.ResponseRejectedAdmin, professorMsgs.Rating.ResponseRejected
+	if approve {
+		verdict, notice = adminMsgs.Rating.ResponseApprovedAdmin, professorMsgs.Rating.ResponseApproved
+	}
+	rh.adminHandler.RecordDecision(c, verdict, "", nil, nil, "")
+	if approve && rh.pages != nil {
+		rh.pages.mark(review.Professor)
+	}
+	if _, err := rh.bot.Send(&tb.Chat{ID: review.Response.UserID}, fmt.Sprintf(notice, review.ID)); err != nil {
+		logrus.WithError(err).WithField("userID", review.Response.UserID).Warn("Failed to notify professor about response")
+	}
+	return rh.bot.Respond(c.Callback())
+}
+
+// HandleVerifyProfessor registers a user as a professor who can answer their reviews, like /verifyprof @user Anna Kowalska
+func (rh *RatingHandler) HandleVerifyProfessor(c tb.Context) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	if !rh.adminHandler.canReview(c.Sender()) {
+		msg, _ := rh.bot.Send(c.Chat(), msgs.Roles.ReviewDenied)
+		rh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	ref, name, _ := strings.Cut(strings.TrimSpace(c.Message().Payload), " ")
+	name = strings.Join(strings.Fields(name), " ")
+	user, ok := rh.adminHandler.users.Find(ref)
+	if !ok || !professorNameRegex.MatchString(name) {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.VerifyProfUsage)
+		return nil
+	}
+	if known, ok := rh.store.DirectoryName(name); ok {
+		name = known
+	} else if known, ok := canonicalProfessor(professorCards(rh.store.GetApprovedReviews()), name); ok {
+		name = known
+	}
+	rh.store.VerifyProfessor(user.ID, name)
+	_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.ProfVerified, rh.adminHandler.GetUserDisplayName(user), name))
+	rh.adminHandler.LogToAdmin(fmt.Sprintf("🎓 Аккаунт преподавателя подтверждён\n\nАдмин: %s\nПользователь: %s\nПреподаватель: %s", rh.adminHandler.GetUserDisplayName(c.Sender()), rh.adminHandler.GetUserDisplayName(user), name))
+	return nil
+}
+
+// HandleUnverifyProfessor takes the professor account away from a user, their approved responses stay
+func (rh *RatingHandler) HandleUnverifyProfessor(c tb.Context) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	if !rh.adminHandler.canReview(c.Sender()) {
+		msg, _ := rh.bot.Send(c.Chat(), msgs.Roles.ReviewDenied)
+		rh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	user, ok := rh.adminHandler.users.Find(strings.TrimSpace(c.Message().Payload))
+	if !ok {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.UnverifyProfUsage)
+		return nil
+	}
+	name, ok := rh.store.UnverifyProfessor(user.ID)
+	if !ok {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.NotProfessorAccount)
+		return nil
+	}
+	_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.ProfUnverified, rh.adminHandler.GetUserDisplayName(user), name))
+	rh.adminHandler.LogToAdmin(fmt.Sprintf("🎓 Аккаунт преподавателя отозван\n\nАдмин: %s\nПользователь: %s\nПреподаватель: %s", rh.adminHandler.GetUserDisplayName(c.Sender()), rh.adminHandler.GetUserDisplayName(user), name))
+	return nil
+}
@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// exportedReview is an approved review as written to an export, anonymous authors appear under their pseudonym
+type exportedReview struct {
+	ID         int            `json:"id"`
+	Professor  string         `json:"professor"`
+	Score      int            `json:"score"`
+	Criteria   map[string]int `json:"criteria,omitempty"`
+	Course     string         `json:"course,omitempty"`
+	Tag        string         `json:"tag,omitempty"`
+	Text       string         `json:"text"`
+	Author     string         `json:"author"`
+	Date       string         `json:"date"`
+	Helpful    int            `json:"helpful"`
+	NotHelpful int            `json:"not_helpful"`
+	Link       string         `json:"link"` // Deep link to the reviews of the professor in the bot
+	Semester   string         `json:"semester"`
+	Archived   bool           `json:"archived"`
+}
+
+// csvCell escapes user-written text that a spreadsheet would run as a formula
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// exportReviews renders approved reviews as JSON or as CSV with one column per criterion, linking professors to the bot at botLink
+func exportReviews(reviews []Review, format, botLink string, author func(Review) string) ([]byte, error) {
+	rows := make([]exportedReview, 0, len(reviews))
+	for _, r := range reviews {
+		up, down := r.helpful()
+		row := exportedReview{
+			ID: r.ID, Professor: r.Professor, Score: r.Score, Criteria: r.Criteria, Course: r.Course, Tag: r.Tag, Text: r.Text,
+			Date: time.Unix(r.CreatedAt, 0).Format(time.RFC3339), Helpful: up, NotHelpful: down, Link: professorDeepLink(botLink, r.Professor),
+			Semester: r.semester(), Archived: r.Archived,
+		}
+		row.Author = author(r)
+		rows = append(rows, row)
+	}
+	if format != "csv" {
+		return json.MarshalIndent(struct {
+			Reviews []exportedReview `json:"reviews"`
+		}{rows}, "", "  ")
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := []string{"id", "professor", "score"}
+	header = append(header, ratingCriteria...)
+	_ = w.Write(append(header, "course", "tag", "text", "author", "date", "helpful", "not_helpful", "link", "semester", "archived"))
+	for _, r := range rows {
+		record := []string{strconv.Itoa(r.ID), csvCell(r.Professor), strconv.Itoa(r.Score)}
+		for _, c := range ratingCriteria {
+			n := ""
+			if r.Criteria[c] > 0 {
+				n = strconv.Itoa(r.Criteria[c])
+			}
+			record = append(record, n)
+		}
+		_ = w.Write(append(record, csvCell(r.Course), r.Tag, csvCell(r.Text), csvCell(r.Author), r.Date, strconv.Itoa(r.Helpful), strconv.Itoa(r.NotHelpful), r.Link, r.Semester, strconv.FormatBool(r.Archived)))
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// HandleExportReviews sends approved reviews to the admin chat as a JSON or CSV document,
+// like /exportreviews csv from:2025-09-01 to:2026-01-31; the rest of the payload filters them as a search does
+func (rh *RatingHandler) HandleExportReviews(c tb.Context) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	if !rh.adminHandler.canReview(c.Sender()) {
+		msg, _ := rh.bot.Send(c.Chat(), msgs.Roles.ReviewDenied)
+		rh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	format, filters, _ := strings.Cut(strings.TrimSpace(c.Message().Payload), " ")
+	format = strings.ToLower(format)
+	if format != "csv" && format != "json" {
+		format, filters = "json", strings.TrimSpace(c.Message().Payload)
+	}
+	query := parseReviewQuery(filters)
+	if query.Sort == "" {
+		query.Sort = sortNewest
+	}
+	reviews := rh.store.QueryReviews(query)
+	if len(reviews) == 0 {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.ExportEmpty)
+		return nil
+	}
+	data, err := exportReviews(reviews, format, rh.botLink(), rh.store.Author)
+	if err != nil {
+		return err
+	}
+	doc := &tb.Document{
+		File:     tb.FromReader(bytes.NewReader(data)),
+		FileName: fmt.Sprintf("reviews-%s.%s", time.Now().Format("2006-01-02"), format),
+		Caption:  fmt.Sprintf(msgs.Rating.ExportCaption, len(reviews)),
+	}
+	if _, err := rh.bot.Send(&tb.Chat{ID: rh.adminChatID}, doc); err != nil {
+		logrus.WithError(err).Error("Failed to send review export")
+		return err
+	}
+	if c.Chat().ID != rh.adminChatID {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.ExportSent)
+	}
+	return nil
+}
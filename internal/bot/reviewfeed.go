@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+)
+
+// feedSize is the number of the newest approved reviews in the feed
+const feedSize = 50
+
+// rssFeed is an RSS 2.0 document
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Language    string    `xml:"language,omitempty"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// publishedAt returns when a review was approved, or when it was written for reviews approved before that was recorded
+func (r Review) publishedAt() int64 {
+	if r.ModeratedAt > 0 {
+		return r.ModeratedAt
+	}
+	return r.CreatedAt
+}
+
+// reviewFeed builds the feed of the most recently published reviews, linking each to the bot
+func reviewFeed(msgs *i18n.Messages, lang i18n.Lang, link string, reviews []Review) rssFeed {
+	feed := rssFeed{Version: "2.0", Channel: rssChannel{
+		Title:       msgs.Rating.ListHeader,
+		Link:        link,
+		Description: msgs.Rating.ListHeader,
+		Language:    string(lang),
+	}}
+	sort.SliceStable(reviews, func(i, j int) bool { return reviews[i].publishedAt() > reviews[j].publishedAt() })
+	for _, r := range reviews[:min(feedSize, len(reviews))] {
+		description := r.Text
+		if details := strings.TrimSpace(criteriaLine(msgs, r.Criteria) + courseLine(msgs, r.Course, r.Tag)); details != "" {
+			description = details + "\n\n" + description
+		}
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       fmt.Sprintf("%s: %d/5 ★", r.Professor, r.Score),
+			Link:        link,
+			Description: description,
+			GUID:        rssGUID{Value: fmt.Sprintf("capybot-review-%d", r.ID)},
+			PubDate:     time.Unix(r.publishedAt(), 0).Format(time.RFC1123Z),
+		})
+	}
+	return feed
+}
+
+// handleFeed serves the most recently published reviews as RSS, open to everyone unlike the JSON endpoints
+func (api *RatingsAPI) handleFeed(w http.ResponseWriter, _ *http.Request) {
+	lang := i18n.Get().GetDefault()
+	feed := reviewFeed(i18n.Get().T(lang), lang, api.link, api.store.QueryReviews(ReviewQuery{Sort: sortNewest}))
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	_, _ = w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		logrus.WithError(err).Warn("Failed to write review feed")
+	}
+}
@@ -0,0 +1,81 @@
+package bot
+
+import "slices"
+
+// reviewIndex locates reviews in RatingStore.Reviews without scanning it; reviews are only ever appended,
+// so positions never move and every list stays in store order, oldest first
+type reviewIndex struct {
+	byID        map[int]int
+	byStatus    map[string][]int
+	byProfessor map[string][]int // By professorKey
+}
+
+// reindex rebuilds the index from scratch, the lock must be held
+func (rs *RatingStore) reindex() {
+	rs.index = reviewIndex{
+		byID:        make(map[int]int, len(rs.Reviews)),
+		byStatus:    make(map[string][]int),
+		byProfessor: make(map[string][]int),
+	}
+	for i := range rs.Reviews {
+		rs.indexAdd(i)
+	}
+}
+
+// indexAdd indexes the review at position i, the lock must be held
+func (rs *RatingStore) indexAdd(i int) {
+	r := &rs.Reviews[i]
+	rs.index.byID[r.ID] = i
+	rs.index.byStatus[r.Status] = append(rs.index.byStatus[r.Status], i)
+	key := professorKey(r.Professor)
+	rs.index.byProfessor[key] = append(rs.index.byProfessor[key], i)
+}
+
+// indexStatus moves a review between the status lists, the lock must be held
+func (rs *RatingStore) indexStatus(id int, old, status string) {
+	i, ok := rs.index.byID[id]
+	if !ok {
+		return
+	}
+	if at, found := slices.BinarySearch(rs.index.byStatus[old], i); found {
+		rs.index.byStatus[old] = slices.Delete(rs.index.byStatus[old], at, at+1)
+	}
+	at, _ := slices.BinarySearch(rs.index.byStatus[status], i)
+	rs.index.byStatus[status] = slices.Insert(rs.index.byStatus[status], at, i)
+}
+
+// review returns the review with the given ID, the lock must be held
+func (rs *RatingStore) review(id int) *Review {
+	if i, ok := rs.index.byID[id]; ok {
+		return &rs.Reviews[i]
+	}
+	return nil
+}
+
+// withStatus returns copies of the reviews with a status, the lock must be held
+func (rs *RatingStore) withStatus(status string) []Review {
+	positions := rs.index.byStatus[status]
+	result := make([]Review, 0, len(positions))
+	for _, i := range positions {
+		result = append(result, rs.Reviews[i])
+	}
+	return result
+}
+
+// professorReviews returns the positions of the reviews of a professor in any status, the lock must be held
+func (rs *RatingStore) professorReviews(professor string) []int {
+	return rs.index.byProfessor[professorKey(professor)]
+}
+
+// ProfessorReviews returns the approved reviews of a professor, oldest first
+func (rs *RatingStore) ProfessorReviews(professor string) []Review {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	result := make([]Review, 0)
+	for _, i := range rs.professorReviews(professor) {
+		if r := rs.Reviews[i]; r.Status == "approved" {
+			result = append(result, r)
+		}
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"fmt"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// maxAlbumPhotos is the number of photos Telegram allows in one album
+const maxAlbumPhotos = 10
+
+// photoKeyboard returns the buttons shown while waiting for the photo of a review
+func photoKeyboard(msgs *i18n.Messages) *tb.ReplyMarkup {
+	return &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{
+			{{Unique: "rate_photo_remove", Text: msgs.Rating.BtnNoPhoto}},
+			draftRow(msgs),
+		},
+	}
+}
+
+// HandleRatePhoto attaches a photo sent in private to the review being written, reporting whether it was taken
+func (rh *RatingHandler) HandleRatePhoto(c tb.Context) bool {
+	if c.Chat().Type != tb.ChatPrivate || c.Message().Photo == nil {
+		return false
+	}
+	rh.sessionsMu.RLock()
+	session, ok := rh.sessions[c.Sender().ID]
+	rh.sessionsMu.RUnlock()
+	if !ok || session.Step != StepAttachPhoto {
+		return false
+	}
+	session.PhotoID = c.Message().Photo.FileID
+	rh.showPreview(c, session, i18n.Get().T(rh.getLangForUser(c.Sender())))
+	return true
+}
+
+// sendReviewPhoto sends the photo attached to a review
+func (rh *RatingHandler) sendReviewPhoto(to tb.Recipient, photoID, caption string) {
+	if _, err := rh.bot.Send(to, &tb.Photo{File: tb.File{FileID: photoID}, Caption: caption}); err != nil {
+		logrus.WithError(err).Warn("Failed to send review photo")
+	}
+}
+
+// showPhotos sends the photos of the approved reviews of a professor as albums
+func (rh *RatingHandler) showPhotos(c tb.Context, reviewID int) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	ref := rh.store.GetReview(reviewID)
+	if ref == nil {
+		return rh.bot.Respond(c.Callback())
+	}
+	var album tb.Album
+	for _, r := range rh.store.QueryReviews(ReviewQuery{Sort: sortNewest}) {
+		if r.PhotoID == "" || professorKey(r.Professor) != professorKey(ref.Professor) {
+			continue
+		}
+		album = append(album, &tb.Photo{File: tb.File{FileID: r.PhotoID}, Caption: fmt.Sprintf("%s #%d", msgs.Rating.ReviewLabel, r.ID)})
+	}
+	if len(album) == 0 {
+		return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Rating.NoPhotos})
+	}
+	for start := 0; start < len(album); start += maxAlbumPhotos {
+		if _, err := rh.bot.SendAlbum(c.Chat(), album[start:min(start+maxAlbumPhotos, len(album))]); err != nil {
+			logrus.WithError(err).WithField("professor", ref.Professor).Warn("Failed to send review photos")
+			break
+		}
+	}
+	return rh.bot.Respond(c.Callback())
+}
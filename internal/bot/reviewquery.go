@@ -0,0 +1,184 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+)
+
+// Sort orders of a review search, an empty one keeps professors sorted by name
+const (
+	sortNewest  = "new"
+	sortHighest = "top"
+	sortHelpful = "helpful"
+)
+
+// reviewSorts are the sort orders offered under the ratings list
+var reviewSorts = []string{sortNewest, sortHighest, sortHelpful}
+
+// queryDateLayout is the date format of the from: and to: filters
+const queryDateLayout = "2006-01-02"
+
+// ReviewQuery selects approved reviews, written as "kowalski #exam min:4 from:2025-09-01 to:2026-01-31 sort:top in:archive"
+type ReviewQuery struct {
+	Text     string    // Professor name or course
+	Tags     []string  // Any of reviewTags
+	MinScore int       // Lowest overall score, 0 for any
+	From     time.Time // First day included, zero for no limit
+	To       time.Time // Last day included, zero for no limit
+	Sort     string    // One of reviewSorts
+	Archived bool      // Whether archived reviews are included
+}
+
+// parseReviewQuery splits a search query into the name or course and its filters, invalid filters are ignored
+func parseReviewQuery(query string) ReviewQuery {
+	var q ReviewQuery
+	var words []string
+	for _, w := range strings.Fields(query) {
+		if tag, ok := strings.CutPrefix(w, "#"); ok {
+			q.Tags = append(q.Tags, strings.ToLower(tag))
+			continue
+		}
+		key, value, ok := strings.Cut(w, ":")
+		if !ok {
+			words = append(words, w)
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "min":
+			if n, err := strconv.Atoi(value); err == nil && n >= 1 && n <= 5 {
+				q.MinScore = n
+			}
+		case "from":
+			if t, err := time.ParseInLocation(queryDateLayout, value, time.Local); err == nil {
+				q.From = t
+			}
+		case "to":
+			if t, err := time.ParseInLocation(queryDateLayout, value, time.Local); err == nil {
+				q.To = t
+			}
+		case "sort":
+			if s := strings.ToLower(value); slices.Contains(reviewSorts, s) {
+				q.Sort = s
+			}
+		case "in":
+			q.Archived = strings.EqualFold(value, "archive")
+		default:
+			words = append(words, w)
+		}
+	}
+	q.Text = strings.Join(words, " ")
+	return q
+}
+
+// String writes the query back in the form parseReviewQuery reads
+func (q ReviewQuery) String() string {
+	parts := []string{}
+	if q.Text != "" {
+		parts = append(parts, q.Text)
+	}
+	for _, tag := range q.Tags {
+		parts = append(parts, "#"+tag)
+	}
+	if q.MinScore > 0 {
+		parts = append(parts, fmt.Sprintf("min:%d", q.MinScore))
+	}
+	if !q.From.IsZero() {
+		parts = append(parts, "from:"+q.From.Format(queryDateLayout))
+	}
+	if !q.To.IsZero() {
+		parts = append(parts, "to:"+q.To.Format(queryDateLayout))
+	}
+	if q.Sort != "" {
+		parts = append(parts, "sort:"+q.Sort)
+	}
+	if q.Archived {
+		parts = append(parts, "in:archive")
+	}
+	return strings.Join(parts, " ")
+}
+
+// filtered reports whether the query narrows the reviews down, rather than only sorting them
+func (q ReviewQuery) filtered() bool {
+	return q.Text != "" || len(q.Tags) > 0 || q.MinScore > 0 || !q.From.IsZero() || !q.To.IsZero() || q.Archived
+}
+
+// matches reports whether an approved review passes the query
+func (q ReviewQuery) matches(r Review) bool {
+	if r.Archived && !q.Archived {
+		return false
+	}
+	if len(q.Tags) > 0 && !slices.Contains(q.Tags, r.Tag) {
+		return false
+	}
+	if r.Score < q.MinScore {
+		return false
+	}
+	created := time.Unix(r.CreatedAt, 0)
+	if !q.From.IsZero() && created.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !created.Before(q.To.AddDate(0, 0, 1)) {
+		return false
+	}
+	if q.Text == "" {
+		return true
+	}
+	folded := normalizeText(q.Text)
+	return nameMatches(q.Text, r.Professor) || strings.Contains(normalizeText(r.Professor), folded) || (r.Course != "" && strings.Contains(normalizeText(r.Course), folded))
+}
+
+// QueryReviews returns the approved reviews passing the query in its sort order
+func (rs *RatingStore) QueryReviews(q ReviewQuery) []Review {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	result := make([]Review, 0)
+	for _, i := range rs.index.byStatus["approved"] {
+		if r := rs.Reviews[i]; q.matches(r) {
+			result = append(result, r)
+		}
+	}
+	switch q.Sort {
+	case sortNewest:
+		sort.SliceStable(result, func(i, j int) bool { return result[i].CreatedAt > result[j].CreatedAt })
+	case sortHighest:
+		sort.SliceStable(result, func(i, j int) bool { return result[i].Score > result[j].Score })
+	case sortHelpful:
+		sort.SliceStable(result, func(i, j int) bool {
+			iu, id := result[i].helpful()
+			ju, jd := result[j].helpful()
+			return iu-id > ju-jd
+		})
+	}
+	return result
+}
+
+// sortLabel returns the localized name of a sort order
+func sortLabel(msgs *i18n.Messages, order string) string {
+	switch order {
+	case sortNewest:
+		return msgs.Rating.SortNewest
+	case sortHighest:
+		return msgs.Rating.SortHighest
+	case sortHelpful:
+		return msgs.Rating.SortHelpful
+	}
+	return order
+}
+
+// sortCards orders professor cards like the query orders reviews: by the latest review, the average or the helpful votes
+func sortCards(cards []professorCard, order string) {
+	switch order {
+	case sortNewest:
+		sort.SliceStable(cards, func(i, j int) bool { return cards[i].Latest > cards[j].Latest })
+	case sortHighest:
+		sort.SliceStable(cards, func(i, j int) bool { return cards[i].Average > cards[j].Average })
+	case sortHelpful:
+		sort.SliceStable(cards, func(i, j int) bool { return cards[i].Helpful > cards[j].Helpful })
+	}
+}
@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"fmt"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// markReported remembers a review waiting for a decision after a report, false if it already is
+func (rh *RatingHandler) markReported(reviewID int) bool {
+	rh.reportedMu.Lock()
+	defer rh.reportedMu.Unlock()
+	if rh.reported[reviewID] {
+		return false
+	}
+	rh.reported[reviewID] = true
+	return true
+}
+
+func (rh *RatingHandler) clearReported(reviewID int) {
+	rh.reportedMu.Lock()
+	defer rh.reportedMu.Unlock()
+	delete(rh.reported, reviewID)
+}
+
+// reportReview sends a published review a reader flagged to the admin chat with buttons to remove or keep it
+func (rh *RatingHandler) reportReview(c tb.Context, reviewID int) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	review := rh.store.GetReview(reviewID)
+	if review == nil || review.Status != "approved" || review.UserID == c.Sender().ID {
+		return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Rating.ReportDenied})
+	}
+	if !rh.markReported(reviewID) {
+		return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Rating.ReportAlready})
+	}
+
+	adminMsgs := rh.adminMsgs()
+	adminText := fmt.Sprintf("%s\n\n%s: %s (ID: %d)\n%s: %s\n%s: @%s (ID: %d)\n\n%s: %s",
+		fmt.Sprintf(adminMsgs.Rating.ReportHeader, reviewID),
+		adminMsgs.Rating.ReportedBy, rh.adminHandler.GetUserDisplayName(c.Sender()), c.Sender().ID,
+		adminMsgs.Rating.Professor, review.Professor,
+		adminMsgs.Rating.Sender, review.Username, review.UserID,
+		adminMsgs.Rating.ReviewLabel, review.Text,
+	)
+	kb := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{
+		{Data: fmt.Sprintf("rate_remove_%d", reviewID), Text: adminMsgs.Rating.BtnRemove},
+		{Data: fmt.Sprintf("rate_keep_%d", reviewID), Text: adminMsgs.Rating.BtnKeep},
+	}}}
+	if _, err := rh.bot.Send(&tb.Chat{ID: rh.adminChatID}, adminText, kb); err != nil {
+		logrus.WithError(err).WithField("reviewID", reviewID).Error("Failed to send review report")
+		rh.clearReported(reviewID)
+		return rh.bot.Respond(c.Callback())
+	}
+	return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Rating.ReportSent})
+}
+
+// handleReportDecision removes a reported review or keeps it published
+func (rh *RatingHandler) handleReportDecision(c tb.Context, remove bool) error {
+	if !rh.adminHandler.canReview(c.Sender()) {
+		return rh.denyReview(c)
+	}
+	format := "rate_keep_%d"
+	if remove {
+		format = "rate_remove_%d"
+	}
+	var reviewID int
+	if n, _ := fmt.Sscanf(c.Callback().Data, format, &reviewID); n != 1 {
+		return rh.bot.Respond(c.Callback())
+	}
+	review := rh.store.GetReview(reviewID)
+	if review == nil {
+		return rh.bot.Respond(c.Callback())
+	}
+	rh.clearReported(reviewID)
+
+	author := &tb.User{ID: review.UserID, Username: review.Username}
+	if !remove {
+		rh.adminHandler.RecordDecision(c, rh.adminMsgs().Rating.ReportKept, actionDismiss, nil, author, fmt.Sprintf("отзыв #%d", reviewID))
+		return rh.bot.Respond(c.Callback())
+	}
+	rh.store.UpdateReviewStatus(reviewID, "removed", c.Sender().ID)
+	rh.adminHandler.RecordDecision(c, rh.adminMsgs().Rating.ReportRemoved, actionDelete, nil, author, fmt.Sprintf("отзыв #%d", reviewID))
+
+	userMsgs := rh.authorMsgs(*review)
+	if _, err := rh.bot.Send(&tb.Chat{ID: review.UserID}, fmt.Sprintf(userMsgs.Rating.ReviewRemoved, review.Professor)); err != nil {
+		logrus.WithError(err).WithField("userID", review.UserID).Warn("Failed to notify user about removed review")
+	}
+	return rh.bot.Respond(c.Callback())
+}
@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"capybot/internal/i18n"
+)
+
+// reviewSimilarityThreshold is the shingle similarity from which a review is flagged as a near-duplicate
+const reviewSimilarityThreshold = 0.7
+
+// maxSimilarShown is the number of similar reviews listed on a moderation card
+const maxSimilarShown = 3
+
+// similarReview is an earlier review of the same professor close to a new one
+type similarReview struct {
+	Review
+	Similarity float64
+}
+
+// SimilarReviews returns the other reviews of the same professor whose text is close to the review, most similar first
+func (rs *RatingStore) SimilarReviews(r Review) []similarReview {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	text := shingles(r.Text)
+	var found []similarReview
+	for _, i := range rs.professorReviews(r.Professor) {
+		other := rs.Reviews[i]
+		if other.ID == r.ID || other.Status == "replaced" {
+			continue
+		}
+		if s := jaccard(text, shingles(other.Text)); s >= reviewSimilarityThreshold {
+			found = append(found, similarReview{other, s})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Similarity > found[j].Similarity })
+	return found
+}
+
+// similarityWarning lists near-duplicates of a review for the admin chat, empty when there are none
+func (rh *RatingHandler) similarityWarning(adminMsgs *i18n.Messages, r Review) string {
+	similar := rh.store.SimilarReviews(r)
+	if len(similar) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\n" + adminMsgs.Rating.SimilarHeader)
+	for _, s := range similar[:min(len(similar), maxSimilarShown)] {
+		author := adminMsgs.Rating.SimilarOtherAuthor
+		if s.UserID == r.UserID {
+			author = adminMsgs.Rating.SimilarSameAuthor
+		}
+		sb.WriteString(fmt.Sprintf("\n• #%d, %.0f%%, %s, %s", s.ID, s.Similarity*100, author, statusLabel(adminMsgs, s.Status)))
+	}
+	return sb.String()
+}
+
+// statusLabel returns the name of a review status in the language of msgs
+func statusLabel(msgs *i18n.Messages, status string) string {
+	switch status {
+	case "pending":
+		return msgs.Rating.ReviewStatusPending
+	case "approved":
+		return msgs.Rating.ReviewStatusApproved
+	case "rejected":
+		return msgs.Rating.ReviewStatusRejected
+	case "removed":
+		return msgs.Rating.ReviewStatusRemoved
+	case "replaced":
+		return msgs.Rating.ReviewStatusReplaced
+	}
+	return status
+}
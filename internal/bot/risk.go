@@ -0,0 +1,220 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// RiskLevel describes how suspicious a joining user looks
+type RiskLevel int
+
+// RiskMedium is the zero value, so users without a stored assessment get the regular quiz
+const (
+	RiskMedium RiskLevel = iota
+	RiskLow
+	RiskHigh
+)
+
+// String returns a short name of the level
+func (r RiskLevel) String() string {
+	switch r {
+	case RiskLow:
+		return "low"
+	case RiskHigh:
+		return "high"
+	default:
+		return "medium"
+	}
+}
+
+// Account ID thresholds used as a rough account-age estimate
+const (
+	oldAccountID    = 1_000_000_000
+	recentAccountID = 6_000_000_000
+	freshAccountID  = 7_000_000_000
+)
+
+// assessRisk scores a joining user with cheap heuristics and maps the score to a level
+func (fh *FeatureHandler) assessRisk(u *tb.User, selfJoined bool) (RiskLevel, int) {
+	score := 0
+	if u.Username == "" {
+		score++
+	}
+	if u.IsPremium {
+		score -= 2
+	}
+	switch {
+	case u.ID >= freshAccountID:
+		score += 2
+	case u.ID >= recentAccountID:
+		score++
+	case u.ID < oldAccountID:
+		score--
+	}
+	// Users added by another member are vouched for, link joins are not
+	if selfJoined {
+		score++
+	}
+	photos, err := fh.bot.ProfilePhotosOf(u)
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", u.ID).Warn("Failed to fetch profile photos")
+	} else if len(photos) == 0 {
+		score += 2
+	}
+
+	level := RiskMedium
+	switch {
+	case score <= 0:
+		level = RiskLow
+	case score >= 4:
+		level = RiskHigh
+	}
+	return level, score
+}
+
+// welcomeKeyboard builds the join keyboard for the given risk level
+func welcomeKeyboard(level RiskLevel, msgs *i18n.Messages) *tb.ReplyMarkup {
+	confirmBtn := tb.InlineButton{Unique: "confirm", Text: msgs.Buttons.Confirm}
+	studentBtn := tb.InlineButton{Unique: "student", Text: msgs.Buttons.Student}
+	guestBtn := tb.InlineButton{Unique: "guest", Text: msgs.Buttons.Guest}
+	adsBtn := tb.InlineButton{Unique: "ads", Text: msgs.Buttons.Ads}
+	switch level {
+	case RiskLow:
+		return &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{confirmBtn}}}
+	case RiskHigh:
+		return &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{studentBtn}, {adsBtn}}}
+	default:
+		return &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{studentBtn}, {guestBtn}, {adsBtn}}}
+	}
+}
+
+// HandleConfirm lifts restriction for low-risk users with a single tap
+func (fh *FeatureHandler) HandleConfirm(c tb.Context) error {
+	lang := fh.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	userID := int(c.Sender().ID)
+	if RiskLevel(fh.state.Risk(userID)) != RiskLow {
+		return fh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Buttons.NotYourButton})
+	}
+	if fh.needsRules(c.Chat(), c.Sender()) {
+		fh.showRules(c, "confirm")
+		return nil
+	}
+	fh.admitConfirmed(c)
+	return nil
+}
+
+// admitConfirmed lets a low-risk user write after the one-button confirmation
+func (fh *FeatureHandler) admitConfirmed(c tb.Context) {
+	lang := fh.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	chat := fh.targetChat(c.Chat(), c.Sender())
+	fh.recordVerification(c.Chat(), c.Sender(), VerifyEvent{Outcome: verifyConfirmed})
+	fh.admitUser(c.Chat(), c.Sender())
+	fh.finishNewbie(c.Sender().ID, c.Message().ID)
+	msg := fh.SendOrEdit(c.Chat(), c.Message(), msgs.Quiz.VerificationPassed, nil)
+	fh.adminHandler.DeleteAfter(msg, 5*time.Second)
+	logMsg := fmt.Sprintf("✅ Пользователь подтвердил, что он не бот (низкий риск).\n\nПользователь: %s", fh.adminHandler.GetUserDisplayName(c.Sender()))
+	fh.adminHandler.LogEvent(chat, eventVerified, c.Sender(), logMsg)
+}
+
+// requestManualApproval asks admins to decide on a user; reason opens the admin card
+func (fh *FeatureHandler) requestManualApproval(c tb.Context, reason string, totalCorrect, totalQuestions int) {
+	lang := fh.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	msg := fh.SendOrEdit(c.Chat(), c.Message(), msgs.Quiz.AwaitingApproval, nil)
+	fh.adminHandler.DeleteAfter(msg, 30*time.Second)
+
+	payload := fmt.Sprintf("%d:%d", fh.targetChat(c.Chat(), c.Sender()).ID, c.Sender().ID)
+	kb := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{
+		{Unique: "verify_approve", Data: payload, Text: "✅ Одобрить"},
+		{Unique: "verify_decline", Data: payload, Text: "❌ Отклонить"},
+	}}}
+	text := fmt.Sprintf("%s\n\nПользователь: %s\nПравильных ответов: %d/%d",
+		reason, fh.adminHandler.GetUserDisplayName(c.Sender()), totalCorrect, totalQuestions)
+	if _, err := fh.bot.Send(&tb.Chat{ID: fh.adminChatID}, text, kb); err != nil {
+		logrus.WithError(err).WithField("user_id", c.Sender().ID).Error("Failed to send approval request")
+	}
+}
+
+// parseVerifyPayload extracts chat and user IDs from approval callback data
+func parseVerifyPayload(data string) (int64, int64, bool) {
+	chatStr, userStr, ok := strings.Cut(data, ":")
+	if !ok {
+		return 0, 0, false
+	}
+	chatID, err := strconv.ParseInt(chatStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	userID, err := strconv.ParseInt(userStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return chatID, userID, true
+}
+
+// HandleVerifyApprove lets a high-risk user into the chat after admin review
+func (fh *FeatureHandler) HandleVerifyApprove(c tb.Context) error {
+	chatID, userID, ok := parseVerifyPayload(c.Callback().Data)
+	if !ok {
+		return fh.bot.Respond(c.Callback())
+	}
+	chat := &tb.Chat{ID: chatID}
+	if !fh.adminHandler.IsModerator(chat, c.Sender()) {
+		msgs := i18n.Get().T(fh.getLangForUser(c.Sender()))
+		return fh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Moderation.AdminOnly})
+	}
+	user := &tb.User{ID: userID}
+	if m, err := fh.bot.ChatMemberOf(chat, user); err == nil && m.User != nil {
+		user = m.User
+	}
+	_, viaRequest := fh.state.JoinRequest(int(userID))
+	fh.recordVerification(chat, user, VerifyEvent{Outcome: verifyApproved, By: fh.adminHandler.GetUserDisplayName(c.Sender())})
+	fh.admitUser(chat, user)
+	fh.finishNewbie(userID, 0)
+
+	if !viaRequest {
+		msgs := i18n.Get().T(fh.getLangForUser(user))
+		if msg, err := fh.bot.Send(chat, fmt.Sprintf(msgs.Quiz.ApprovedByAdmin, fh.adminHandler.GetUserDisplayName(user))); err == nil {
+			fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		}
+	}
+	fh.adminHandler.RecordDecision(c, "✅ Одобрено", actionApprove, chat, user, "проверка")
+	return fh.bot.Respond(c.Callback())
+}
+
+// HandleVerifyDecline removes a high-risk user from the chat after admin review
+func (fh *FeatureHandler) HandleVerifyDecline(c tb.Context) error {
+	chatID, userID, ok := parseVerifyPayload(c.Callback().Data)
+	if !ok {
+		return fh.bot.Respond(c.Callback())
+	}
+	chat := &tb.Chat{ID: chatID}
+	if !fh.adminHandler.IsModerator(chat, c.Sender()) {
+		msgs := i18n.Get().T(fh.getLangForUser(c.Sender()))
+		return fh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Moderation.AdminOnly})
+	}
+	user := &tb.User{ID: userID}
+	fh.recordVerification(chat, user, VerifyEvent{Outcome: verifyDeclined, By: fh.adminHandler.GetUserDisplayName(c.Sender())})
+	if _, ok := fh.state.JoinRequest(int(userID)); ok {
+		fh.rejectUser(user)
+	} else if err := fh.adminHandler.BanUser(chat, user); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": chatID, "user_id": userID}).Error("Failed to remove declined user")
+	} else if err := fh.bot.Unban(chat, user); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": chatID, "user_id": userID}).Warn("Failed to unban declined user")
+	}
+	fh.finishNewbie(userID, 0)
+	fh.adminHandler.RecordDecision(c, "❌ Отклонено", actionReject, chat, user, "проверка")
+	return fh.bot.Respond(c.Callback())
+}
@@ -0,0 +1,266 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// Bot roles, each one including the rights of the roles below it
+const (
+	roleReviewer  = "reviewer"  // approves and rejects ratings
+	roleModerator = "moderator" // runs moderation commands in every chat of the bot
+	roleOwner     = "owner"     // manages roles
+)
+
+// roleRank orders the roles from the weakest
+var roleRank = map[string]int{roleReviewer: 1, roleModerator: 2, roleOwner: 3}
+
+// StaffMember is a user with a bot role
+type StaffMember struct {
+	Role  string    `json:"role"`
+	Name  string    `json:"name,omitempty"`
+	By    int64     `json:"by,omitempty"`
+	Since time.Time `json:"since"`
+}
+
+// RoleStore persists bot roles; owners from the configuration always keep their role
+type RoleStore struct {
+	mu     sync.RWMutex
+	Staff  map[int64]StaffMember `json:"staff"`
+	owners []int64
+	file   string
+}
+
+// NewRoleStore creates a role store backed by a JSON file, with owners given as comma-separated user IDs
+func NewRoleStore(file, owners string) *RoleStore {
+	_ = os.MkdirAll("data", 0755)
+	rs := &RoleStore{
+		Staff: make(map[int64]StaffMember),
+		file:  file,
+	}
+	for _, s := range strings.Split(owners, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			logrus.WithField("value", s).Warn("Ignoring invalid owner ID")
+			continue
+		}
+		rs.owners = append(rs.owners, id)
+	}
+	rs.load()
+	return rs
+}
+
+func (rs *RoleStore) load() {
+	data, err := os.ReadFile(rs.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, rs)
+	if rs.Staff == nil {
+		rs.Staff = make(map[int64]StaffMember)
+	}
+}
+
+func (rs *RoleStore) save() {
+	data, err := json.MarshalIndent(rs, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error("role store marshal")
+		return
+	}
+	if err := os.WriteFile(rs.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("role store write")
+	}
+}
+
+// Role returns the role of a user, empty when they have none
+func (rs *RoleStore) Role(userID int64) string {
+	if slices.Contains(rs.owners, userID) {
+		return roleOwner
+	}
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.Staff[userID].Role
+}
+
+// Has reports whether the user has the role or a stronger one
+func (rs *RoleStore) Has(userID int64, role string) bool {
+	return roleRank[rs.Role(userID)] >= roleRank[role]
+}
+
+// HasOwners reports whether anybody can manage roles yet
+func (rs *RoleStore) HasOwners() bool {
+	if len(rs.owners) > 0 {
+		return true
+	}
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	for _, m := range rs.Staff {
+		if m.Role == roleOwner {
+			return true
+		}
+	}
+	return false
+}
+
+// Set gives a user a role, replacing the previous one
+func (rs *RoleStore) Set(userID int64, m StaffMember) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.Staff[userID] = m
+	rs.save()
+}
+
+// Remove takes the role away from a user
+func (rs *RoleStore) Remove(userID int64) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if _, ok := rs.Staff[userID]; !ok {
+		return false
+	}
+	delete(rs.Staff, userID)
+	rs.save()
+	return true
+}
+
+// List returns a copy of the assigned roles
+func (rs *RoleStore) List() map[int64]StaffMember {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	staff := make(map[int64]StaffMember, len(rs.Staff))
+	for id, m := range rs.Staff {
+		staff[id] = m
+	}
+	return staff
+}
+
+// IsModerator reports whether the user may moderate the chat, as its admin or with a bot role
+func (ah *AdminHandler) IsModerator(chat *tb.Chat, user *tb.User) bool {
+	return ah.roles.Has(user.ID, roleModerator) || ah.IsAdmin(chat, user)
+}
+
+// canReview reports whether the user may approve and reject ratings
+func (ah *AdminHandler) canReview(user *tb.User) bool {
+	return ah.roles.Has(user.ID, roleReviewer) || ah.IsAdmin(&tb.Chat{ID: ah.adminChatID}, user)
+}
+
+// canManageRoles reports whether the user may assign roles; until an owner exists the admins of the admin chat may
+func (ah *AdminHandler) canManageRoles(user *tb.User) bool {
+	if ah.roles.HasOwners() {
+		return ah.roles.Has(user.ID, roleOwner)
+	}
+	return ah.IsAdmin(&tb.Chat{ID: ah.adminChatID}, user)
+}
+
+// HandlePromoteMod gives a bot role to the replied user or the one with the given ID, like /promotemod reviewer 12345
+func (ah *AdminHandler) HandlePromoteMod(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if c.Sender() == nil || !ah.canManageRoles(c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Roles.OwnerOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	role, rest, _ := strings.Cut(strings.TrimSpace(c.Message().Payload), " ")
+	role = strings.ToLower(role)
+	target := roleTarget(c.Message(), rest)
+	if target == nil || roleRank[role] == 0 {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Roles.Usage)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	name := ah.GetUserDisplayName(target)
+	ah.roles.Set(target.ID, StaffMember{Role: role, Name: name, By: c.Sender().ID, Since: time.Now()})
+	_, _ = ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Roles.Promoted, name, role))
+	ah.LogToAdmin(fmt.Sprintf("👮 Назначена роль\n\nАдмин: %s\nПользователь: %s\nРоль: %s", ah.GetUserDisplayName(c.Sender()), name, role))
+	return nil
+}
+
+// HandleDemoteMod takes the bot role away from the replied user or the one with the given ID
+func (ah *AdminHandler) HandleDemoteMod(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if c.Sender() == nil || !ah.canManageRoles(c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Roles.OwnerOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	target := roleTarget(c.Message(), c.Message().Payload)
+	if target == nil {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Roles.DemoteUsage)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	if !ah.roles.Remove(target.ID) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Roles.NotFound)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	name := ah.GetUserDisplayName(target)
+	_, _ = ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Roles.Demoted, name))
+	ah.LogToAdmin(fmt.Sprintf("👮 Роль снята\n\nАдмин: %s\nПользователь: %s", ah.GetUserDisplayName(c.Sender()), name))
+	return nil
+}
+
+// HandleListMods lists the users with bot roles, the strongest first
+func (ah *AdminHandler) HandleListMods(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if c.Sender() == nil || !ah.IsModerator(c.Chat(), c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Moderation.AdminOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	staff := ah.roles.List()
+	for _, id := range ah.roles.owners {
+		if _, ok := staff[id]; !ok {
+			staff[id] = StaffMember{Role: roleOwner}
+		}
+	}
+	if len(staff) == 0 {
+		_, _ = ah.bot.Send(c.Chat(), msgs.Roles.ListEmpty)
+		return nil
+	}
+	ids := make([]int64, 0, len(staff))
+	for id := range staff {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b int64) int {
+		if d := roleRank[staff[b].Role] - roleRank[staff[a].Role]; d != 0 {
+			return d
+		}
+		return strings.Compare(staff[a].Name, staff[b].Name)
+	})
+	var sb strings.Builder
+	sb.WriteString(msgs.Roles.ListHeader)
+	for _, id := range ids {
+		name := staff[id].Name
+		if name == "" {
+			name = strconv.FormatInt(id, 10)
+		}
+		sb.WriteString(fmt.Sprintf("\n• %s — %s", name, staff[id].Role))
+	}
+	_, _ = ah.bot.Send(c.Chat(), sb.String())
+	return nil
+}
+
+// roleTarget resolves the user of a role command from a reply or a numeric ID in the arguments
+func roleTarget(m *tb.Message, args string) *tb.User {
+	if m.ReplyTo != nil && m.ReplyTo.Sender != nil {
+		return m.ReplyTo.Sender
+	}
+	if id, err := strconv.ParseInt(strings.TrimSpace(args), 10, 64); err == nil && id > 0 {
+		return &tb.User{ID: id}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"fmt"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// needsRules reports whether the user still has to accept the rules of the chat
+func (fh *FeatureHandler) needsRules(chat *tb.Chat, user *tb.User) bool {
+	target := fh.targetChat(chat, user)
+	if !fh.settings.Get(target.ID).RulesRequired {
+		return false
+	}
+	_, accepted := fh.state.RulesAccepted(int(user.ID), target.ID)
+	return !accepted
+}
+
+// showRules replaces the verification message with the rules prompt; next is the step to resume after acceptance,
+// kept in the state rather than on the button so it can't be swapped for another
+func (fh *FeatureHandler) showRules(c tb.Context, next string) {
+	lang := fh.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+	fh.state.SetRulesNext(int(c.Sender().ID), next)
+
+	text := msgs.Rules.Prompt + "\n\n" + msgs.Rules.Pinned
+	if link := fh.settings.Get(fh.targetChat(c.Chat(), c.Sender()).ID).RulesLink; link != "" {
+		text = msgs.Rules.Prompt + "\n\n" + fmt.Sprintf(msgs.Rules.Link, link)
+	}
+	kb := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{{Unique: "rules_accept", Text: msgs.Buttons.AcceptRules}}}}
+	_ = fh.SendOrEdit(c.Chat(), c.Message(), text, kb)
+}
+
+// HandleRulesAccept records rules acceptance and resumes the verification step, checking again that the user may take it
+func (fh *FeatureHandler) HandleRulesAccept(c tb.Context) error {
+	msgs := i18n.Get().T(fh.getLangForUser(c.Sender()))
+	userID := int(c.Sender().ID)
+	target := fh.targetChat(c.Chat(), c.Sender())
+	next, _ := fh.state.TakeRulesNext(userID)
+	risk := RiskLevel(fh.state.Risk(userID))
+	totalCorrect, totalQuestions := fh.state.TotalCorrect(userID), len(fh.quizFor(target.ID).GetQuestions())
+
+	var admit func()
+	switch {
+	case next == "guest" && risk != RiskHigh:
+		admit = func() { fh.admitGuest(c) }
+	case next == "confirm" && risk == RiskLow:
+		admit = func() { fh.admitConfirmed(c) }
+	// The quiz step is only stored once all required questions were answered, the score is checked again
+	case next == "quiz" && fh.settings.Get(target.ID).quizPassed(totalCorrect, totalQuestions):
+		admit = func() {
+			fh.passQuiz(c, totalCorrect, totalQuestions)
+			fh.state.Reset(userID)
+		}
+	default:
+		return fh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Buttons.NotYourButton})
+	}
+	fh.state.AcceptRules(userID, target.ID)
+	logrus.WithFields(logrus.Fields{"user_id": userID, "chat_id": target.ID}).Info("User accepted the rules")
+	admit()
+	return nil
+}
@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// scheduledJob is a job run at most once per period
+type scheduledJob struct {
+	period time.Duration
+	last   time.Time
+	run    func(now time.Time)
+}
+
+// Scheduler runs registered jobs periodically
+type Scheduler struct {
+	mu       sync.Mutex
+	interval time.Duration
+	jobs     map[string]*scheduledJob
+}
+
+// NewScheduler creates a scheduler ticking with the given interval
+func NewScheduler(interval time.Duration) *Scheduler {
+	return &Scheduler{interval: interval, jobs: make(map[string]*scheduledJob)}
+}
+
+// Every registers a job run once per period, rounded up to the tick interval
+func (s *Scheduler) Every(name string, period time.Duration, job func(now time.Time)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[name] = &scheduledJob{period: period, run: job}
+}
+
+// Start runs the jobs in the background, the first time right away
+func (s *Scheduler) Start() {
+	go func() {
+		s.tick(time.Now())
+		for now := range time.Tick(s.interval) {
+			s.tick(now)
+		}
+	}()
+}
+
+func (s *Scheduler) tick(now time.Time) {
+	s.mu.Lock()
+	due := make(map[string]func(now time.Time))
+	for name, job := range s.jobs {
+		if now.Sub(job.last) >= job.period {
+			job.last = now
+			due[name] = job.run
+		}
+	}
+	s.mu.Unlock()
+	for name, run := range due {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logrus.WithField("job", name).Errorf("Scheduled job panicked: %v", r)
+				}
+			}()
+			run(now)
+		}()
+	}
+}
@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"strconv"
+	"sync"
+)
+
+// searchTokenLimit is the number of searches remembered for pagination buttons, the oldest are forgotten first
+const searchTokenLimit = 1000
+
+// searchTokens maps short tokens to search queries so callback data stays within 64 bytes whatever the query is
+type searchTokens struct {
+	mu      sync.Mutex
+	next    int64
+	queries map[string]string // Query by token
+	tokens  map[string]string // Token by query
+}
+
+// token returns the token of a query, issuing a new one for a query not seen recently; an empty query has an empty token
+func (st *searchTokens) token(query string) string {
+	if query == "" {
+		return ""
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if t, ok := st.tokens[query]; ok {
+		return t
+	}
+	if st.queries == nil {
+		st.queries = make(map[string]string)
+		st.tokens = make(map[string]string)
+	}
+	st.next++
+	t := strconv.FormatInt(st.next, 36)
+	st.queries[t], st.tokens[query] = query, t
+	if old := strconv.FormatInt(st.next-searchTokenLimit, 36); st.next > searchTokenLimit {
+		delete(st.tokens, st.queries[old])
+		delete(st.queries, old)
+	}
+	return t
+}
+
+// query returns the query behind a token, false once it was forgotten or after a restart
+func (st *searchTokens) query(token string) (string, bool) {
+	if token == "" {
+		return "", true
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	q, ok := st.queries[token]
+	return q, ok
+}
@@ -0,0 +1,151 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// semesterRegex matches a semester code, like 2025W for the winter semester of 2025/26 and 2026S for the summer one
+var semesterRegex = regexp.MustCompile(`^\d{4}[WS]$`)
+
+// semesterOf returns the semester a time falls in; the winter semester runs from October to February, the summer one from March to September
+func semesterOf(t time.Time) string {
+	switch year, month := t.Year(), t.Month(); {
+	case month >= time.October:
+		return fmt.Sprintf("%dW", year)
+	case month <= time.February:
+		return fmt.Sprintf("%dW", year-1)
+	default:
+		return fmt.Sprintf("%dS", year)
+	}
+}
+
+// semester returns the semester the review was written in, reviews saved before semesters were recorded get it from their date
+func (r Review) semester() string {
+	if r.Semester != "" {
+		return r.Semester
+	}
+	return semesterOf(time.Unix(r.CreatedAt, 0))
+}
+
+// semesterLabel returns the localized name of a semester code
+func semesterLabel(msgs *i18n.Messages, code string) string {
+	year, err := strconv.Atoi(code[:len(code)-1])
+	if err != nil {
+		return code
+	}
+	if strings.HasSuffix(code, "W") {
+		return fmt.Sprintf(msgs.Rating.SemesterWinter, year, (year+1)%100)
+	}
+	return fmt.Sprintf(msgs.Rating.SemesterSummer, year)
+}
+
+// archivedLine marks an archived review with its semester, empty for a current one
+func archivedLine(msgs *i18n.Messages, r Review) string {
+	if !r.Archived {
+		return ""
+	}
+	return fmt.Sprintf("\n🗄 %s · %s", msgs.Rating.ArchivedLabel, semesterLabel(msgs, r.semester()))
+}
+
+// semesterCount is the number of approved reviews of a semester in the listing and in the archive
+type semesterCount struct {
+	current, archived int
+}
+
+// Semesters counts the approved reviews by semester
+func (rs *RatingStore) Semesters() map[string]semesterCount {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	counts := make(map[string]semesterCount)
+	for _, i := range rs.index.byStatus["approved"] {
+		r := rs.Reviews[i]
+		n := counts[r.semester()]
+		if r.Archived {
+			n.archived++
+		} else {
+			n.current++
+		}
+		counts[r.semester()] = n
+	}
+	return counts
+}
+
+// ArchiveSemester moves the reviews of a semester out of the default listing and returns how many approved ones it moved
+// and the professors they belong to
+func (rs *RatingStore) ArchiveSemester(semester string) (int, []string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	moved, changed := 0, false
+	var professors []string
+	for i := range rs.Reviews {
+		r := &rs.Reviews[i]
+		if r.Archived || r.semester() != semester {
+			continue
+		}
+		r.Archived, changed = true, true
+		if r.Status == "approved" {
+			moved++
+			if !slices.Contains(professors, r.Professor) {
+				professors = append(professors, r.Professor)
+			}
+		}
+	}
+	if changed {
+		rs.save()
+	}
+	return moved, professors
+}
+
+// HandleArchiveSemester archives the reviews of a finished semester, like /archivesemester 2025W; without a semester it lists them
+func (rh *RatingHandler) HandleArchiveSemester(c tb.Context) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	if !rh.adminHandler.canReview(c.Sender()) {
+		msg, _ := rh.bot.Send(c.Chat(), msgs.Roles.ReviewDenied)
+		rh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	semester := strings.ToUpper(strings.TrimSpace(c.Message().Payload))
+	if !semesterRegex.MatchString(semester) {
+		counts := rh.store.Semesters()
+		codes := make([]string, 0, len(counts))
+		for code := range counts {
+			codes = append(codes, code)
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(codes)))
+		var sb strings.Builder
+		sb.WriteString(msgs.Rating.ArchiveSemesterUsage)
+		if len(codes) > 0 {
+			sb.WriteString("\n\n" + msgs.Rating.ArchiveSemesterList)
+		}
+		for _, code := range codes {
+			sb.WriteString("\n" + fmt.Sprintf(msgs.Rating.ArchiveSemesterItem, code, semesterLabel(msgs, code), counts[code].current, counts[code].archived))
+		}
+		_, _ = rh.bot.Send(c.Chat(), sb.String())
+		return nil
+	}
+	if semester == semesterOf(time.Now()) {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Rating.ArchiveSemesterCurrent)
+		return nil
+	}
+	moved, professors := rh.store.ArchiveSemester(semester)
+	if rh.pages != nil {
+		for _, professor := range professors {
+			rh.pages.mark(professor)
+		}
+	}
+	_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.ArchiveSemesterDone, semester, semesterLabel(msgs, semester), moved))
+	if moved > 0 {
+		rh.adminHandler.LogToAdmin(fmt.Sprintf("🗄 Семестр в архиве\n\nАдмин: %s\nСеместр: %s (%s)\nОтзывов: %d", rh.adminHandler.GetUserDisplayName(c.Sender()), semester, semesterLabel(rh.adminMsgs(), semester), moved))
+	}
+	return nil
+}
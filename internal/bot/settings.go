@@ -0,0 +1,456 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"capybot/internal/core"
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// ChatSettings holds per-chat configuration
+type ChatSettings struct {
+	RulesRequired bool          `json:"rules_required"`
+	RulesLink     string        `json:"rules_link"`
+	WelcomeMedia  *WelcomeMedia `json:"welcome_media,omitempty"`
+	// QuizPassScore is the number of correct answers needed to pass, 0 means two thirds of the questions
+	QuizPassScore int `json:"quiz_pass_score"`
+	// QuizRequireAll fails quizzes with skipped questions, the pass score applies on top of it
+	QuizRequireAll bool `json:"quiz_require_all"`
+	// QuizPartialReview sends users with some correct answers to admins instead of rejecting them
+	QuizPartialReview bool `json:"quiz_partial_review"`
+	// PromoFilterDisabled turns off removal of invite links and channel mentions
+	PromoFilterDisabled bool `json:"promo_filter_disabled"`
+	// PromoLevel is the action for promotion, empty means delete_warn
+	PromoLevel string `json:"promo_level,omitempty"`
+	// PromoAllowlist holds our own channels and invite links that may be posted
+	PromoAllowlist []string `json:"promo_allowlist,omitempty"`
+	// NewbieHours is how long after verification a member counts as new, 0 means a day
+	NewbieHours int `json:"newbie_hours"`
+	// FilterPhones, FilterEmails and FilterBotMentions remove messages with such entities
+	FilterPhones      bool `json:"filter_phones"`
+	FilterEmails      bool `json:"filter_emails"`
+	FilterBotMentions bool `json:"filter_bot_mentions"`
+	// FilterChannelForwards removes messages forwarded from channels
+	FilterChannelForwards bool `json:"filter_channel_forwards"`
+	// FilterSenderChat removes messages sent on behalf of a channel
+	FilterSenderChat bool `json:"filter_sender_chat"`
+	// CASDisabled turns off the Combot Anti-Spam lookup on join
+	CASDisabled bool `json:"cas_disabled"`
+	// BayesDisabled turns off the spam classifier
+	BayesDisabled bool `json:"bayes_disabled"`
+	// BayesDeleteScore and BayesReviewScore are spam probabilities in percent to act on, 0 means 95 and 80
+	BayesDeleteScore int `json:"bayes_delete_score"`
+	BayesReviewScore int `json:"bayes_review_score"`
+	// BayesLevel is the action for likely spam, empty means delete
+	BayesLevel string `json:"bayes_level,omitempty"`
+	// ModerationEnabled sends messages to the external moderation provider
+	ModerationEnabled bool `json:"moderation_enabled"`
+	// ModerationLogScore, ModerationWarnScore and ModerationDeleteScore are thresholds in percent, 0 means 50, 70 and 90
+	ModerationLogScore    int `json:"moderation_log_score"`
+	ModerationWarnScore   int `json:"moderation_warn_score"`
+	ModerationDeleteScore int `json:"moderation_delete_score"`
+	// CapsFilter handles messages written mostly in capital letters
+	CapsFilter bool `json:"caps_filter"`
+	// CapsMinLetters is the shortest message checked, 0 means 20 letters
+	CapsMinLetters int `json:"caps_min_letters"`
+	// CapsPercent is the share of capitals that triggers the filter, 0 means 70
+	CapsPercent int `json:"caps_percent"`
+	// CapsLevel is the action for shouting, empty means delete_warn
+	CapsLevel string `json:"caps_level,omitempty"`
+	// SimilarityDisabled turns off matching against recently deleted spam
+	SimilarityDisabled bool `json:"similarity_disabled"`
+	// SimilarityPercent is the similarity to known spam that triggers the filter, 0 means 80
+	SimilarityPercent int `json:"similarity_percent"`
+	// SimilarityLevel is the action for messages resembling known spam, empty means delete
+	SimilarityLevel string `json:"similarity_level,omitempty"`
+	// ProfileCheck runs names and bios of joining users through the link and blacklist filters
+	ProfileCheck bool `json:"profile_check"`
+	// ProfileAction is kick or quiz for users with a suspicious profile, empty means kick
+	ProfileAction string `json:"profile_action,omitempty"`
+	// WarnLimit is how many strikes lead to a ban, 0 means 2
+	WarnLimit int `json:"warn_limit"`
+	// WarnExpiryDays is how long a strike counts towards the limit, 0 means 30 days
+	WarnExpiryDays int `json:"warn_expiry_days"`
+	// DigestEvents lists routine events collected into a periodic summary instead of one admin message each
+	DigestEvents []string `json:"digest_events,omitempty"`
+	// DigestHours is how often the summary is sent, 0 means once a day
+	DigestHours int `json:"digest_hours"`
+	// SpamWipeHours is how far back /spamban deletes the spammer's messages, 0 means a day
+	SpamWipeHours int `json:"spam_wipe_hours"`
+	// ConfirmActions asks admins to confirm bans, kicks and purges with a button before they happen
+	ConfirmActions bool `json:"confirm_actions"`
+	// ProbationMessages is how many first messages after verification get stricter rules, 0 means off
+	ProbationMessages int `json:"probation_messages"`
+	// ProbationPremod holds probation messages for admin approval
+	ProbationPremod bool `json:"probation_premod"`
+	// SilentActions lists actions taken without a public warning, only logged to the admin chat
+	SilentActions []string `json:"silent_actions,omitempty"`
+	// NewbieMedia lists media kinds new and unverified members may not post
+	NewbieMedia []string `json:"newbie_media,omitempty"`
+	// NewbieMaxLength and NewbieMaxEmoji limit messages of new members, 0 means no limit
+	NewbieMaxLength int `json:"newbie_max_length"`
+	NewbieMaxEmoji  int `json:"newbie_max_emoji"`
+	// FloodDisabled turns off the message frequency limiter
+	FloodDisabled bool `json:"flood_disabled"`
+	// FloodLimit is how many messages fit in the window, 0 means 8
+	FloodLimit int `json:"flood_limit"`
+	// FloodWindow is the window length in seconds, 0 means 10
+	FloodWindow int `json:"flood_window"`
+	// FloodMuteMinutes is how long a flooder stays muted, 0 means 10
+	FloodMuteMinutes int `json:"flood_mute_minutes"`
+	// SlowModeSeconds is the bot-enforced delay between messages of one user, 0 means off
+	SlowModeSeconds int `json:"slow_mode_seconds"`
+	// SlowModeUntil is when slow mode switches off by itself, 0 means never
+	SlowModeUntil int64 `json:"slow_mode_until,omitempty"`
+	// NightHours are daily quiet hours like 01:00-07:00 in server time, empty means off
+	NightHours string `json:"night_hours,omitempty"`
+	// NightDelete deletes messages during quiet hours instead of closing the chat
+	NightDelete bool `json:"night_delete"`
+	// NightActive and NightSavedRights track the running night and the permissions to restore after it
+	NightActive      bool       `json:"night_active,omitempty"`
+	NightSavedRights *tb.Rights `json:"night_saved_rights,omitempty"`
+	// DuplicatesDisabled turns off deleting repeated copies of the same message
+	DuplicatesDisabled bool `json:"duplicates_disabled"`
+	// DuplicateWindow is how long a message is remembered in minutes, 0 means 10
+	DuplicateWindow int `json:"duplicate_window"`
+	// DuplicateMuteAfter is how many copies from one user lead to a mute, 0 means 3
+	DuplicateMuteAfter int `json:"duplicate_mute_after"`
+	// MediaFloodDisabled turns off the sticker and GIF flood control
+	MediaFloodDisabled bool `json:"media_flood_disabled"`
+	// MediaFloodLimit is how many stickers or GIFs of one kind fit in the window, 0 means 5
+	MediaFloodLimit int `json:"media_flood_limit"`
+	// MediaFloodWindow is the window length in seconds, 0 means a minute
+	MediaFloodWindow int `json:"media_flood_window"`
+	// RemoteLevels applies the levels of the subscribed blacklist as published, otherwise they are capped at delete
+	RemoteLevels bool `json:"remote_levels"`
+}
+
+// newbiePeriod returns how long a verified member is treated as new
+func (cs ChatSettings) newbiePeriod() time.Duration {
+	if cs.NewbieHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(cs.NewbieHours) * time.Hour
+}
+
+// bayesLevel returns the action for messages the classifier finds likely spam
+func (cs ChatSettings) bayesLevel() string {
+	if cs.BayesLevel == "" {
+		return core.LevelDelete
+	}
+	return cs.BayesLevel
+}
+
+// quizComplete reports whether enough questions were answered rather than skipped
+func (cs ChatSettings) quizComplete(answered, total int) bool {
+	return !cs.QuizRequireAll || answered >= total
+}
+
+// quizPassed reports whether the score passes the chat quiz
+func (cs ChatSettings) quizPassed(correct, total int) bool {
+	need := cs.QuizPassScore
+	if need <= 0 {
+		need = (2*total + 2) / 3
+	}
+	return correct >= min(need, total)
+}
+
+// defaultChatSettings returns settings for chats that were never configured
+func defaultChatSettings() ChatSettings {
+	return ChatSettings{}
+}
+
+// SettingsStore persists per-chat settings
+type SettingsStore struct {
+	mu    sync.RWMutex
+	Chats map[int64]*ChatSettings `json:"chats"`
+	file  string
+}
+
+// NewSettingsStore creates a settings store backed by a JSON file
+func NewSettingsStore(file string) *SettingsStore {
+	_ = os.MkdirAll("data", 0755)
+	ss := &SettingsStore{
+		Chats: make(map[int64]*ChatSettings),
+		file:  file,
+	}
+	ss.load()
+	return ss
+}
+
+func (ss *SettingsStore) load() {
+	data, err := os.ReadFile(ss.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, ss)
+	if ss.Chats == nil {
+		ss.Chats = make(map[int64]*ChatSettings)
+	}
+}
+
+func (ss *SettingsStore) save() {
+	data, err := json.MarshalIndent(ss, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error("settings marshal")
+		return
+	}
+	if err := os.WriteFile(ss.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("settings write")
+	}
+}
+
+// Get returns a copy of chat settings
+func (ss *SettingsStore) Get(chatID int64) ChatSettings {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	if cs, ok := ss.Chats[chatID]; ok {
+		return *cs
+	}
+	return defaultChatSettings()
+}
+
+// ChatIDs returns the chats that have settings
+func (ss *SettingsStore) ChatIDs() []int64 {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	ids := make([]int64, 0, len(ss.Chats))
+	for id := range ss.Chats {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Update modifies chat settings and persists them
+func (ss *SettingsStore) Update(chatID int64, fn func(cs *ChatSettings)) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	cs, ok := ss.Chats[chatID]
+	if !ok {
+		def := defaultChatSettings()
+		cs = &def
+		ss.Chats[chatID] = cs
+	}
+	fn(cs)
+	ss.save()
+}
+
+// settingSetters maps /set keys to parsers
+var settingSetters = map[string]func(cs *ChatSettings, value string) error{
+	"rules_required":      func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.RulesRequired) },
+	"rules_link":          func(cs *ChatSettings, v string) error { cs.RulesLink = clearable(v); return nil },
+	"quiz_pass_score":     func(cs *ChatSettings, v string) error { return parseCount(v, &cs.QuizPassScore) },
+	"quiz_require_all":    func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.QuizRequireAll) },
+	"quiz_partial_review": func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.QuizPartialReview) },
+	"promo_filter":        func(cs *ChatSettings, v string) error { return parseInverseSwitch(v, &cs.PromoFilterDisabled) },
+	"promo_level":         func(cs *ChatSettings, v string) error { return parseLevel(v, &cs.PromoLevel) },
+	"promo_allow": func(cs *ChatSettings, v string) error {
+		cs.PromoAllowlist = parseList(clearable(v), promoKey)
+		return nil
+	},
+	"newbie_hours":            func(cs *ChatSettings, v string) error { return parseCount(v, &cs.NewbieHours) },
+	"filter_phones":           func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.FilterPhones) },
+	"filter_emails":           func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.FilterEmails) },
+	"filter_bot_mentions":     func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.FilterBotMentions) },
+	"filter_channel_forwards": func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.FilterChannelForwards) },
+	"filter_sender_chat":      func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.FilterSenderChat) },
+	"cas":                     func(cs *ChatSettings, v string) error { return parseInverseSwitch(v, &cs.CASDisabled) },
+	"bayes":                   func(cs *ChatSettings, v string) error { return parseInverseSwitch(v, &cs.BayesDisabled) },
+	"bayes_delete_score":      func(cs *ChatSettings, v string) error { return parseCount(v, &cs.BayesDeleteScore) },
+	"bayes_review_score":      func(cs *ChatSettings, v string) error { return parseCount(v, &cs.BayesReviewScore) },
+	"bayes_level":             func(cs *ChatSettings, v string) error { return parseLevel(v, &cs.BayesLevel) },
+	"moderation":              func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.ModerationEnabled) },
+	"moderation_log_score":    func(cs *ChatSettings, v string) error { return parseCount(v, &cs.ModerationLogScore) },
+	"moderation_warn_score":   func(cs *ChatSettings, v string) error { return parseCount(v, &cs.ModerationWarnScore) },
+	"moderation_delete_score": func(cs *ChatSettings, v string) error { return parseCount(v, &cs.ModerationDeleteScore) },
+	"similarity":              func(cs *ChatSettings, v string) error { return parseInverseSwitch(v, &cs.SimilarityDisabled) },
+	"similarity_percent":      func(cs *ChatSettings, v string) error { return parseCount(v, &cs.SimilarityPercent) },
+	"similarity_level":        func(cs *ChatSettings, v string) error { return parseLevel(v, &cs.SimilarityLevel) },
+	"profile_check":           func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.ProfileCheck) },
+	"profile_action":          func(cs *ChatSettings, v string) error { return parseChoice(v, profileActions, &cs.ProfileAction) },
+	"caps_filter":             func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.CapsFilter) },
+	"caps_min_letters":        func(cs *ChatSettings, v string) error { return parseCount(v, &cs.CapsMinLetters) },
+	"caps_percent":            func(cs *ChatSettings, v string) error { return parseCount(v, &cs.CapsPercent) },
+	"caps_level":              func(cs *ChatSettings, v string) error { return parseLevel(v, &cs.CapsLevel) },
+	"newbie_max_length":       func(cs *ChatSettings, v string) error { return parseCount(v, &cs.NewbieMaxLength) },
+	"newbie_max_emoji":        func(cs *ChatSettings, v string) error { return parseCount(v, &cs.NewbieMaxEmoji) },
+	"warn_limit":              func(cs *ChatSettings, v string) error { return parseCount(v, &cs.WarnLimit) },
+	"warn_expiry_days":        func(cs *ChatSettings, v string) error { return parseCount(v, &cs.WarnExpiryDays) },
+	"digest":                  func(cs *ChatSettings, v string) error { return parseChoices(v, digestEvents, &cs.DigestEvents) },
+	"digest_hours":            func(cs *ChatSettings, v string) error { return parseCount(v, &cs.DigestHours) },
+	"spam_wipe_hours":         func(cs *ChatSettings, v string) error { return parseCount(v, &cs.SpamWipeHours) },
+	"confirm_actions":         func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.ConfirmActions) },
+	"probation_messages":      func(cs *ChatSettings, v string) error { return parseCount(v, &cs.ProbationMessages) },
+	"probation_premod":        func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.ProbationPremod) },
+	"silent":                  func(cs *ChatSettings, v string) error { return parseChoices(v, silentActions, &cs.SilentActions) },
+	"newbie_media":            func(cs *ChatSettings, v string) error { return parseChoices(v, newbieMediaKinds, &cs.NewbieMedia) },
+	"flood":                   func(cs *ChatSettings, v string) error { return parseInverseSwitch(v, &cs.FloodDisabled) },
+	"flood_limit":             func(cs *ChatSettings, v string) error { return parseCount(v, &cs.FloodLimit) },
+	"flood_window":            func(cs *ChatSettings, v string) error { return parseCount(v, &cs.FloodWindow) },
+	"flood_mute_minutes":      func(cs *ChatSettings, v string) error { return parseCount(v, &cs.FloodMuteMinutes) },
+	"night_hours": func(cs *ChatSettings, v string) error {
+		v = clearable(v)
+		if _, _, ok := parseNightHours(v); v != "" && !ok {
+			return fmt.Errorf("expected HH:MM-HH:MM, got %q", v)
+		}
+		cs.NightHours = v
+		return nil
+	},
+	"night_delete":         func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.NightDelete) },
+	"media_flood":          func(cs *ChatSettings, v string) error { return parseInverseSwitch(v, &cs.MediaFloodDisabled) },
+	"media_flood_limit":    func(cs *ChatSettings, v string) error { return parseCount(v, &cs.MediaFloodLimit) },
+	"media_flood_window":   func(cs *ChatSettings, v string) error { return parseCount(v, &cs.MediaFloodWindow) },
+	"duplicates":           func(cs *ChatSettings, v string) error { return parseInverseSwitch(v, &cs.DuplicatesDisabled) },
+	"duplicate_window":     func(cs *ChatSettings, v string) error { return parseCount(v, &cs.DuplicateWindow) },
+	"duplicate_mute_after": func(cs *ChatSettings, v string) error { return parseCount(v, &cs.DuplicateMuteAfter) },
+	"remote_levels":        func(cs *ChatSettings, v string) error { return parseSwitch(v, &cs.RemoteLevels) },
+}
+
+// parseSwitch parses on/off style values
+func parseSwitch(v string, dst *bool) error {
+	switch strings.ToLower(v) {
+	case "on", "true", "yes", "1":
+		*dst = true
+	case "off", "false", "no", "0":
+		*dst = false
+	default:
+		return fmt.Errorf("expected on/off, got %q", v)
+	}
+	return nil
+}
+
+// parseInverseSwitch parses on/off into a flag that disables a feature
+func parseInverseSwitch(v string, disabled *bool) error {
+	on := !*disabled
+	if err := parseSwitch(v, &on); err != nil {
+		return err
+	}
+	*disabled = !on
+	return nil
+}
+
+// parseCount parses a non-negative number
+func parseCount(v string, dst *int) error {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return fmt.Errorf("expected a non-negative number, got %q", v)
+	}
+	*dst = n
+	return nil
+}
+
+// parseLevel parses a filter severity level, "-" resets it to the default
+func parseLevel(v string, dst *string) error {
+	return parseChoice(v, core.BlacklistLevels, dst)
+}
+
+// parseChoice accepts one of the allowed values, "-" resets to the default
+func parseChoice(v string, allowed []string, dst *string) error {
+	v = strings.ToLower(clearable(v))
+	if v != "" && !slices.Contains(allowed, v) {
+		return fmt.Errorf("expected one of %s, got %q", strings.Join(allowed, ", "), v)
+	}
+	*dst = v
+	return nil
+}
+
+// parseList splits a comma-separated value and normalizes each item
+func parseList(v string, normalize func(string) string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = normalize(item); item != "" && !slices.Contains(items, item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseChoices parses a comma-separated list of allowed values, "-" clears it
+func parseChoices(v string, allowed []string, dst *[]string) error {
+	items := parseList(clearable(v), func(s string) string { return strings.ToLower(strings.TrimSpace(s)) })
+	for _, item := range items {
+		if !slices.Contains(allowed, item) {
+			return fmt.Errorf("expected any of %s, got %q", strings.Join(allowed, ", "), item)
+		}
+	}
+	*dst = items
+	return nil
+}
+
+// clearable treats "-" as an empty value
+func clearable(v string) string {
+	if v == "-" {
+		return ""
+	}
+	return v
+}
+
+// HandleSettings shows chat settings
+func (ah *AdminHandler) HandleSettings(c tb.Context) error {
+	lang := ah.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if c.Message() == nil || c.Sender() == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Settings.AdminOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	data, err := json.MarshalIndent(ah.settings.Get(c.Chat().ID), "", "  ")
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(settingSetters))
+	for k := range settingSetters {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	text := fmt.Sprintf(msgs.Settings.Current, string(data), strings.Join(keys, ", "))
+	_, err = ah.bot.Send(c.Chat(), text, tb.ModeMarkdown)
+	return err
+}
+
+// HandleSet changes a single chat setting
+func (ah *AdminHandler) HandleSet(c tb.Context) error {
+	lang := ah.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if c.Message() == nil || c.Sender() == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Settings.AdminOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	args := strings.Fields(c.Message().Text)
+	if len(args) < 3 {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Settings.Usage)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	key, value := strings.ToLower(args[1]), strings.Join(args[2:], " ")
+	setter, ok := settingSetters[key]
+	if !ok {
+		msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Settings.UnknownKey, key))
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	var setErr error
+	ah.settings.Update(c.Chat().ID, func(cs *ChatSettings) { setErr = setter(cs, value) })
+	if setErr != nil {
+		msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Settings.InvalidValue, key, setErr))
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Settings.Updated, key, value))
+	ah.DeleteAfter(msg, 10*time.Second)
+	ah.LogToAdmin(fmt.Sprintf("⚙️ Изменена настройка чата.\n\nАдмин: %s\nЧат: %s\nНастройка: %s = %s", ah.GetUserDisplayName(c.Sender()), c.Chat().Title, key, value))
+	return nil
+}
@@ -0,0 +1,163 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"capybot/internal/core"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Spam corpus limits
+const (
+	maxSpamSamples   = 500
+	minSampleShingle = 10 // samples with fewer shingles are too short to compare reliably
+)
+
+// similarRulePrefix marks filter actions taken because of a known spam sample
+const similarRulePrefix = "similar:"
+
+// SpamCorpus keeps recently deleted spam to catch lightly reworded copies
+type SpamCorpus struct {
+	mu       sync.RWMutex
+	Samples  []string `json:"samples"`
+	file     string
+	shingles [][]uint64
+}
+
+// NewSpamCorpus creates a corpus backed by a JSON file
+func NewSpamCorpus(file string) *SpamCorpus {
+	_ = os.MkdirAll("data", 0755)
+	sc := &SpamCorpus{file: file}
+	sc.load()
+	return sc
+}
+
+func (sc *SpamCorpus) load() {
+	data, err := os.ReadFile(sc.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, sc)
+	sc.shingles = make([][]uint64, len(sc.Samples))
+	for i, s := range sc.Samples {
+		sc.shingles[i] = shingles(s)
+	}
+}
+
+func (sc *SpamCorpus) save() {
+	data, err := json.Marshal(sc)
+	if err != nil {
+		logrus.WithError(err).Error("spam corpus marshal")
+		return
+	}
+	if err := os.WriteFile(sc.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("spam corpus write")
+	}
+}
+
+// shingles returns the sorted distinct hashes of the character trigrams of normalized text
+func shingles(text string) []uint64 {
+	runes := []rune(strings.Join(strings.Fields(normalizeText(text)), " "))
+	var result []uint64
+	for i := 0; i+3 <= len(runes); i++ {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(string(runes[i : i+3])))
+		result = append(result, h.Sum64())
+	}
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+// jaccard returns the similarity of two sorted shingle sets from 0 to 1
+func jaccard(a, b []uint64) float64 {
+	common := 0
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			common++
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	union := len(a) + len(b) - common
+	if union == 0 {
+		return 0
+	}
+	return float64(common) / float64(union)
+}
+
+// Add remembers a deleted spam message, dropping the oldest samples beyond the limit
+func (sc *SpamCorpus) Add(text string) {
+	sh := shingles(text)
+	if len(sh) < minSampleShingle {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	for _, known := range sc.shingles {
+		if jaccard(sh, known) == 1 {
+			return
+		}
+	}
+	sc.Samples = append(sc.Samples, text)
+	sc.shingles = append(sc.shingles, sh)
+	if extra := len(sc.Samples) - maxSpamSamples; extra > 0 {
+		sc.Samples = slices.Delete(sc.Samples, 0, extra)
+		sc.shingles = slices.Delete(sc.shingles, 0, extra)
+	}
+	sc.save()
+}
+
+// Similarity returns the highest similarity of text to any known sample
+func (sc *SpamCorpus) Similarity(text string) float64 {
+	sh := shingles(text)
+	if len(sh) < minSampleShingle {
+		return 0
+	}
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	best := 0.0
+	for _, known := range sc.shingles {
+		best = max(best, jaccard(sh, known))
+	}
+	return best
+}
+
+// similarityPercent returns the similarity to known spam that triggers the filter
+func (cs ChatSettings) similarityPercent() int {
+	if cs.SimilarityPercent <= 0 {
+		return 80
+	}
+	return cs.SimilarityPercent
+}
+
+// similarityLevel returns the action for messages resembling known spam
+func (cs ChatSettings) similarityLevel() string {
+	if cs.SimilarityLevel == "" {
+		return core.LevelDelete
+	}
+	return cs.SimilarityLevel
+}
+
+// matchSimilarSpam finds messages close to a recently deleted spam message
+func (fh *FeatureHandler) matchSimilarSpam(in filterInput) (filterHit, bool) {
+	if in.cs.SimilarityDisabled {
+		return filterHit{}, false
+	}
+	percent := int(fh.spamCorpus.Similarity(in.text) * 100)
+	if percent < in.cs.similarityPercent() {
+		return filterHit{}, false
+	}
+	return filterHit{rule: fmt.Sprintf("%s%d%%", similarRulePrefix, percent), level: in.cs.similarityLevel()}, true
+}
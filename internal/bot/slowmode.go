@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// maxSlowModeDelay is the longest allowed delay between messages
+const maxSlowModeDelay = time.Hour
+
+// slowModeTracker remembers when users last posted
+type slowModeTracker struct {
+	mu   sync.Mutex
+	last map[floodKey]time.Time
+}
+
+// allow reports whether the delay has passed since the last accepted message and records the new one
+func (t *slowModeTracker) allow(key floodKey, delay time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if last, ok := t.last[key]; ok && now.Sub(last) < delay {
+		return false
+	}
+	t.last[key] = now
+	return true
+}
+
+// slowModeDelay returns the active delay between messages, 0 when slow mode is off or expired
+func (cs ChatSettings) slowModeDelay() time.Duration {
+	if cs.SlowModeSeconds <= 0 || (cs.SlowModeUntil > 0 && time.Now().Unix() >= cs.SlowModeUntil) {
+		return 0
+	}
+	return time.Duration(cs.SlowModeSeconds) * time.Second
+}
+
+// parseSlowModeDuration parses 30s, 5m or a plain number of seconds
+func parseSlowModeDuration(s string) (time.Duration, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// checkSlowMode deletes messages sent before the slow mode delay passed and reports whether the message was handled
+func (fh *FeatureHandler) checkSlowMode(c tb.Context) bool {
+	delay := fh.settings.Get(c.Chat().ID).slowModeDelay()
+	m := c.Message()
+	if delay == 0 || fh.slowMode.allow(floodKey{chatID: c.Chat().ID, userID: m.Sender.ID, kind: "slowmode"}, delay) {
+		return false
+	}
+	if err := fh.bot.Delete(m); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": c.Chat().ID, "user_id": m.Sender.ID}).Warn("Failed to delete slow mode message")
+	}
+	return true
+}
+
+// HandleSlowMode turns the bot-enforced slow mode on or off, optionally for a limited time
+func (ah *AdminHandler) HandleSlowMode(c tb.Context) error {
+	lang := ah.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if c.Message() == nil || c.Sender() == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.SlowMode.AdminOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	args := strings.Fields(c.Message().Payload)
+	if len(args) == 0 {
+		status := msgs.SlowMode.StatusOff
+		if delay := ah.settings.Get(c.Chat().ID).slowModeDelay(); delay > 0 {
+			status = fmt.Sprintf(msgs.SlowMode.StatusOn, delay)
+		}
+		msg, _ := ah.bot.Send(c.Chat(), msgs.SlowMode.Usage+"\n\n"+status)
+		ah.DeleteAfter(msg, 30*time.Second)
+		return nil
+	}
+
+	if strings.EqualFold(args[0], "off") {
+		ah.settings.Update(c.Chat().ID, func(cs *ChatSettings) { cs.SlowModeSeconds, cs.SlowModeUntil = 0, 0 })
+		msg, _ := ah.bot.Send(c.Chat(), msgs.SlowMode.Disabled)
+		ah.DeleteAfter(msg, 10*time.Second)
+		ah.LogToAdmin(fmt.Sprintf("🐢 Медленный режим выключен.\n\nАдмин: %s\nЧат: %s", ah.GetUserDisplayName(c.Sender()), c.Chat().Title))
+		return nil
+	}
+
+	delay, err := parseSlowModeDuration(args[0])
+	var lasts time.Duration
+	if err == nil && len(args) > 1 {
+		lasts, err = time.ParseDuration(args[1])
+	}
+	if err != nil || delay < time.Second || delay > maxSlowModeDelay || lasts < 0 || len(args) > 2 {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.SlowMode.Invalid)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+
+	var until int64
+	if lasts > 0 {
+		until = time.Now().Add(lasts).Unix()
+	}
+	ah.settings.Update(c.Chat().ID, func(cs *ChatSettings) {
+		cs.SlowModeSeconds, cs.SlowModeUntil = int(delay/time.Second), until
+	})
+	text := fmt.Sprintf(msgs.SlowMode.Enabled, delay)
+	logMsg := fmt.Sprintf("🐢 Медленный режим включён.\n\nАдмин: %s\nЧат: %s\nЗадержка: %s", ah.GetUserDisplayName(c.Sender()), c.Chat().Title, delay)
+	if until > 0 {
+		end := time.Unix(until, 0).Format("02.01.2006 15:04")
+		text = fmt.Sprintf(msgs.SlowMode.EnabledUntil, delay, end)
+		logMsg += "\nДо: " + end
+		ah.scheduleSlowModeOff(c.Chat(), until)
+	}
+	msg, _ := ah.bot.Send(c.Chat(), text)
+	ah.DeleteAfter(msg, 10*time.Second)
+	ah.LogToAdmin(logMsg)
+	return nil
+}
+
+// scheduleSlowModeOff clears slow mode when its time runs out unless it was changed meanwhile
+func (ah *AdminHandler) scheduleSlowModeOff(chat *tb.Chat, until int64) {
+	ah.tasks.Add(Task{Kind: taskSlowModeOff, At: time.Unix(until, 0), ChatID: chat.ID, Value: until})
+}
+
+// slowModeOff clears slow mode set up until the time of the task
+func (ah *AdminHandler) slowModeOff(t Task) {
+	expired := false
+	ah.settings.Update(t.ChatID, func(cs *ChatSettings) {
+		if cs.SlowModeUntil == t.Value {
+			cs.SlowModeSeconds, cs.SlowModeUntil = 0, 0
+			expired = true
+		}
+	})
+	if !expired {
+		return
+	}
+	title := strconv.FormatInt(t.ChatID, 10)
+	if chat, err := ah.bot.ChatByID(t.ChatID); err == nil {
+		title = chat.Title
+	}
+	ah.LogToAdmin(fmt.Sprintf("🐢 Медленный режим выключен по расписанию.\n\nЧат: %s", title))
+}
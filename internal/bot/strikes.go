@@ -0,0 +1,325 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// Strike is a single warning given to a user in a chat
+type Strike struct {
+	At     time.Time `json:"at"`
+	ChatID int64     `json:"chat_id,omitempty"` // 0 for strikes from before they were kept per chat, counted in every chat
+	ByID   int64     `json:"by_id,omitempty"`   // 0 for warnings given by the filters
+	By     string    `json:"by,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// StrikeStore persists the warning history of users
+type StrikeStore struct {
+	mu      sync.RWMutex
+	Strikes map[int64][]Strike `json:"strikes"`
+	file    string
+}
+
+// NewStrikeStore creates a strike store backed by a JSON file, importing counts from the old violations file
+func NewStrikeStore(file, legacyFile string) *StrikeStore {
+	_ = os.MkdirAll("data", 0755)
+	ss := &StrikeStore{
+		Strikes: make(map[int64][]Strike),
+		file:    file,
+	}
+	ss.load()
+	ss.migrate(legacyFile)
+	return ss
+}
+
+func (ss *StrikeStore) load() {
+	data, err := os.ReadFile(ss.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, ss)
+	if ss.Strikes == nil {
+		ss.Strikes = make(map[int64][]Strike)
+	}
+}
+
+// migrate turns the counters of the old violations file into strikes without details
+func (ss *StrikeStore) migrate(legacyFile string) {
+	data, err := os.ReadFile(legacyFile)
+	if err != nil {
+		return
+	}
+	var counts map[int64]int
+	if err := json.Unmarshal(data, &counts); err != nil {
+		logrus.WithError(err).Warn("Failed to read old violations file")
+		return
+	}
+	now := time.Now()
+	for userID, n := range counts {
+		for range n - len(ss.Strikes[userID]) {
+			ss.Strikes[userID] = append(ss.Strikes[userID], Strike{At: now})
+		}
+	}
+	ss.save()
+	if err := os.Rename(legacyFile, legacyFile+".migrated"); err != nil {
+		logrus.WithError(err).Warn("Failed to retire old violations file")
+	}
+}
+
+func (ss *StrikeStore) save() {
+	data, err := json.MarshalIndent(ss, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error("strike store marshal")
+		return
+	}
+	if err := os.WriteFile(ss.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("strike store write")
+	}
+}
+
+// counts reports whether the strike counts in the chat
+func (s Strike) counts(chatID int64) bool {
+	return s.ChatID == chatID || s.ChatID == 0
+}
+
+// inChat returns the strikes that count in the chat
+func inChat(strikes []Strike, chatID int64) []Strike {
+	var result []Strike
+	for _, s := range strikes {
+		if s.counts(chatID) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// Add records a strike and returns how many the user has received in its chat after since
+func (ss *StrikeStore) Add(userID int64, s Strike, since time.Time) int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.Strikes[userID] = append(ss.Strikes[userID], s)
+	ss.save()
+	return countSince(inChat(ss.Strikes[userID], s.ChatID), since)
+}
+
+// RemoveLast takes back the latest strike in the chat and returns how many given there after since are left
+func (ss *StrikeStore) RemoveLast(chatID, userID int64, since time.Time) (int, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	strikes := ss.Strikes[userID]
+	if countSince(inChat(strikes, chatID), since) == 0 {
+		return 0, false
+	}
+	for i := len(strikes) - 1; i >= 0; i-- {
+		if strikes[i].counts(chatID) {
+			strikes = slices.Delete(strikes, i, i+1)
+			break
+		}
+	}
+	if len(strikes) == 0 {
+		delete(ss.Strikes, userID)
+	} else {
+		ss.Strikes[userID] = strikes
+	}
+	ss.save()
+	return countSince(inChat(strikes, chatID), since), true
+}
+
+// Count returns the number of strikes a user has received in the chat after since
+func (ss *StrikeStore) Count(chatID, userID int64, since time.Time) int {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return countSince(inChat(ss.Strikes[userID], chatID), since)
+}
+
+// countSince counts the strikes given after since
+func countSince(strikes []Strike, since time.Time) int {
+	n := 0
+	for _, s := range strikes {
+		if s.At.After(since) {
+			n++
+		}
+	}
+	return n
+}
+
+// History returns the strikes of a user that count in the chat, the oldest first
+func (ss *StrikeStore) History(chatID, userID int64) []Strike {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return inChat(ss.Strikes[userID], chatID)
+}
+
+// Clear forgets the strikes of a user in the chat
+func (ss *StrikeStore) Clear(chatID, userID int64) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	strikes, ok := ss.Strikes[userID]
+	if !ok {
+		return
+	}
+	strikes = slices.DeleteFunc(strikes, func(s Strike) bool { return s.counts(chatID) })
+	if len(strikes) == 0 {
+		delete(ss.Strikes, userID)
+	} else {
+		ss.Strikes[userID] = strikes
+	}
+	ss.save()
+}
+
+// ClearAll forgets the strikes of a user in every chat
+func (ss *StrikeStore) ClearAll(userID int64) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if _, ok := ss.Strikes[userID]; !ok {
+		return
+	}
+	delete(ss.Strikes, userID)
+	ss.save()
+}
+
+// warnLimit returns the number of strikes that leads to a ban
+func (cs ChatSettings) warnLimit() int {
+	if cs.WarnLimit <= 0 {
+		return 2
+	}
+	return cs.WarnLimit
+}
+
+// warnExpiry returns how long a strike counts towards the limit
+func (cs ChatSettings) warnExpiry() time.Duration {
+	if cs.WarnExpiryDays <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(cs.WarnExpiryDays) * 24 * time.Hour
+}
+
+// strikesSince returns the moment before which strikes have expired in the chat
+func (ah *AdminHandler) strikesSince(chatID int64) time.Time {
+	return time.Now().Add(-ah.settings.Get(chatID).warnExpiry())
+}
+
+// AddViolation records a strike given by an admin, or by the filters when by is nil, and returns the count that has not expired in the chat
+func (ah *AdminHandler) AddViolation(chatID, userID int64, by *tb.User, reason string) int {
+	s := Strike{At: time.Now(), ChatID: chatID, Reason: reason}
+	if by != nil {
+		s.ByID, s.By = by.ID, ah.GetUserDisplayName(by)
+	}
+	return ah.strikes.Add(userID, s, ah.strikesSince(chatID))
+}
+
+// GetViolations returns the number of strikes of a user that have not expired in the chat
+func (ah *AdminHandler) GetViolations(chatID, userID int64) int {
+	return ah.strikes.Count(chatID, userID, ah.strikesSince(chatID))
+}
+
+// ClearViolations forgets the strikes of a user in the chat
+func (ah *AdminHandler) ClearViolations(chatID, userID int64) {
+	ah.strikes.Clear(chatID, userID)
+}
+
+// commandTarget returns the user an admin command is aimed at and the rest of its payload; the target is the author
+// of the replied message or an @username or ID opening the payload, and admins are refused
+func (ah *AdminHandler) commandTarget(c tb.Context, msgs *i18n.Messages) (*tb.User, string) {
+	payload := strings.TrimSpace(c.Message().Payload)
+	var target *tb.User
+	if reply := c.Message().ReplyTo; reply != nil && reply.Sender != nil {
+		target = reply.Sender
+	} else {
+		ref, rest, _ := strings.Cut(payload, " ")
+		user, ok := ah.users.Find(ref)
+		if !ok {
+			text := msgs.Moderation.TargetRequired
+			if strings.HasPrefix(ref, "@") {
+				text = fmt.Sprintf(msgs.Moderation.UserNotFound, ref)
+			}
+			msg, _ := ah.bot.Send(c.Chat(), text)
+			ah.DeleteAfter(msg, 10*time.Second)
+			return nil, ""
+		}
+		if m, err := ah.bot.ChatMemberOf(c.Chat(), user); err == nil && m.User != nil {
+			user = m.User
+		}
+		target, payload = user, strings.TrimSpace(rest)
+	}
+	if ah.IsAdmin(c.Chat(), target) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Admin.SpambanCannotBanAdmin)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil, ""
+	}
+	return target, payload
+}
+
+// HandleWarn gives a strike to the replied or named user and bans them once the chat limit is reached
+func (ah *AdminHandler) HandleWarn(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if !ah.requireModerator(c, msgs) {
+		return nil
+	}
+	target, reason := ah.commandTarget(c, msgs)
+	if target == nil {
+		return nil
+	}
+	ah.warn(c.Chat(), c.Sender(), target, reason)
+	return nil
+}
+
+// warn gives a strike from the admin by, announces it in the target's language and bans at the chat limit
+func (ah *AdminHandler) warn(chat *tb.Chat, by, target *tb.User, reason string) {
+	count := ah.AddViolation(chat.ID, target.ID, by, reason)
+	limit := ah.settings.Get(chat.ID).warnLimit()
+	userMsgs := i18n.Get().T(ah.getLangForUser(target))
+	name := ah.GetUserDisplayName(target)
+
+	if count >= limit {
+		if err := ah.BanUser(chat, target); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{"chat_id": chat.ID, "user_id": target.ID}).Error("Failed to ban warned user")
+			return
+		}
+		ah.ClearViolations(chat.ID, target.ID)
+		ah.actions.Record(ModAction{Kind: actionBan, ChatID: chat.ID, UserID: target.ID, UserName: name, ByID: by.ID, By: ah.GetUserDisplayName(by), Reason: reason})
+		_, _ = ah.bot.Send(chat, fmt.Sprintf(userMsgs.Moderation.WarnBanned.Form(userMsgs.Lang(), count), name, count))
+		ah.LogToAdmin(fmt.Sprintf("🔨 Бан после предупреждений\n\nАдмин: %s\nЗабанен: %s\nПредупреждений: %d\nПричина: %s", ah.GetUserDisplayName(by), name, count, reason))
+		return
+	}
+	text := fmt.Sprintf(userMsgs.Moderation.Warned, name, count, limit)
+	if reason != "" {
+		text += "\n" + fmt.Sprintf(userMsgs.Moderation.Reason, reason)
+	}
+	_, _ = ah.bot.Send(chat, text)
+	ah.LogToAdmin(fmt.Sprintf("⚠️ Предупреждение\n\nАдмин: %s\nПользователь: %s\nПредупреждений: %d/%d\nПричина: %s", ah.GetUserDisplayName(by), name, count, limit, reason))
+}
+
+// HandleUnwarn takes back the latest strike of the replied or named user
+func (ah *AdminHandler) HandleUnwarn(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if !ah.requireModerator(c, msgs) {
+		return nil
+	}
+	target, _ := ah.commandTarget(c, msgs)
+	if target == nil {
+		return nil
+	}
+	left, ok := ah.strikes.RemoveLast(c.Chat().ID, target.ID, ah.strikesSince(c.Chat().ID))
+	if !ok {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Moderation.NoWarnings)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	userMsgs := i18n.Get().T(ah.getLangForUser(target))
+	name := ah.GetUserDisplayName(target)
+	_, _ = ah.bot.Send(c.Chat(), fmt.Sprintf(userMsgs.Moderation.Unwarned, name, left))
+	ah.LogToAdmin(fmt.Sprintf("↩️ Предупреждение снято\n\nАдмин: %s\nПользователь: %s\nОсталось: %d", ah.GetUserDisplayName(c.Sender()), name, left))
+	return nil
+}
@@ -0,0 +1,177 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// Subscriptions keeps the users who want a message when a new review of a professor is approved
+type Subscriptions struct {
+	mu          sync.Mutex
+	Subscribers map[string]map[int64]i18n.Lang `json:"subscribers"` // Language of each subscriber by professorKey
+	file        string
+}
+
+// NewSubscriptions loads the subscriptions kept in the file
+func NewSubscriptions(file string) *Subscriptions {
+	_ = os.MkdirAll("data", 0755)
+	s := &Subscriptions{Subscribers: make(map[string]map[int64]i18n.Lang), file: file}
+	s.load()
+	return s
+}
+
+func (s *Subscriptions) load() {
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, s)
+	if s.Subscribers == nil {
+		s.Subscribers = make(map[string]map[int64]i18n.Lang)
+	}
+}
+
+func (s *Subscriptions) save() {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error("subscriptions marshal")
+		return
+	}
+	if err := os.WriteFile(s.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("subscriptions write")
+	}
+}
+
+// Toggle subscribes a user to a professor or cancels the subscription, returning whether the user is subscribed now
+func (s *Subscriptions) Toggle(professor string, userID int64, lang i18n.Lang) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := professorKey(professor)
+	if s.unsubscribe(key, userID) {
+		return false
+	}
+	if s.Subscribers[key] == nil {
+		s.Subscribers[key] = make(map[int64]i18n.Lang)
+	}
+	s.Subscribers[key][userID] = lang
+	s.save()
+	return true
+}
+
+// Unsubscribe cancels the subscription of a user to a professor, false if there was none
+func (s *Subscriptions) Unsubscribe(professor string, userID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.unsubscribe(professorKey(professor), userID)
+}
+
+func (s *Subscriptions) unsubscribe(key string, userID int64) bool {
+	if _, ok := s.Subscribers[key][userID]; !ok {
+		return false
+	}
+	delete(s.Subscribers[key], userID)
+	if len(s.Subscribers[key]) == 0 {
+		delete(s.Subscribers, key)
+	}
+	s.save()
+	return true
+}
+
+// Subscribed reports whether a user is subscribed to a professor
+func (s *Subscriptions) Subscribed(professor string, userID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.Subscribers[professorKey(professor)][userID]
+	return ok
+}
+
+// subscribers returns a copy of the subscribers of a professor
+func (s *Subscriptions) subscribers(professor string) map[int64]i18n.Lang {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[int64]i18n.Lang, len(s.Subscribers[professorKey(professor)]))
+	for id, lang := range s.Subscribers[professorKey(professor)] {
+		result[id] = lang
+	}
+	return result
+}
+
+// move hands the subscribers of a renamed or merged professor over to the new name
+func (s *Subscriptions) move(from, to string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fromKey, toKey := professorKey(from), professorKey(to)
+	if fromKey == toKey || len(s.Subscribers[fromKey]) == 0 {
+		return
+	}
+	if s.Subscribers[toKey] == nil {
+		s.Subscribers[toKey] = make(map[int64]i18n.Lang)
+	}
+	for id, lang := range s.Subscribers[fromKey] {
+		s.Subscribers[toKey][id] = lang
+	}
+	delete(s.Subscribers, fromKey)
+	s.save()
+}
+
+// markdownEscaper escapes the characters that open an entity in Telegram's Markdown, for user-written text
+var markdownEscaper = strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[")
+
+// notifySubscribers sends a newly approved review to the subscribers of its professor; edits of approved reviews are not announced
+func (rh *RatingHandler) notifySubscribers(r Review, oldStatus string) {
+	if oldStatus != "pending" || r.Status != "approved" || r.Replaces != 0 || r.Archived {
+		return
+	}
+	for userID, lang := range rh.subs.subscribers(r.Professor) {
+		if userID == r.UserID || rh.store.IsBlocked(userID) {
+			continue
+		}
+		msgs := i18n.Get().T(lang)
+		text := fmt.Sprintf("%s\n\n🔸 %s: [%d/5]%s\n💬 %s",
+			fmt.Sprintf(msgs.Rating.NewReviewNotice, r.Professor),
+			msgs.Rating.Score, r.Score, markdownEscaper.Replace(criteriaLine(msgs, r.Criteria)+courseLine(msgs, r.Course, r.Tag)), markdownEscaper.Replace(r.Text),
+		)
+		kb := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{
+			{{Data: fmt.Sprintf("ratings_prof_%d_0", r.ID), Text: msgs.Rating.BtnOpenProfessor}},
+			{{Data: fmt.Sprintf("ratings_unsub_%d", r.ID), Text: msgs.Rating.BtnUnsubscribe}},
+		}}
+		if _, err := rh.bot.Send(&tb.Chat{ID: userID}, text, kb, tb.ModeMarkdown); err != nil {
+			logrus.WithError(err).WithField("userID", userID).Warn("Failed to notify subscriber about new review")
+		}
+	}
+}
+
+// toggleSubscription subscribes the user to the professor of a card or cancels it and redraws the card
+func (rh *RatingHandler) toggleSubscription(c tb.Context, reviewID, page int, msgs *i18n.Messages) error {
+	review := rh.store.GetReview(reviewID)
+	if review == nil || rh.subs == nil {
+		return rh.bot.Respond(c.Callback())
+	}
+	text := msgs.Rating.Unsubscribed
+	if rh.subs.Toggle(review.Professor, c.Sender().ID, rh.getLangForUser(c.Sender())) {
+		text = fmt.Sprintf(msgs.Rating.Subscribed, review.Professor)
+	}
+	rh.renderProfessor(c, reviewID, page)
+	return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: text})
+}
+
+// unsubscribe cancels a subscription from the message about a new review, leaving the button to open the professor
+func (rh *RatingHandler) unsubscribe(c tb.Context, reviewID int, msgs *i18n.Messages) error {
+	review := rh.store.GetReview(reviewID)
+	if review == nil || rh.subs == nil {
+		return rh.bot.Respond(c.Callback())
+	}
+	rh.subs.Unsubscribe(review.Professor, c.Sender().ID)
+	if kb := c.Message().ReplyMarkup; kb != nil && len(kb.InlineKeyboard) > 0 {
+		_, _ = rh.bot.EditReplyMarkup(c.Message(), &tb.ReplyMarkup{InlineKeyboard: kb.InlineKeyboard[:1]})
+	}
+	return rh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Rating.Unsubscribed})
+}
@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// reviewTags are the kinds of classes a review can be tagged with, searchable as #lecture and so on
+var reviewTags = []string{"lecture", "seminar", "exam"}
+
+// Limits of the course name attached to a review
+const (
+	minCourseLength = 2
+	maxCourseLength = 80
+)
+
+// tagLabel returns the localized name of a review tag
+func tagLabel(msgs *i18n.Messages, tag string) string {
+	switch tag {
+	case "lecture":
+		return msgs.Rating.TagLecture
+	case "seminar":
+		return msgs.Rating.TagSeminar
+	case "exam":
+		return msgs.Rating.TagExam
+	}
+	return tag
+}
+
+// validCourse reports whether a course name has a sensible length
+func validCourse(course string) bool {
+	n := utf8.RuneCountInString(course)
+	return n >= minCourseLength && n <= maxCourseLength
+}
+
+// tagKeyboard returns the buttons for tagging a review
+func tagKeyboard(msgs *i18n.Messages) *tb.ReplyMarkup {
+	var tags []tb.InlineButton
+	for _, tag := range reviewTags {
+		tags = append(tags, tb.InlineButton{Data: "rate_tag_" + tag, Text: tagLabel(msgs, tag)})
+	}
+	return &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{
+			tags,
+			{{Data: "rate_tag_skip", Text: msgs.Rating.BtnSkip}},
+			draftRow(msgs),
+		},
+	}
+}
+
+// courseKeyboard returns the buttons shown while asking for the course
+func courseKeyboard(msgs *i18n.Messages) *tb.ReplyMarkup {
+	return &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{
+			{{Data: "rate_course_skip", Text: msgs.Rating.BtnSkip}},
+			draftRow(msgs),
+		},
+	}
+}
+
+// courseLine returns the course and tag of a review as a line to append after the scores
+func courseLine(msgs *i18n.Messages, course, tag string) string {
+	var parts []string
+	if course != "" {
+		parts = append(parts, course)
+	}
+	if tag != "" {
+		parts = append(parts, tagLabel(msgs, tag))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "\n📚 " + strings.Join(parts, " · ")
+}
@@ -0,0 +1,221 @@
+package bot
+
+import (
+	"container/heap"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Timed task kinds
+const (
+	taskDelete        = "delete"
+	taskExpireActions = "expire_actions"
+	taskWelcome       = "welcome"
+	taskQuizTimeout   = "quiz_timeout"
+	taskSlowModeOff   = "slow_mode_off"
+)
+
+// idleWait is how long the queue sleeps when it has nothing to run
+const idleWait = time.Hour
+
+// saveDelay batches saves of the short-lived tasks added in bursts, like notices and welcomes
+const saveDelay = 2 * time.Second
+
+// durableTasks are saved as soon as they are added, since losing one leaves a restriction in place for good
+var durableTasks = map[string]bool{taskExpireActions: true, taskSlowModeOff: true}
+
+// Task is a one-off action due at a given time
+type Task struct {
+	ID        int       `json:"id"`
+	Kind      string    `json:"kind"`
+	At        time.Time `json:"at"`
+	ChatID    int64     `json:"chat_id,omitempty"`
+	UserID    int64     `json:"user_id,omitempty"`
+	MessageID int       `json:"message_id,omitempty"`
+	Value     int64     `json:"value,omitempty"` // kind specific, e.g. the end of slow mode it was set up for
+}
+
+// taskHeap orders tasks by due time
+type taskHeap []Task
+
+func (h taskHeap) Len() int           { return len(h) }
+func (h taskHeap) Less(i, j int) bool { return h[i].At.Before(h[j].At) }
+func (h taskHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *taskHeap) Push(x any)        { *h = append(*h, x.(Task)) }
+func (h *taskHeap) Pop() any {
+	old := *h
+	t := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return t
+}
+
+// TaskQueue persists timed tasks and runs each at its time, so they survive restarts
+type TaskQueue struct {
+	mu       sync.Mutex
+	NextID   int      `json:"next_id"`
+	Tasks    taskHeap `json:"tasks"`
+	file     string
+	handlers map[string]func(Task)
+	wake     chan struct{}
+	pending  *time.Timer // Scheduled save, nil when the file is up to date
+	writeMu  sync.Mutex  // Keeps writes in order
+}
+
+// NewTaskQueue creates a task queue backed by a JSON file
+func NewTaskQueue(file string) *TaskQueue {
+	_ = os.MkdirAll("data", 0755)
+	tq := &TaskQueue{NextID: 1, file: file, handlers: make(map[string]func(Task)), wake: make(chan struct{}, 1)}
+	tq.load()
+	return tq
+}
+
+func (tq *TaskQueue) load() {
+	data, err := os.ReadFile(tq.file)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, tq); err != nil {
+		// Keep the broken file for inspection instead of overwriting it with the next save
+		logrus.WithError(err).WithField("file", tq.file).Error("Failed to load timed tasks, starting with an empty queue")
+		if err := os.Rename(tq.file, tq.file+".broken"); err != nil {
+			logrus.WithError(err).WithField("file", tq.file).Warn("Failed to move broken task file aside")
+		}
+		tq.NextID, tq.Tasks = 1, nil
+	}
+	heap.Init(&tq.Tasks)
+}
+
+// save schedules writing the queue after saveDelay, callers hold tq.mu
+func (tq *TaskQueue) save() {
+	if tq.pending == nil {
+		tq.pending = time.AfterFunc(saveDelay, tq.flush)
+	}
+}
+
+// flush writes the queue now, through a temporary file so a crash mid-write keeps the previous contents
+func (tq *TaskQueue) flush() {
+	tq.writeMu.Lock()
+	defer tq.writeMu.Unlock()
+	tq.mu.Lock()
+	if tq.pending != nil {
+		tq.pending.Stop()
+		tq.pending = nil
+	}
+	data, err := json.MarshalIndent(tq, "", "  ")
+	tq.mu.Unlock()
+	if err != nil {
+		logrus.WithError(err).Error("task queue marshal")
+		return
+	}
+	tmp := tq.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		logrus.WithError(err).Error("task queue write")
+		return
+	}
+	if err := os.Rename(tmp, tq.file); err != nil {
+		logrus.WithError(err).Error("task queue write")
+	}
+}
+
+// Handle sets the function running tasks of a kind
+func (tq *TaskQueue) Handle(kind string, run func(Task)) {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	tq.handlers[kind] = run
+}
+
+// Add schedules a task and returns its ID
+func (tq *TaskQueue) Add(t Task) int {
+	tq.mu.Lock()
+	t.ID = tq.NextID
+	tq.NextID++
+	heap.Push(&tq.Tasks, t)
+	tq.save()
+	tq.mu.Unlock()
+	if durableTasks[t.Kind] {
+		tq.flush()
+	}
+	select {
+	case tq.wake <- struct{}{}:
+	default:
+	}
+	return t.ID
+}
+
+// Cancel drops the pending tasks of a kind that match and returns how many were dropped
+func (tq *TaskQueue) Cancel(kind string, match func(Task) bool) int {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	kept := tq.Tasks[:0]
+	for _, t := range tq.Tasks {
+		if t.Kind != kind || !match(t) {
+			kept = append(kept, t)
+		}
+	}
+	dropped := len(tq.Tasks) - len(kept)
+	if dropped > 0 {
+		tq.Tasks = kept
+		heap.Init(&tq.Tasks)
+		tq.save()
+	}
+	return dropped
+}
+
+// Start runs due tasks in the background, the overdue ones from before a restart right away
+func (tq *TaskQueue) Start() {
+	go func() {
+		timer := time.NewTimer(idleWait)
+		for {
+			tq.runDue(time.Now())
+			timer.Reset(tq.untilNext())
+			select {
+			case <-timer.C:
+			case <-tq.wake:
+			}
+		}
+	}()
+}
+
+// untilNext returns how long to wait for the earliest task
+func (tq *TaskQueue) untilNext() time.Duration {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	if len(tq.Tasks) == 0 {
+		return idleWait
+	}
+	return min(max(time.Until(tq.Tasks[0].At), 0), idleWait)
+}
+
+func (tq *TaskQueue) runDue(now time.Time) {
+	tq.mu.Lock()
+	var due []Task
+	for len(tq.Tasks) > 0 && !tq.Tasks[0].At.After(now) {
+		due = append(due, heap.Pop(&tq.Tasks).(Task))
+	}
+	if len(due) > 0 {
+		tq.save()
+	}
+	handlers := make([]func(Task), len(due))
+	for i, t := range due {
+		handlers[i] = tq.handlers[t.Kind]
+	}
+	tq.mu.Unlock()
+	for i, t := range due {
+		if handlers[i] == nil {
+			logrus.WithField("kind", t.Kind).Warn("No handler for timed task")
+			continue
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logrus.WithFields(logrus.Fields{"kind": t.Kind, "task_id": t.ID}).Errorf("Timed task panicked: %v", r)
+				}
+			}()
+			handlers[i](t)
+		}()
+	}
+}
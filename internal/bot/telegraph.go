@@ -0,0 +1,242 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+)
+
+const telegraphAPI = "https://api.telegra.ph/"
+
+// TelegraphPeriod is how often changed professor pages are republished, telegraphBatch pages at a time
+const (
+	TelegraphPeriod = time.Minute
+	telegraphBatch  = 10
+)
+
+// telegraphMaxContent keeps page content under the 64 KB Telegraph limit with room for the truncation note
+const telegraphMaxContent = 60 << 10
+
+// errTelegraphRejected marks pages the Telegraph API refused, which would be refused again on retry
+var errTelegraphRejected = errors.New("telegraph rejected the page")
+
+// telegraphNode is an element of Telegraph page content
+type telegraphNode struct {
+	Tag      string `json:"tag"`
+	Children []any  `json:"children,omitempty"`
+}
+
+// TelegraphPages keeps a Telegraph page per professor with all their approved reviews
+type TelegraphPages struct {
+	mu     sync.Mutex
+	store  *RatingStore
+	token  string
+	client *http.Client
+	Pages  map[string]string `json:"pages"` // Page path by professorKey
+	dirty  map[string]string // Professors to republish by professorKey
+	file   string
+}
+
+// NewTelegraphPages publishes pages with the given Telegraph access token, or returns nil when no token is set
+func NewTelegraphPages(file, token string, store *RatingStore) *TelegraphPages {
+	if token == "" {
+		return nil
+	}
+	_ = os.MkdirAll("data", 0755)
+	tp := &TelegraphPages{
+		store:  store,
+		token:  token,
+		client: &http.Client{Timeout: 15 * time.Second},
+		Pages:  make(map[string]string),
+		dirty:  make(map[string]string),
+		file:   file,
+	}
+	tp.load()
+	// Professors reviewed before pages were enabled get theirs on the first ticks
+	for _, card := range professorCards(store.GetApprovedReviews()) {
+		if _, ok := tp.Pages[professorKey(card.Professor)]; !ok {
+			tp.dirty[professorKey(card.Professor)] = card.Professor
+		}
+	}
+	store.OnStatusChange(func(r Review, _ string) { tp.mark(r.Professor) })
+	return tp
+}
+
+func (tp *TelegraphPages) load() {
+	data, err := os.ReadFile(tp.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, tp)
+	if tp.Pages == nil {
+		tp.Pages = make(map[string]string)
+	}
+}
+
+func (tp *TelegraphPages) save() {
+	data, err := json.MarshalIndent(tp, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error("telegraph pages marshal")
+		return
+	}
+	if err := os.WriteFile(tp.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("telegraph pages write")
+	}
+}
+
+// mark queues the page of a professor for republishing
+func (tp *TelegraphPages) mark(professor string) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	tp.dirty[professorKey(professor)] = professor
+}
+
+// URL returns the page of a professor, false until it is published
+func (tp *TelegraphPages) URL(professor string) (string, bool) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	path, ok := tp.Pages[professorKey(professor)]
+	if !ok {
+		return "", false
+	}
+	return "https://telegra.ph/" + path, true
+}
+
+// Tick republishes the pages of professors whose reviews changed
+func (tp *TelegraphPages) Tick(time.Time) {
+	tp.mu.Lock()
+	batch := make(map[string]string)
+	for key, name := range tp.dirty {
+		if len(batch) == telegraphBatch {
+			break
+		}
+		batch[key] = name
+		delete(tp.dirty, key)
+	}
+	tp.mu.Unlock()
+
+	for key, name := range batch {
+		err := tp.publish(key, name)
+		switch {
+		case errors.Is(err, errTelegraphRejected):
+			// Published again when the reviews of the professor change
+			logrus.WithError(err).WithField("professor", name).Error("Telegraph refused the page")
+		case err != nil:
+			logrus.WithError(err).WithField("professor", name).Warn("Failed to publish Telegraph page")
+			tp.mark(name)
+		}
+	}
+}
+
+// publish creates or updates the page of a professor
+func (tp *TelegraphPages) publish(key, name string) error {
+	reviews := tp.store.ProfessorReviews(name)
+	slices.Reverse(reviews)
+	content, err := json.Marshal(tp.content(i18n.Get().T(i18n.Get().GetDefault()), reviews))
+	if err != nil {
+		return err
+	}
+	tp.mu.Lock()
+	path := tp.Pages[key]
+	tp.mu.Unlock()
+
+	method := "createPage"
+	if path != "" {
+		method = "editPage/" + path
+	}
+	resp, err := tp.client.PostForm(telegraphAPI+method, url.Values{
+		"access_token": {tp.token},
+		"title":        {name},
+		"content":      {string(content)},
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var body struct {
+		OK     bool   `json:"ok"`
+		Error  string `json:"error"`
+		Result struct {
+			Path string `json:"path"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return err
+	}
+	if !body.OK {
+		if strings.HasPrefix(body.Error, "FLOOD_WAIT") {
+			return fmt.Errorf("telegraph: %s", body.Error)
+		}
+		return fmt.Errorf("%w: %s", errTelegraphRejected, body.Error)
+	}
+	if body.Result.Path != path {
+		tp.mu.Lock()
+		tp.Pages[key] = body.Result.Path
+		tp.save()
+		tp.mu.Unlock()
+	}
+	return nil
+}
+
+// content lays out the summary and the reviews of a professor, newest first, leaving out the oldest ones past telegraphMaxContent
+func (tp *TelegraphPages) content(msgs *i18n.Messages, reviews []Review) []any {
+	if len(reviews) == 0 {
+		return []any{telegraphNode{Tag: "p", Children: []any{msgs.Rating.NoReviews}}}
+	}
+	card := professorCards(reviews)[0]
+	summary := fmt.Sprintf(msgs.Rating.ProfessorSummary, card.Average, card.Count)
+	if averages := formatCriteriaAverages(msgs, card); averages != "" {
+		summary += " · " + averages
+	}
+	content := []any{telegraphNode{Tag: "p", Children: []any{telegraphNode{Tag: "b", Children: []any{summary}}}}}
+	size := nodesSize(content)
+	for i, r := range reviews {
+		heading := fmt.Sprintf("%d/5 ★", r.Score)
+		if criteria := formatCriteria(msgs, r.Criteria); criteria != "" {
+			heading += " · " + criteria
+		}
+		if line := courseLine(msgs, r.Course, r.Tag); line != "" {
+			heading += " · " + strings.TrimPrefix(line, "\n📚 ")
+		}
+		if line := archivedLine(msgs, r); line != "" {
+			heading += " · " + strings.TrimPrefix(line, "\n🗄 ")
+		}
+		author := tp.store.Author(r)
+		nodes := []any{
+			telegraphNode{Tag: "hr"},
+			telegraphNode{Tag: "h4", Children: []any{heading}},
+			telegraphNode{Tag: "p", Children: []any{r.Text}},
+			telegraphNode{Tag: "p", Children: []any{telegraphNode{Tag: "i", Children: []any{author + ", " + time.Unix(r.CreatedAt, 0).Format("02.01.2006")}}}},
+		}
+		if r.Response != nil && r.Response.Status == "approved" {
+			nodes = append(nodes, telegraphNode{Tag: "blockquote", Children: []any{
+				telegraphNode{Tag: "b", Children: []any{msgs.Rating.ProfessorResponse + ": "}}, r.Response.Text,
+			}})
+		}
+		if size += nodesSize(nodes); size > telegraphMaxContent {
+			content = append(content, telegraphNode{Tag: "hr"}, telegraphNode{Tag: "p", Children: []any{
+				telegraphNode{Tag: "i", Children: []any{fmt.Sprintf(msgs.Rating.PageTruncated, i, len(reviews))}},
+			}})
+			break
+		}
+		content = append(content, nodes...)
+	}
+	return content
+}
+
+// nodesSize returns the encoded size of page nodes
+func nodesSize(nodes []any) int {
+	data, _ := json.Marshal(nodes)
+	return len(data)
+}
@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// isStaff reports whether the user is an admin of the admin chat or a bot moderator, using the cached admin list
+func (fh *FeatureHandler) isStaff(user *tb.User) bool {
+	return fh.adminHandler.IsModerator(&tb.Chat{ID: fh.adminChatID}, user)
+}
+
+// explainFilters lists the rules a message would match, in the order FilterMessage applies them
+func (fh *FeatureHandler) explainFilters(in filterInput) []string {
+	var matches []string
+	for _, step := range filterSteps {
+		if step.match == nil {
+			continue
+		}
+		if hit, ok := step.match(fh, in); ok {
+			matches = append(matches, fmt.Sprintf("• `%s` → %s", hit.rule, hit.level))
+		}
+	}
+	return matches
+}
+
+// HandleTestFilter shows which filter rules a text or the replied message would trigger, with the settings of the chat
+// whose ID comes first in the payload or the default ones
+func (fh *FeatureHandler) HandleTestFilter(c tb.Context) error {
+	lang := fh.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if c.Message() == nil || c.Sender() == nil {
+		return nil
+	}
+	if c.Chat().ID != fh.adminChatID && (c.Chat().Type != tb.ChatPrivate || !fh.isStaff(c.Sender())) {
+		msg, _ := fh.bot.Send(c.Chat(), msgs.TestFilter.AdminOnly)
+		fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	payload := strings.TrimSpace(c.Message().Payload)
+	in := filterInput{chat: &tb.Chat{}, msg: c.Message(), cs: defaultChatSettings(), dryRun: true}
+	settingsLine := msgs.TestFilter.SettingsDefault
+	first, rest, _ := strings.Cut(payload, " ")
+	if chatID, err := strconv.ParseInt(first, 10, 64); err == nil && chatID < 0 {
+		payload = strings.TrimSpace(rest)
+		in.chat = &tb.Chat{ID: chatID}
+		if chat, err := fh.bot.ChatByID(chatID); err == nil {
+			in.chat = chat
+		}
+		in.cs = fh.settings.Get(chatID)
+		name := in.chat.Title
+		if name == "" {
+			name = first
+		}
+		settingsLine = fmt.Sprintf(msgs.TestFilter.SettingsChat, markdownEscaper.Replace(name))
+	}
+	in.text = payload
+	var author *tb.User
+	if reply := c.Message().ReplyTo; reply != nil && payload == "" {
+		in.msg, in.text, author = reply, messageText(reply), reply.Sender
+	}
+	if in.text == "" && author == nil {
+		return c.Send(msgs.TestFilter.Usage)
+	}
+
+	var lines []string
+	if author != nil {
+		// name patterns screen members when they join
+		if pattern, action, ok := fh.namePatterns.Match(author); ok {
+			lines = append(lines, fmt.Sprintf("• `name:%s` → %s", pattern, action))
+		}
+	}
+	lines = append(lines, fh.explainFilters(in)...)
+	reply := settingsLine + "\n\n" + msgs.TestFilter.NoMatch
+	if len(lines) > 0 {
+		reply = settingsLine + "\n\n" + msgs.TestFilter.Header + "\n" + strings.Join(lines, "\n")
+	}
+	if score, ok := fh.spamModel.Score(in.text); ok {
+		reply += "\n\n" + fmt.Sprintf(msgs.TestFilter.SpamScore, int(score*100))
+	}
+	reply += "\n\n" + msgs.TestFilter.NotSimulated
+	return c.Send(reply, tb.ModeMarkdown)
+}
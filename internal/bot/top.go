@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// A professor needs topMinReviews approved reviews to be ranked by /top, which lists topSize of each kind
+const (
+	topMinReviews = 3
+	topSize       = 5
+)
+
+// topCache keeps the /top ranking until the rating store changes
+type topCache struct {
+	mu      sync.Mutex
+	version int
+	ready   bool
+	best    []professorCard
+	worst   []professorCard
+}
+
+// Version returns a number that changes whenever the store is saved
+func (rs *RatingStore) Version() int {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.version
+}
+
+// rankProfessors returns the best and the worst rated professors with enough reviews, the two lists never overlap
+func rankProfessors(reviews []Review) (best, worst []professorCard) {
+	var ranked []professorCard
+	for _, card := range professorCards(reviews) {
+		if card.Count >= topMinReviews {
+			ranked = append(ranked, card)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Average != ranked[j].Average {
+			return ranked[i].Average > ranked[j].Average
+		}
+		return ranked[i].Count > ranked[j].Count
+	})
+	best = ranked[:min(topSize, len(ranked))]
+	rest := ranked[len(best):]
+	for i := len(rest) - 1; i >= 0 && len(worst) < topSize; i-- {
+		worst = append(worst, rest[i])
+	}
+	return best, worst
+}
+
+// topProfessors returns the cached ranking, computing it again after reviews change
+func (rh *RatingHandler) topProfessors() (best, worst []professorCard) {
+	version := rh.store.Version()
+	rh.top.mu.Lock()
+	defer rh.top.mu.Unlock()
+	if !rh.top.ready || rh.top.version != version {
+		rh.top.best, rh.top.worst = rankProfessors(rh.store.QueryReviews(ReviewQuery{}))
+		rh.top.version, rh.top.ready = version, true
+	}
+	return rh.top.best, rh.top.worst
+}
+
+// HandleTop shows the best and the worst rated professors with buttons to open their reviews
+func (rh *RatingHandler) HandleTop(c tb.Context) error {
+	msgs := i18n.Get().T(rh.getLangForUser(c.Sender()))
+	if c.Chat().Type != tb.ChatPrivate {
+		_, _ = rh.bot.Send(c.Chat(), msgs.Common.PrivateOnly)
+		return nil
+	}
+	best, worst := rh.topProfessors()
+	if len(best) == 0 {
+		_, _ = rh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Rating.TopEmpty.Form(msgs.Lang(), topMinReviews), topMinReviews))
+		return nil
+	}
+
+	var sb strings.Builder
+	var buttons [][]tb.InlineButton
+	var row []tb.InlineButton
+	n := 0
+	section := func(header string, cards []professorCard) {
+		if len(cards) == 0 {
+			return
+		}
+		if n > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(header)
+		for _, card := range cards {
+			n++
+			sb.WriteString(fmt.Sprintf("\n%d. *%s*: %.1f★ (%d)", n, card.Professor, card.Average, card.Count))
+			row = append(row, tb.InlineButton{Data: fmt.Sprintf("ratings_prof_%d_0", card.ReviewID), Text: strconv.Itoa(n)})
+			if len(row) == 5 {
+				buttons, row = append(buttons, row), nil
+			}
+		}
+	}
+	section(msgs.Rating.TopBest, best)
+	section(msgs.Rating.TopWorst, worst)
+	if len(row) > 0 {
+		buttons = append(buttons, row)
+	}
+	sb.WriteString("\n\n" + fmt.Sprintf(msgs.Rating.TopNote.Form(msgs.Lang(), topMinReviews), topMinReviews))
+
+	_, _ = rh.bot.Send(c.Chat(), sb.String(), &tb.ReplyMarkup{InlineKeyboard: buttons}, tb.ModeMarkdown)
+	return nil
+}
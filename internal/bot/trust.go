@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// TrustedUser is a member an admin trusted to skip verification in every chat
+type TrustedUser struct {
+	Since   time.Time `json:"since"`
+	AddedBy int64     `json:"added_by,omitempty"`
+}
+
+// TrustStore persists the trusted-user whitelist and the users who passed verification in each chat
+type TrustStore struct {
+	mu    sync.RWMutex
+	Users map[int64]TrustedUser         `json:"users"`
+	Chats map[int64]map[int64]time.Time `json:"chats,omitempty"` // chat ID -> user ID -> when they passed verification there
+	file  string
+}
+
+// NewTrustStore creates a trust store backed by a JSON file
+func NewTrustStore(file string) *TrustStore {
+	_ = os.MkdirAll("data", 0755)
+	ts := &TrustStore{
+		Users: make(map[int64]TrustedUser),
+		file:  file,
+	}
+	ts.load()
+	return ts
+}
+
+func (ts *TrustStore) load() {
+	data, err := os.ReadFile(ts.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, ts)
+	if ts.Users == nil {
+		ts.Users = make(map[int64]TrustedUser)
+	}
+	if ts.Chats == nil {
+		ts.Chats = make(map[int64]map[int64]time.Time)
+	}
+	// entries without an admin were trusted everywhere after passing verification once, which is no longer done
+	for id, u := range ts.Users {
+		if u.AddedBy == 0 {
+			delete(ts.Users, id)
+		}
+	}
+}
+
+func (ts *TrustStore) save() {
+	data, err := json.MarshalIndent(ts, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error("trust store marshal")
+		return
+	}
+	if err := os.WriteFile(ts.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("trust store write")
+	}
+}
+
+// Trust adds the user to the whitelist, keeping the original entry if present
+func (ts *TrustStore) Trust(userID, addedBy int64) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if _, ok := ts.Users[userID]; ok {
+		return
+	}
+	ts.Users[userID] = TrustedUser{Since: time.Now(), AddedBy: addedBy}
+	ts.save()
+}
+
+// TrustIn lets a user who passed verification skip it when rejoining the same chat
+func (ts *TrustStore) TrustIn(chatID, userID int64) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.Chats[chatID] == nil {
+		ts.Chats[chatID] = make(map[int64]time.Time)
+	}
+	ts.Chats[chatID][userID] = time.Now()
+	ts.save()
+}
+
+// Untrust removes the user from the whitelist and forgets every chat they passed verification in
+func (ts *TrustStore) Untrust(userID int64) bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	_, removed := ts.Users[userID]
+	delete(ts.Users, userID)
+	for _, users := range ts.Chats {
+		if _, ok := users[userID]; ok {
+			delete(users, userID)
+			removed = true
+		}
+	}
+	if removed {
+		ts.save()
+	}
+	return removed
+}
+
+// IsTrusted reports whether the user skips verification in the chat
+func (ts *TrustStore) IsTrusted(chatID, userID int64) bool {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	if _, ok := ts.Users[userID]; ok {
+		return true
+	}
+	_, ok := ts.Chats[chatID][userID]
+	return ok
+}
+
+// trustTarget resolves the user of a trust command from a reply or a numeric ID
+func trustTarget(m *tb.Message) *tb.User {
+	if m.ReplyTo != nil && m.ReplyTo.Sender != nil {
+		return m.ReplyTo.Sender
+	}
+	if id, err := strconv.ParseInt(strings.TrimSpace(m.Payload), 10, 64); err == nil && id > 0 {
+		return &tb.User{ID: id}
+	}
+	return nil
+}
+
+// HandleTrust whitelists a user so they skip verification on rejoin
+func (fh *FeatureHandler) HandleTrust(c tb.Context) error {
+	lang := fh.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if !fh.requireAdmin(c, msgs.Trust.AdminOnly) {
+		return nil
+	}
+	user := trustTarget(c.Message())
+	if user == nil {
+		msg, _ := fh.bot.Send(c.Chat(), msgs.Trust.Usage)
+		fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	fh.trusted.Trust(user.ID, c.Sender().ID)
+	msg, _ := fh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Trust.Added, fh.adminHandler.GetUserDisplayName(user)))
+	fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+	fh.adminHandler.LogToAdmin(fmt.Sprintf("🤝 Пользователь добавлен в доверенные.\n\nПользователь: %s\nАдмин: %s", fh.adminHandler.GetUserDisplayName(user), fh.adminHandler.GetUserDisplayName(c.Sender())))
+	return nil
+}
+
+// HandleUntrust removes a user from the whitelist
+func (fh *FeatureHandler) HandleUntrust(c tb.Context) error {
+	lang := fh.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if !fh.requireAdmin(c, msgs.Trust.AdminOnly) {
+		return nil
+	}
+	user := trustTarget(c.Message())
+	if user == nil {
+		msg, _ := fh.bot.Send(c.Chat(), msgs.Trust.UntrustUsage)
+		fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	if !fh.trusted.Untrust(user.ID) {
+		msg, _ := fh.bot.Send(c.Chat(), msgs.Trust.NotTrusted)
+		fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	msg, _ := fh.bot.Send(c.Chat(), fmt.Sprintf(msgs.Trust.Removed, fh.adminHandler.GetUserDisplayName(user)))
+	fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+	fh.adminHandler.LogToAdmin(fmt.Sprintf("🚫 Пользователь убран из доверенных.\n\nПользователь: %s\nАдмин: %s", fh.adminHandler.GetUserDisplayName(user), fh.adminHandler.GetUserDisplayName(c.Sender())))
+	return nil
+}
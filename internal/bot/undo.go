@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// undoableActions are the action kinds /undo knows how to reverse
+var undoableActions = []string{actionMute, actionUnmute, actionBan, actionSpamBan}
+
+// HandleUndo reverses the latest mute, unmute or ban the issuing admin made in this chat
+func (ah *AdminHandler) HandleUndo(c tb.Context) error {
+	msgs := i18n.Get().T(ah.getLangForUser(c.Sender()))
+	if !ah.requireModerator(c, msgs) {
+		return nil
+	}
+	a, ok := ah.actions.LastBy(c.Chat().ID, c.Sender().ID, undoableActions...)
+	if !ok {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Moderation.NothingToUndo)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	if a.Kind == actionUnmute {
+		// the mute comes back with the end time and reason it had, a mute that is already over is not restored
+		mute, found := ah.actions.Before(a, actionMute)
+		if !found || !mute.Until.IsZero() && time.Now().After(mute.Until) {
+			msg, _ := ah.bot.Send(c.Chat(), fmt.Sprintf(msgs.Moderation.UndoMuteOver, a.UserName))
+			ah.DeleteAfter(msg, 10*time.Second)
+			return nil
+		}
+		a.Until, a.Reason = mute.Until, mute.Reason
+	}
+	if err := ah.revert(c.Chat(), a); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"chat_id": a.ChatID, "user_id": a.UserID, "kind": a.Kind}).Error("Failed to undo action")
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Moderation.UndoFailed)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	ah.actions.MarkUndone(a.ID)
+
+	var text string
+	switch a.Kind {
+	case actionMute:
+		text = msgs.Moderation.UndoneMute
+	case actionUnmute:
+		text = msgs.Moderation.UndoneUnmute
+		ah.actions.Record(ModAction{Kind: actionMute, ChatID: a.ChatID, UserID: a.UserID, UserName: a.UserName, ByID: c.Sender().ID, By: ah.GetUserDisplayName(c.Sender()), Reason: a.Reason, Until: a.Until})
+		ah.scheduleExpiry(a.Until)
+	default:
+		text = msgs.Moderation.UndoneBan
+	}
+	_, _ = ah.bot.Send(c.Chat(), fmt.Sprintf(text, a.UserName))
+	ah.LogToAdmin(fmt.Sprintf("↩️ Действие отменено\n\nАдмин: %s\nДействие: %s от %s\nПользователь: %s", ah.GetUserDisplayName(c.Sender()), a.Kind, a.At.Format("02.01.2006 15:04"), a.UserName))
+	return nil
+}
+
+// revert undoes a recorded action: lifts a mute, mutes again until a.Until after an unmute, or unbans
+func (ah *AdminHandler) revert(chat *tb.Chat, a ModAction) error {
+	user := &tb.User{ID: a.UserID}
+	switch a.Kind {
+	case actionMute:
+		return ah.bot.Restrict(chat, &tb.ChatMember{User: user, Rights: memberRights, RestrictedUntil: tb.Forever()})
+	case actionUnmute:
+		member := &tb.ChatMember{User: user, Rights: tb.Rights{CanSendMessages: false}, RestrictedUntil: tb.Forever()}
+		if !a.Until.IsZero() {
+			member.RestrictedUntil = a.Until.Unix()
+		}
+		return ah.bot.Restrict(chat, member)
+	case actionBan:
+		return ah.bot.Unban(chat, user, true)
+	case actionSpamBan:
+		var last error
+		for _, chatID := range ah.AllGroupIDs() {
+			if err := ah.bot.Unban(&tb.Chat{ID: chatID}, user, true); err != nil {
+				logrus.WithError(err).WithFields(logrus.Fields{"chat_id": chatID, "user_id": a.UserID}).Warn("Failed to lift spam ban in group")
+				last = err
+			}
+		}
+		return last
+	}
+	return fmt.Errorf("cannot undo %s", a.Kind)
+}
@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// seenRefresh is how stale the last seen time may get before it is updated
+const seenRefresh = time.Hour
+
+// IndexedUser is what the bot remembers about a user it has seen writing
+type IndexedUser struct {
+	Username  string    `json:"username,omitempty"`
+	FirstName string    `json:"first_name,omitempty"`
+	LastName  string    `json:"last_name,omitempty"`
+	SeenAt    time.Time `json:"seen_at"`
+}
+
+// UserIndex resolves usernames and IDs of seen users for admin commands
+type UserIndex struct {
+	mu    sync.RWMutex
+	Users map[int64]IndexedUser `json:"users"`
+	file  string
+	dirty bool
+}
+
+// NewUserIndex creates a user index backed by a JSON file
+func NewUserIndex(file string) *UserIndex {
+	_ = os.MkdirAll("data", 0755)
+	ui := &UserIndex{Users: make(map[int64]IndexedUser), file: file}
+	ui.load()
+	return ui
+}
+
+func (ui *UserIndex) load() {
+	data, err := os.ReadFile(ui.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, ui)
+	if ui.Users == nil {
+		ui.Users = make(map[int64]IndexedUser)
+	}
+}
+
+func (ui *UserIndex) save() {
+	data, err := json.Marshal(ui)
+	if err != nil {
+		logrus.WithError(err).Error("user index marshal")
+		return
+	}
+	if err := os.WriteFile(ui.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("user index write")
+	}
+}
+
+// Flush writes the index to disk if it changed since the last write
+func (ui *UserIndex) Flush(time.Time) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	if ui.dirty {
+		ui.save()
+		ui.dirty = false
+	}
+}
+
+// Seen remembers the current name and username of a user, taking the username away from whoever held it before
+func (ui *UserIndex) Seen(user *tb.User) {
+	if user == nil || user.IsBot {
+		return
+	}
+	now := time.Now()
+	entry := IndexedUser{Username: user.Username, FirstName: user.FirstName, LastName: user.LastName, SeenAt: now}
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	old, ok := ui.Users[user.ID]
+	if ok && old.Username == entry.Username && old.FirstName == entry.FirstName && old.LastName == entry.LastName && now.Sub(old.SeenAt) < seenRefresh {
+		return
+	}
+	if entry.Username != "" && !strings.EqualFold(old.Username, entry.Username) {
+		for id, u := range ui.Users {
+			if id != user.ID && strings.EqualFold(u.Username, entry.Username) {
+				u.Username = ""
+				ui.Users[id] = u
+			}
+		}
+	}
+	ui.Users[user.ID] = entry
+	ui.dirty = true
+}
+
+// Find resolves an @username to the user last seen with it, or a numeric ID, which is accepted even if the user was never seen
+func (ui *UserIndex) Find(ref string) (*tb.User, bool) {
+	ui.mu.RLock()
+	defer ui.mu.RUnlock()
+	if username, ok := strings.CutPrefix(ref, "@"); ok {
+		if username == "" {
+			return nil, false
+		}
+		var found *tb.User
+		var seenAt time.Time
+		for id, u := range ui.Users {
+			if strings.EqualFold(u.Username, username) && (found == nil || u.SeenAt.After(seenAt)) {
+				found, seenAt = &tb.User{ID: id, Username: u.Username, FirstName: u.FirstName, LastName: u.LastName}, u.SeenAt
+			}
+		}
+		return found, found != nil
+	}
+	id, err := strconv.ParseInt(ref, 10, 64)
+	if err != nil || id <= 0 {
+		return nil, false
+	}
+	u := ui.Users[id]
+	return &tb.User{ID: id, Username: u.Username, FirstName: u.FirstName, LastName: u.LastName}, true
+}
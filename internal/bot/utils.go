@@ -20,29 +20,79 @@ type FeatureHandler struct {
 	quiz            core.QuizInterface
 	blacklist       core.BlacklistInterface
 	adminChatID     int64
-	violations      map[int64]int
 	rlMu            sync.Mutex
 	rateLimit       map[int64]time.Time
 	Btns            struct{ Student, Guest, Ads tb.InlineButton }
 	adminHandler    core.AdminHandlerInterface
 	userLanguages   map[int64]i18n.Lang
 	userLanguagesMu sync.RWMutex
+	approvedJoins   map[int64]time.Time
+	approvedMu      sync.Mutex
+	settings        *SettingsStore
+	quizzes         *QuizStore
+	trusted         *TrustStore
+	audit           *AuditStore
+	strikes         *StrikeStore
+	actions         *ActionLog
+	ratings         *RatingStore
+	domains         *DomainStore
+	namePatterns    *NamePatternStore
+	chatTypes       chatTypeCache
+	mediaFlood      *floodCounter
+	messageFlood    *floodCounter
+	duplicates      *floodCounter
+	slowMode        slowModeTracker
+	cas             *casChecker
+	spamModel       *SpamModel
+	spamCorpus      *SpamCorpus
+	spamReviews     spamReviews
+	moderation      core.ModerationProvider
+	quizSessions    map[int64]*quizSession
+	quizMu          sync.Mutex
+	tasks           *TaskQueue
+	users           *UserIndex
+	startPayloads   []startPayload // Deep link handlers by /start payload prefix
 }
 
 // NewFeatureHandler constructs feature handler
-func NewFeatureHandler(bot *tb.Bot, state core.UserState, quiz core.QuizInterface, blacklist core.BlacklistInterface, adminChatID int64, violations map[int64]int, adminHandler core.AdminHandlerInterface, btns struct{ Student, Guest, Ads tb.InlineButton }) *FeatureHandler {
-	return &FeatureHandler{
+func NewFeatureHandler(bot *tb.Bot, state core.UserState, quiz core.QuizInterface, blacklist core.BlacklistInterface, adminChatID int64, adminHandler core.AdminHandlerInterface, btns struct{ Student, Guest, Ads tb.InlineButton }, settings *SettingsStore, quizzes *QuizStore, trusted *TrustStore, audit *AuditStore, strikes *StrikeStore, actions *ActionLog, ratings *RatingStore, domains *DomainStore, namePatterns *NamePatternStore, spamModel *SpamModel, spamCorpus *SpamCorpus, moderation core.ModerationProvider, tasks *TaskQueue, users *UserIndex) *FeatureHandler {
+	fh := &FeatureHandler{
 		bot:           bot,
 		state:         state,
 		quiz:          quiz,
 		blacklist:     blacklist,
 		adminChatID:   adminChatID,
-		violations:    violations,
 		rateLimit:     make(map[int64]time.Time),
 		Btns:          btns,
 		adminHandler:  adminHandler,
 		userLanguages: make(map[int64]i18n.Lang),
+		approvedJoins: make(map[int64]time.Time),
+		settings:      settings,
+		quizzes:       quizzes,
+		trusted:       trusted,
+		audit:         audit,
+		strikes:       strikes,
+		actions:       actions,
+		ratings:       ratings,
+		domains:       domains,
+		namePatterns:  namePatterns,
+		chatTypes:     chatTypeCache{types: make(map[string]chatTypeEntry)},
+		mediaFlood:    newFloodCounter(),
+		messageFlood:  newFloodCounter(),
+		duplicates:    newFloodCounter(),
+		slowMode:      slowModeTracker{last: make(map[floodKey]time.Time)},
+		cas:           newCASChecker(),
+		spamModel:     spamModel,
+		spamCorpus:    spamCorpus,
+		spamReviews:   spamReviews{pending: make(map[int]spamReview)},
+		moderation:    moderation,
+		quizSessions:  make(map[int64]*quizSession),
+		tasks:         tasks,
+		users:         users,
 	}
+	tasks.Handle(taskWelcome, fh.expireWelcome)
+	tasks.Handle(taskQuizTimeout, fh.quizTimeout)
+	return fh
 }
 
 // getLangForUser returns language for a specific user based on their Telegram language
@@ -96,6 +146,8 @@ func (fh *FeatureHandler) SendOrEdit(chat *tb.Chat, msg *tb.Message, text string
 	var err error
 	if msg == nil {
 		msg, err = fh.bot.Send(chat, text, rm)
+	} else if hasCaption(msg) {
+		msg, err = fh.bot.EditCaption(msg, text, rm)
 	} else {
 		msg, err = fh.bot.Edit(msg, text, rm)
 	}
@@ -109,8 +161,7 @@ func (fh *FeatureHandler) SendOrEdit(chat *tb.Chat, msg *tb.Message, text string
 // SetUserRestriction applies chat permissions
 func (fh *FeatureHandler) SetUserRestriction(chat *tb.Chat, user *tb.User, allowAll bool) {
 	if allowAll {
-		rights := tb.Rights{CanSendMessages: true, CanSendPhotos: true, CanSendVideos: true, CanSendVideoNotes: true, CanSendVoiceNotes: true, CanSendPolls: true, CanSendOther: true, CanAddPreviews: true, CanInviteUsers: true}
-		if err := fh.bot.Restrict(chat, &tb.ChatMember{User: user, Rights: rights, RestrictedUntil: tb.Forever()}); err != nil {
+		if err := fh.bot.Restrict(chat, &tb.ChatMember{User: user, Rights: memberRights, RestrictedUntil: tb.Forever()}); err != nil {
 			logrus.WithError(err).WithFields(logrus.Fields{"chat_id": chat.ID, "user_id": user.ID, "action": "unrestrict"}).Error("Failed to unrestrict")
 		}
 	} else {
@@ -142,25 +193,45 @@ func (fh *FeatureHandler) HandleUserJoined(c tb.Context) error {
 	}
 	users := GetNewUsers(c.Message())
 	for _, u := range users {
+		if fh.takeApprovedJoin(u.ID) {
+			logrus.WithField("user_id", u.ID).Info("User joined after approved join request")
+			continue
+		}
+		if fh.trusted.IsTrusted(c.Chat().ID, u.ID) {
+			fh.recordVerification(c.Chat(), u, VerifyEvent{Outcome: verifyTrusted})
+			fh.adminHandler.LogEvent(c.Chat(), eventTrusted, u, fmt.Sprintf("🤝 Доверенный участник вошёл в чат без проверки.\n\nПользователь: %s", fh.adminHandler.GetUserDisplayName(u)))
+			continue
+		}
+		if fh.checkCAS(c.Chat(), u) {
+			continue
+		}
+		kicked, suspicious := fh.screenProfile(c.Chat(), u, false)
+		if kicked {
+			continue
+		}
 		lang := fh.getLangForUser(u)
 		msgs := i18n.Get().T(lang)
 
-		studentBtn := tb.InlineButton{Unique: "student", Text: msgs.Buttons.Student}
-		guestBtn := tb.InlineButton{Unique: "guest", Text: msgs.Buttons.Guest}
-		adsBtn := tb.InlineButton{Unique: "ads", Text: msgs.Buttons.Ads}
-		kb := &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{studentBtn}, {guestBtn}, {adsBtn}}}
+		selfJoined := c.Message().Sender == nil || c.Message().Sender.ID == u.ID
+		risk, score := fh.assessRisk(u, selfJoined)
+		if suspicious {
+			risk = RiskHigh
+		}
+		kb := welcomeKeyboard(risk, msgs)
 
+		fh.dropWelcome(u.ID, 0)
 		fh.state.SetNewbie(int(u.ID))
+		fh.state.SetRisk(int(u.ID), int(risk))
 		fh.SetUserRestriction(c.Chat(), u, false)
 		txt := msgs.Welcome.Greeting + "\n\n" + msgs.Welcome.ChooseOption
 		if u.Username != "" {
 			txt = fmt.Sprintf(msgs.Welcome.GreetingWithUsername, u.Username) + "\n\n" + msgs.Welcome.ChooseOption
 		}
-		msg := fh.SendOrEdit(c.Chat(), nil, txt, kb)
-		fh.adminHandler.DeleteAfter(msg, 5*time.Minute)
+		fh.sendWelcome(c.Chat(), u, txt, kb)
 		fh.state.InitUser(int(u.ID))
-		logMsg := fmt.Sprintf("👤 Новый участник вошёл в чат.\n\nПользователь: %s", fh.adminHandler.GetUserDisplayName(u))
-		fh.adminHandler.LogToAdmin(logMsg)
+		fh.recordVerification(c.Chat(), u, VerifyEvent{Outcome: verifyJoined, Score: fmt.Sprintf("%s, %d", risk, score)})
+		logMsg := fmt.Sprintf("👤 Новый участник вошёл в чат.\n\nПользователь: %s\nРиск: %s (%d)", fh.adminHandler.GetUserDisplayName(u), risk, score)
+		fh.adminHandler.LogEvent(c.Chat(), eventJoin, u, logMsg)
 	}
 	return nil
 }
@@ -171,25 +242,54 @@ func (fh *FeatureHandler) HandleUserLeft(c tb.Context) error {
 		return nil
 	}
 	user := c.Message().UserLeft
-	fh.state.ClearNewbie(int(user.ID))
-	fh.adminHandler.ClearViolations(user.ID)
+	fh.endQuizSession(user.ID)
+	fh.finishNewbie(user.ID, 0)
+	fh.adminHandler.ClearViolations(c.Chat().ID, user.ID)
 	logMsg := fmt.Sprintf("👋 Участник покинул чат.\n\nПользователь: %s", fh.adminHandler.GetUserDisplayName(user))
-	fh.adminHandler.LogToAdmin(logMsg)
+	fh.adminHandler.LogEvent(c.Chat(), eventLeave, user, logMsg)
 	return nil
 }
 
+// requireAdmin replies with denied to users who are neither chat admins nor bot moderators and reports whether a group command may proceed
+func (fh *FeatureHandler) requireAdmin(c tb.Context, denied string) bool {
+	if c.Message() == nil || c.Sender() == nil || c.Chat().Type == tb.ChatPrivate || !fh.adminHandler.IsModerator(c.Chat(), c.Sender()) {
+		msg, _ := fh.bot.Send(c.Chat(), denied)
+		fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return false
+	}
+	return true
+}
+
 // HandleGuest lifts restriction for guest.
 func (fh *FeatureHandler) HandleGuest(c tb.Context) error {
 	lang := fh.getLangForUser(c.Sender())
 	msgs := i18n.Get().T(lang)
 
+	if RiskLevel(fh.state.Risk(int(c.Sender().ID))) == RiskHigh {
+		return fh.bot.Respond(c.Callback(), &tb.CallbackResponse{Text: msgs.Buttons.NotYourButton})
+	}
+	if fh.needsRules(c.Chat(), c.Sender()) {
+		fh.showRules(c, "guest")
+		return nil
+	}
+	fh.admitGuest(c)
+	return nil
+}
+
+// admitGuest lets a guest write without the quiz
+func (fh *FeatureHandler) admitGuest(c tb.Context) {
+	lang := fh.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	chat := fh.targetChat(c.Chat(), c.Sender())
+	fh.recordVerification(c.Chat(), c.Sender(), VerifyEvent{Outcome: verifyGuest})
 	fh.SetUserRestriction(c.Chat(), c.Sender(), true)
-	fh.state.ClearNewbie(int(c.Sender().ID))
+	fh.state.SetVerified(int(c.Sender().ID))
+	fh.finishNewbie(c.Sender().ID, c.Message().ID)
 	msg := fh.SendOrEdit(c.Chat(), c.Message(), msgs.Guest.CanWrite, nil)
 	fh.adminHandler.DeleteAfter(msg, 5*time.Second)
 	logMsg := fmt.Sprintf("🧐 Пользователь выбрал, что у него есть вопрос.\n\nПользователь: %s", fh.adminHandler.GetUserDisplayName(c.Sender()))
-	fh.adminHandler.LogToAdmin(logMsg)
-	return nil
+	fh.adminHandler.LogEvent(chat, eventGuest, c.Sender(), logMsg)
 }
 
 // HandleAds informs about ads
@@ -200,7 +300,7 @@ func (fh *FeatureHandler) HandleAds(c tb.Context) error {
 	msg := fh.SendOrEdit(c.Chat(), c.Message(), msgs.Ads.Message, nil)
 	fh.adminHandler.DeleteAfter(msg, 10*time.Second)
 	logMsg := fmt.Sprintf("📢 Пользователь выбрал рекламу.\n\nПользователь: %s", fh.adminHandler.GetUserDisplayName(c.Sender()))
-	fh.adminHandler.LogToAdmin(logMsg)
+	fh.adminHandler.LogEvent(fh.targetChat(c.Chat(), c.Sender()), eventAds, c.Sender(), logMsg)
 	return nil
 }
 
@@ -213,6 +313,10 @@ func (fh *FeatureHandler) HandleStart(c tb.Context) error {
 		return nil
 	}
 	uid := c.Sender().ID
+	if handled, err := fh.dispatchStart(c); handled {
+		logrus.WithFields(logrus.Fields{"user_id": uid, "payload": c.Message().Payload}).Info("User opened a deep link")
+		return err
+	}
 	_, err := fh.bot.Send(c.Chat(), msgs.Start.Greeting)
 	logrus.WithField("user_id", uid).Info("User started bot")
 	return err
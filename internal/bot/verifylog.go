@@ -0,0 +1,265 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// Verification outcomes stored in the audit trail
+const (
+	verifyJoined        = "joined"
+	verifyJoinRequest   = "join_request"
+	verifyTrusted       = "trusted"
+	verifyQuizPassed    = "quiz_passed"
+	verifyQuizFailed    = "quiz_failed"
+	verifyTimedOut      = "timed_out"
+	verifyGuest         = "guest"
+	verifyConfirmed     = "confirmed"
+	verifyAwaiting      = "awaiting_approval"
+	verifyApproved      = "approved"
+	verifyDeclined      = "declined"
+	verifyCASBanned     = "cas_banned"
+	verifyProfileKicked = "profile_kicked"
+)
+
+const (
+	// maxVerifyEvents caps the stored history of a single user
+	maxVerifyEvents = 50
+	// verifyLogShown is how many latest events /verifylog prints
+	verifyLogShown = 20
+)
+
+// VerifyEvent is a single step of a user's verification
+type VerifyEvent struct {
+	At      time.Time `json:"at"`
+	ChatID  int64     `json:"chat_id,omitempty"`
+	Outcome string    `json:"outcome"`
+	Score   string    `json:"score,omitempty"`
+	Answers []string  `json:"answers,omitempty"`
+	By      string    `json:"by,omitempty"` // admin who decided a manual override
+}
+
+// VerifyHistory is the verification history of a user
+type VerifyHistory struct {
+	Username  string        `json:"username,omitempty"`
+	Name      string        `json:"name,omitempty"`
+	Language  string        `json:"language,omitempty"`
+	FirstSeen time.Time     `json:"first_seen,omitzero"`
+	Events    []VerifyEvent `json:"events"`
+}
+
+// AuditStore persists verification histories
+type AuditStore struct {
+	mu    sync.RWMutex
+	Users map[int64]*VerifyHistory `json:"users"`
+	file  string
+}
+
+// NewAuditStore creates an audit store backed by a JSON file
+func NewAuditStore(file string) *AuditStore {
+	_ = os.MkdirAll("data", 0755)
+	as := &AuditStore{
+		Users: make(map[int64]*VerifyHistory),
+		file:  file,
+	}
+	as.load()
+	return as
+}
+
+func (as *AuditStore) load() {
+	data, err := os.ReadFile(as.file)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, as)
+	if as.Users == nil {
+		as.Users = make(map[int64]*VerifyHistory)
+	}
+}
+
+func (as *AuditStore) save() {
+	data, err := json.MarshalIndent(as, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error("audit store marshal")
+		return
+	}
+	if err := os.WriteFile(as.file, data, 0644); err != nil {
+		logrus.WithError(err).Error("audit store write")
+	}
+}
+
+// Record appends an event to the user's history
+func (as *AuditStore) Record(user *tb.User, ev VerifyEvent) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	h, ok := as.Users[user.ID]
+	if !ok {
+		h = &VerifyHistory{}
+		as.Users[user.ID] = h
+	}
+	if user.Username != "" {
+		h.Username = user.Username
+	}
+	if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+		h.Name = name
+	}
+	if user.LanguageCode != "" {
+		h.Language = user.LanguageCode
+	}
+	if h.FirstSeen.IsZero() {
+		h.FirstSeen = ev.At
+		if len(h.Events) > 0 {
+			h.FirstSeen = h.Events[0].At
+		}
+	}
+	h.Events = append(h.Events, ev)
+	if len(h.Events) > maxVerifyEvents {
+		h.Events = h.Events[len(h.Events)-maxVerifyEvents:]
+	}
+	as.save()
+}
+
+// History returns a copy of the user's history
+func (as *AuditStore) History(userID int64) (VerifyHistory, bool) {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+	h, ok := as.Users[userID]
+	if !ok {
+		return VerifyHistory{}, false
+	}
+	cp := *h
+	cp.Events = append([]VerifyEvent(nil), h.Events...)
+	return cp, true
+}
+
+// FindByUsername looks up a user ID by the last seen username
+func (as *AuditStore) FindByUsername(username string) (int64, bool) {
+	username = strings.TrimPrefix(username, "@")
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+	for id, h := range as.Users {
+		if strings.EqualFold(h.Username, username) {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// recordVerification adds an event for the user in the chat being verified
+func (fh *FeatureHandler) recordVerification(chat *tb.Chat, user *tb.User, ev VerifyEvent) {
+	ev.At = time.Now()
+	if chat != nil {
+		ev.ChatID = fh.targetChat(chat, user).ID
+	}
+	fh.audit.Record(user, ev)
+}
+
+// chosen returns the text of the answers picked so far, in question order
+func (s *quizSession) chosen() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]string, len(s.questions))
+	for i, q := range s.questions {
+		result[i] = "—"
+		for _, btn := range q.GetButtons() {
+			if btn.Unique == s.answers[i] {
+				result[i] = btn.Text
+			}
+		}
+	}
+	return result
+}
+
+// verifyOutcomeLabel returns a localized description of an outcome
+func verifyOutcomeLabel(outcome string, msgs *i18n.Messages) string {
+	switch outcome {
+	case verifyJoined:
+		return msgs.VerifyLog.Joined
+	case verifyJoinRequest:
+		return msgs.VerifyLog.JoinRequest
+	case verifyTrusted:
+		return msgs.VerifyLog.Trusted
+	case verifyQuizPassed:
+		return msgs.VerifyLog.QuizPassed
+	case verifyQuizFailed:
+		return msgs.VerifyLog.QuizFailed
+	case verifyTimedOut:
+		return msgs.VerifyLog.TimedOut
+	case verifyGuest:
+		return msgs.VerifyLog.Guest
+	case verifyConfirmed:
+		return msgs.VerifyLog.Confirmed
+	case verifyAwaiting:
+		return msgs.VerifyLog.Awaiting
+	case verifyApproved:
+		return msgs.VerifyLog.Approved
+	case verifyDeclined:
+		return msgs.VerifyLog.Declined
+	case verifyCASBanned:
+		return msgs.VerifyLog.CASBanned
+	case verifyProfileKicked:
+		return msgs.VerifyLog.ProfileKicked
+	}
+	return outcome
+}
+
+// HandleVerifyLog shows the verification history of a user
+func (fh *FeatureHandler) HandleVerifyLog(c tb.Context) error {
+	lang := fh.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if !fh.requireAdmin(c, msgs.VerifyLog.AdminOnly) {
+		return nil
+	}
+	var userID int64
+	payload := strings.TrimSpace(c.Message().Payload)
+	if strings.HasPrefix(payload, "@") {
+		userID, _ = fh.audit.FindByUsername(payload)
+	} else if u := trustTarget(c.Message()); u != nil {
+		userID = u.ID
+	} else {
+		msg, _ := fh.bot.Send(c.Chat(), msgs.VerifyLog.Usage)
+		fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	h, ok := fh.audit.History(userID)
+	if !ok || len(h.Events) == 0 {
+		msg, _ := fh.bot.Send(c.Chat(), msgs.VerifyLog.Empty)
+		fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+
+	name := h.Name
+	if h.Username != "" {
+		name = "@" + h.Username
+	}
+	events := h.Events
+	if len(events) > verifyLogShown {
+		events = events[len(events)-verifyLogShown:]
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(msgs.VerifyLog.Header, name, userID))
+	for _, ev := range events {
+		sb.WriteString(fmt.Sprintf("\n%s · %s", ev.At.Format("02.01.2006 15:04"), verifyOutcomeLabel(ev.Outcome, msgs)))
+		if ev.Score != "" {
+			sb.WriteString(" (" + ev.Score + ")")
+		}
+		if len(ev.Answers) > 0 {
+			sb.WriteString("\n   " + fmt.Sprintf(msgs.VerifyLog.Answers, strings.Join(ev.Answers, ", ")))
+		}
+		if ev.By != "" {
+			sb.WriteString("\n   " + fmt.Sprintf(msgs.VerifyLog.By, ev.By))
+		}
+	}
+	_, err := fh.bot.Send(c.Chat(), sb.String())
+	return err
+}
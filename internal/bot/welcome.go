@@ -0,0 +1,150 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"capybot/internal/core"
+	"capybot/internal/i18n"
+
+	"github.com/sirupsen/logrus"
+	tb "gopkg.in/telebot.v4"
+)
+
+// WelcomeMedia is a media attachment sent with the greeting
+type WelcomeMedia struct {
+	Type   string `json:"type"` // photo, animation, video, sticker
+	FileID string `json:"file_id"`
+}
+
+// welcomeMediaFrom extracts a supported attachment from a message
+func welcomeMediaFrom(m *tb.Message) *WelcomeMedia {
+	switch {
+	case m == nil:
+		return nil
+	case m.Photo != nil:
+		return &WelcomeMedia{Type: "photo", FileID: m.Photo.FileID}
+	case m.Animation != nil:
+		return &WelcomeMedia{Type: "animation", FileID: m.Animation.FileID}
+	case m.Video != nil:
+		return &WelcomeMedia{Type: "video", FileID: m.Video.FileID}
+	case m.Sticker != nil:
+		return &WelcomeMedia{Type: "sticker", FileID: m.Sticker.FileID}
+	}
+	return nil
+}
+
+// hasCaption reports whether a message text is stored as a media caption
+func hasCaption(m *tb.Message) bool {
+	return m.Photo != nil || m.Animation != nil || m.Video != nil || m.Document != nil
+}
+
+// welcomeTTL is how long an unanswered greeting stays in the chat
+const welcomeTTL = 5 * time.Minute
+
+// trackWelcome remembers a welcome message of the user and removes it after the timeout
+func (fh *FeatureHandler) trackWelcome(userID int64, msg *tb.Message) {
+	if msg == nil || msg.Chat == nil {
+		return
+	}
+	fh.state.AddWelcome(int(userID), core.MessageRef{ChatID: msg.Chat.ID, MessageID: msg.ID})
+	fh.tasks.Add(Task{Kind: taskWelcome, At: time.Now().Add(welcomeTTL), ChatID: msg.Chat.ID, UserID: userID, MessageID: msg.ID})
+}
+
+// expireWelcome deletes a greeting nobody answered unless it is gone already
+func (fh *FeatureHandler) expireWelcome(t Task) {
+	if fh.state.ForgetWelcome(int(t.UserID), t.MessageID) {
+		_ = fh.bot.Delete(&tb.StoredMessage{MessageID: strconv.Itoa(t.MessageID), ChatID: t.ChatID})
+	}
+}
+
+// dropWelcome deletes all tracked welcome messages of the user except keepID
+func (fh *FeatureHandler) dropWelcome(userID int64, keepID int) {
+	for _, ref := range fh.state.TakeWelcome(int(userID)) {
+		if ref.MessageID == keepID {
+			continue
+		}
+		stored := tb.StoredMessage{MessageID: strconv.Itoa(ref.MessageID), ChatID: ref.ChatID}
+		if err := fh.bot.Delete(stored); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{"chat_id": ref.ChatID, "message_id": ref.MessageID}).Debug("Failed to delete stale welcome message")
+		}
+	}
+}
+
+// finishNewbie clears verification state of the user and removes stale greetings
+func (fh *FeatureHandler) finishNewbie(userID int64, keepID int) {
+	fh.state.ClearNewbie(int(userID))
+	fh.state.ClearRisk(int(userID))
+	fh.dropWelcome(userID, keepID)
+}
+
+// sendWelcome sends the greeting with the chat's welcome media if configured and tracks it
+func (fh *FeatureHandler) sendWelcome(chat *tb.Chat, user *tb.User, text string, kb *tb.ReplyMarkup) {
+	fh.trackWelcome(user.ID, fh.sendWelcomeMessage(chat, user.ID, text, kb))
+}
+
+// sendWelcomeMessage sends the greeting, preceded or captioned by the welcome media
+func (fh *FeatureHandler) sendWelcomeMessage(chat *tb.Chat, userID int64, text string, kb *tb.ReplyMarkup) *tb.Message {
+	media := fh.settings.Get(chat.ID).WelcomeMedia
+	if media == nil {
+		return fh.SendOrEdit(chat, nil, text, kb)
+	}
+	file := tb.File{FileID: media.FileID}
+	var what tb.Sendable
+	switch media.Type {
+	case "photo":
+		what = &tb.Photo{File: file, Caption: text}
+	case "animation":
+		what = &tb.Animation{File: file, Caption: text}
+	case "video":
+		what = &tb.Video{File: file, Caption: text}
+	case "sticker":
+		// Stickers have no caption, so the greeting follows as a separate message
+		if sticker, err := fh.bot.Send(chat, &tb.Sticker{File: file}); err == nil {
+			fh.trackWelcome(userID, sticker)
+		} else {
+			logrus.WithError(err).WithField("chat_id", chat.ID).Warn("Failed to send welcome sticker")
+		}
+		return fh.SendOrEdit(chat, nil, text, kb)
+	default:
+		return fh.SendOrEdit(chat, nil, text, kb)
+	}
+	msg, err := fh.bot.Send(chat, what, kb)
+	if err != nil {
+		logrus.WithError(err).WithField("chat_id", chat.ID).Warn("Failed to send welcome media, falling back to text")
+		return fh.SendOrEdit(chat, nil, text, kb)
+	}
+	return msg
+}
+
+// HandleSetWelcomeMedia stores the replied media as the chat greeting attachment
+func (ah *AdminHandler) HandleSetWelcomeMedia(c tb.Context) error {
+	lang := ah.getLangForUser(c.Sender())
+	msgs := i18n.Get().T(lang)
+
+	if c.Message() == nil || c.Sender() == nil || !ah.IsAdmin(c.Chat(), c.Sender()) {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Settings.AdminOnly)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	if strings.EqualFold(strings.TrimSpace(c.Message().Payload), "off") {
+		ah.settings.Update(c.Chat().ID, func(cs *ChatSettings) { cs.WelcomeMedia = nil })
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Welcome.MediaCleared)
+		ah.DeleteAfter(msg, 10*time.Second)
+		ah.LogToAdmin(fmt.Sprintf("🖼 Медиа приветствия удалено.\n\nАдмин: %s\nЧат: %s", ah.GetUserDisplayName(c.Sender()), c.Chat().Title))
+		return nil
+	}
+	media := welcomeMediaFrom(c.Message().ReplyTo)
+	if media == nil {
+		msg, _ := ah.bot.Send(c.Chat(), msgs.Welcome.MediaUsage)
+		ah.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+	ah.settings.Update(c.Chat().ID, func(cs *ChatSettings) { cs.WelcomeMedia = media })
+	msg, _ := ah.bot.Send(c.Chat(), msgs.Welcome.MediaSet)
+	ah.DeleteAfter(msg, 10*time.Second)
+	ah.LogToAdmin(fmt.Sprintf("🖼 Установлено медиа приветствия.\n\nАдмин: %s\nЧат: %s\nТип: %s", ah.GetUserDisplayName(c.Sender()), c.Chat().Title, media.Type))
+	return nil
+}
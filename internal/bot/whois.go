@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"capybot/internal/i18n"
+
+	tb "gopkg.in/telebot.v4"
+)
+
+// whoisActionsShown is how many latest moderation actions /whois prints
+const whoisActionsShown = 10
+
+// whoisTarget resolves the user of /whois from a reply, an @username seen during verification or a numeric ID
+func (fh *FeatureHandler) whoisTarget(m *tb.Message) *tb.User {
+	payload := strings.TrimSpace(m.Payload)
+	if strings.HasPrefix(payload, "@") {
+		if user, ok := fh.users.Find(payload); ok {
+			return user
+		}
+		if id, ok := fh.audit.FindByUsername(payload); ok {
+			return &tb.User{ID: id, Username: strings.TrimPrefix(payload, "@")}
+		}
+		return nil
+	}
+	return trustTarget(m)
+}
+
+// actionLabel returns the localized name of a moderation action kind
+func actionLabel(kind string, msgs *i18n.Messages) string {
+	switch kind {
+	case actionMute:
+		return msgs.Whois.Mute
+	case actionUnmute:
+		return msgs.Whois.Unmute
+	case actionBan:
+		return msgs.Whois.Ban
+	case actionUnban:
+		return msgs.Whois.Unban
+	case actionSpamBan:
+		return msgs.Whois.SpamBan
+	case actionKick:
+		return msgs.Whois.Kick
+	case actionApprove:
+		return msgs.Whois.Approve
+	case actionReject:
+		return msgs.Whois.Reject
+	case actionBlock:
+		return msgs.Whois.Block
+	case actionUnblock:
+		return msgs.Whois.Unblock
+	case actionDelete:
+		return msgs.Whois.Delete
+	case actionDismiss:
+		return msgs.Whois.Dismiss
+	}
+	return kind
+}
+
+// yesNo returns the localized yes or no
+func yesNo(v bool, msgs *i18n.Messages) string {
+	if v {
+		return msgs.Whois.Yes
+	}
+	return msgs.Whois.No
+}
+
+// HandleWhois shows what the bot has stored about a user
+func (fh *FeatureHandler) HandleWhois(c tb.Context) error {
+	msgs := i18n.Get().T(fh.getLangForUser(c.Sender()))
+	if !fh.requireAdmin(c, msgs.Whois.AdminOnly) {
+		return nil
+	}
+	user := fh.whoisTarget(c.Message())
+	if user == nil {
+		msg, _ := fh.bot.Send(c.Chat(), msgs.Whois.Usage)
+		fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+
+	history, known := fh.audit.History(user.ID)
+	var actions []ModAction
+	for _, a := range fh.actions.ForUser(user.ID) {
+		if a.Kind == actionMute || a.Kind == actionUnmute || a.Kind == actionBan || a.Kind == actionSpamBan || a.Kind == actionKick {
+			actions = append(actions, a)
+		}
+	}
+	strikes := fh.strikes.History(c.Chat().ID, user.ID)
+	if !known && len(actions) == 0 && len(strikes) == 0 && user.FirstName == "" {
+		msg, _ := fh.bot.Send(c.Chat(), msgs.Whois.Unknown)
+		fh.adminHandler.DeleteAfter(msg, 10*time.Second)
+		return nil
+	}
+
+	name := fh.adminHandler.GetUserDisplayName(user)
+	switch {
+	case history.Username != "":
+		name = "@" + history.Username
+	case user.FirstName == "" && user.Username == "" && history.Name != "":
+		name = history.Name
+	}
+	language := user.LanguageCode
+	if language == "" {
+		language = history.Language
+	}
+	if language == "" {
+		language = "—"
+	}
+	firstSeen := "—"
+	if !history.FirstSeen.IsZero() {
+		firstSeen = history.FirstSeen.Format("02.01.2006 15:04")
+	} else if len(history.Events) > 0 {
+		firstSeen = history.Events[0].At.Format("02.01.2006 15:04")
+	}
+	verified := msgs.Whois.No
+	if at, ok := fh.state.VerifiedAt(int(user.ID)); ok {
+		verified = at.Format("02.01.2006 15:04")
+	} else if fh.state.IsNewbie(int(user.ID)) {
+		verified = msgs.Whois.Pending
+	}
+	rulesAccepted := msgs.Whois.No
+	if at, ok := fh.state.RulesAccepted(int(user.ID), c.Chat().ID); ok {
+		rulesAccepted = at.Format("02.01.2006 15:04")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(msgs.Whois.Header, name, user.ID))
+	sb.WriteString("\n" + fmt.Sprintf(msgs.Whois.FirstSeen, firstSeen))
+	sb.WriteString("\n" + fmt.Sprintf(msgs.Whois.Verified, verified))
+	sb.WriteString("\n" + fmt.Sprintf(msgs.Whois.RulesAccepted, rulesAccepted))
+	if n := len(history.Events); n > 0 {
+		sb.WriteString("\n" + fmt.Sprintf(msgs.Whois.LastVerification, verifyOutcomeLabel(history.Events[n-1].Outcome, msgs)))
+	}
+	sb.WriteString("\n" + fmt.Sprintf(msgs.Whois.Trusted, yesNo(fh.trusted.IsTrusted(c.Chat().ID, user.ID), msgs)))
+	sb.WriteString("\n" + fmt.Sprintf(msgs.Whois.Language, language))
+	cs := fh.settings.Get(c.Chat().ID)
+	active := countSince(strikes, time.Now().Add(-cs.warnExpiry()))
+	sb.WriteString("\n" + fmt.Sprintf(msgs.Whois.Warnings, active, cs.warnLimit()))
+	if expired := len(strikes) - active; expired > 0 {
+		sb.WriteString(" " + fmt.Sprintf(msgs.Whois.ExpiredWarnings, expired))
+	}
+	sb.WriteString("\n" + fmt.Sprintf(msgs.Whois.ReviewBlocked, yesNo(fh.ratings.IsBlocked(user.ID), msgs)))
+
+	if len(actions) == 0 {
+		sb.WriteString("\n\n" + msgs.Whois.NoActions)
+	} else {
+		sb.WriteString("\n\n" + msgs.Whois.ActionsHeader)
+		if len(actions) > whoisActionsShown {
+			actions = actions[len(actions)-whoisActionsShown:]
+		}
+		for _, a := range actions {
+			sb.WriteString(fmt.Sprintf("\n%s · %s · %s", a.At.Format("02.01.2006 15:04"), actionLabel(a.Kind, msgs), a.By))
+			if !a.Until.IsZero() {
+				sb.WriteString(" · " + fmt.Sprintf(msgs.Whois.Until, formatUntil(a.Until, msgs)))
+			}
+			if a.Undone {
+				sb.WriteString(" · " + msgs.Whois.Undone)
+			}
+			if a.Reason != "" {
+				sb.WriteString("\n   " + fmt.Sprintf(msgs.Moderation.Reason, a.Reason))
+			}
+		}
+	}
+	_, err := fh.bot.Send(c.Chat(), sb.String())
+	return err
+}
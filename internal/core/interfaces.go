@@ -1,6 +1,7 @@
 package core
 
 import (
+	"strings"
 	"time"
 
 	tb "gopkg.in/telebot.v4"
@@ -9,12 +10,31 @@ import (
 // UserState manages per-user quiz progress and newbie status
 type UserState interface {
 	InitUser(id int)
-	IncCorrect(id int)
+	SetCorrect(id, n int)
 	TotalCorrect(id int) int
 	Reset(id int)
 	SetNewbie(id int)
 	ClearNewbie(id int)
 	IsNewbie(id int) bool
+	SetRisk(id int, level int)
+	ClearRisk(id int)
+	Risk(id int) int
+	SetJoinRequest(id int, chatID int64)
+	ClearJoinRequest(id int)
+	JoinRequest(id int) (int64, bool)
+	AcceptRules(id int, chatID int64)
+	RulesAccepted(id int, chatID int64) (time.Time, bool)
+	SetRulesNext(id int, step string)
+	TakeRulesNext(id int) (string, bool)
+	SetVerified(id int)
+	VerifiedAt(id int) (time.Time, bool)
+	CountPost(id int) int
+	PostsSinceVerified(id int) int
+	AddWelcome(id int, ref MessageRef)
+	TakeWelcome(id int) []MessageRef
+	ForgetWelcome(id int, messageID int) bool
+	TrackMessage(id int, ref MessageRef)
+	TakeMessages(id int, since time.Time) []MessageRef
 }
 
 // QuestionInterface single quiz question
@@ -29,28 +49,125 @@ type QuizInterface interface {
 	GetQuestions() []QuestionInterface
 }
 
+// Blacklist matching modes
+const (
+	MatchPhrase   = "phrase"
+	MatchWildcard = "wildcard"
+	MatchRegex    = "regex"
+)
+
+// Blacklist severity levels deciding what happens to a matching message
+const (
+	LevelMonitor    = "monitor"     // only report the match to the admin chat
+	LevelWarn       = "warn"        // keep the message, warn and count a violation
+	LevelDelete     = "delete"      // delete the message silently
+	LevelDeleteWarn = "delete_warn" // delete, warn and count a violation
+	LevelBan        = "ban"         // delete and ban right away
+)
+
+// BlacklistLevels lists valid severity levels
+var BlacklistLevels = []string{LevelMonitor, LevelWarn, LevelDelete, LevelDeleteWarn, LevelBan}
+
+// BlacklistEntry is a blocked phrase or pattern
+type BlacklistEntry struct {
+	Words    []string `json:"words,omitempty"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Mode     string   `json:"mode"`
+	Level    string   `json:"level,omitempty"`
+	Category string   `json:"category,omitempty"`
+	Remote   bool     `json:"remote,omitempty"` // comes from a subscribed list and cannot be removed locally
+}
+
+// Severity returns the entry level, defaulting to delete_warn
+func (e BlacklistEntry) Severity() string {
+	if e.Level == "" {
+		return LevelDeleteWarn
+	}
+	return e.Level
+}
+
+// String returns the entry as admins type it
+func (e BlacklistEntry) String() string {
+	if e.Mode == MatchRegex {
+		return "re:" + e.Pattern
+	}
+	return strings.Join(e.Words, " ")
+}
+
 // BlacklistInterface operations for banned phrases
 type BlacklistInterface interface {
-	AddPhrase(words []string)
+	AddPhrase(words []string, level, category string)
+	AddPattern(pattern, level, category string) error
+	Import(entries []BlacklistEntry) (added, duplicates int, invalid []string)
 	RemovePhrase(words []string) bool
-	List() [][]string
-	CheckMessage(msg string) bool
+	RemovePattern(pattern string) bool
+	List() []BlacklistEntry
+	Match(msg string) (BlacklistEntry, bool)
+	SetRemote(entries []BlacklistEntry, skipped []string, etag string) (invalid []string, err error)
+	RemoteETag() string
+}
+
+// ModerationProvider scores messages with an external toxicity model
+type ModerationProvider interface {
+	Name() string
+	// Moderate returns the worst category found in the text and its score from 0 to 1
+	Moderate(text string) (category string, score float64, err error)
 }
 
 // AdminHandlerInterface admin tools
 type AdminHandlerInterface interface {
 	LogToAdmin(message string)
+	LogEvent(chat *tb.Chat, kind string, user *tb.User, message string)
+	RecordDecision(c tb.Context, verdict, kind string, chat *tb.Chat, user *tb.User, reason string)
 	IsAdmin(chat *tb.Chat, user *tb.User) bool
+	IsModerator(chat *tb.Chat, user *tb.User) bool
+	IsChatPost(chat *tb.Chat, m *tb.Message) bool
+	HandleChatMember(c tb.Context) error
 	GetUserDisplayName(user *tb.User) string
 	DeleteAfter(m *tb.Message, d time.Duration)
 	BanUser(chat *tb.Chat, user *tb.User) error
 	HandleBan(c tb.Context) error
 	HandleUnban(c tb.Context) error
 	HandleListBan(c tb.Context) error
+	HandleListBanPage(c tb.Context) error
+	HandleImportBan(c tb.Context) error
+	HandleExportBan(c tb.Context) error
+	HandleSyncBan(c tb.Context) error
 	HandleSpamBan(c tb.Context) error
-	AddViolation(userID int64)
-	GetViolations(userID int64) int
-	ClearViolations(userID int64)
+	HandleWarn(c tb.Context) error
+	HandleUnwarn(c tb.Context) error
+	HandleMute(c tb.Context) error
+	HandleUnmute(c tb.Context) error
+	HandleBanUser(c tb.Context) error
+	HandleTempBan(c tb.Context) error
+	HandleKick(c tb.Context) error
+	HandlePurge(c tb.Context) error
+	HandleDel(c tb.Context) error
+	HandleUndo(c tb.Context) error
+	HandleBanList(c tb.Context) error
+	HandleMuteList(c tb.Context) error
+	HandleModListPage(c tb.Context) error
+	HandleModListLift(c tb.Context) error
+	HandlePromoteMod(c tb.Context) error
+	HandleDemoteMod(c tb.Context) error
+	HandleListMods(c tb.Context) error
+	HandleConfirmAction(c tb.Context) error
+	HandleReport(c tb.Context) error
+	HandleReportAction(c tb.Context) error
+	HandlePin(c tb.Context) error
+	HandleUnpin(c tb.Context) error
+	HandleUnpinAll(c tb.Context) error
+	HandleSettings(c tb.Context) error
+	HandleSet(c tb.Context) error
+	HandleSetWelcomeMedia(c tb.Context) error
+	HandleBanDomain(c tb.Context) error
+	HandleUnbanDomain(c tb.Context) error
+	HandleBanNamePattern(c tb.Context) error
+	HandleUnbanNamePattern(c tb.Context) error
+	HandleSlowMode(c tb.Context) error
+	AddViolation(chatID, userID int64, by *tb.User, reason string) int
+	GetViolations(chatID, userID int64) int
+	ClearViolations(chatID, userID int64)
 	Bot() *tb.Bot
 }
 
@@ -61,9 +178,19 @@ type FeatureHandlerInterface interface {
 	SetUserRestriction(chat *tb.Chat, user *tb.User, allowAll bool)
 	HandleUserJoined(c tb.Context) error
 	HandleUserLeft(c tb.Context) error
+	HandleJoinRequest(c tb.Context) error
 	HandleStudent(c tb.Context) error
 	HandleGuest(c tb.Context) error
 	HandleAds(c tb.Context) error
+	HandleConfirm(c tb.Context) error
+	HandleAddQuestion(c tb.Context) error
+	HandleDelQuestion(c tb.Context) error
+	HandleListQuestions(c tb.Context) error
+	HandleTrust(c tb.Context) error
+	HandleUntrust(c tb.Context) error
+	HandleVerifyLog(c tb.Context) error
+	HandleWhois(c tb.Context) error
+	HandleTestFilter(c tb.Context) error
 	HandlePing(c tb.Context) error
 	HandleStart(c tb.Context) error
 	HandlePrivateMessage(c tb.Context) error
@@ -71,4 +198,6 @@ type FeatureHandlerInterface interface {
 	RegisterQuizHandlers(bot *tb.Bot)
 	CreateQuizHandler(i int, q QuestionInterface, btn tb.InlineButton) func(tb.Context) error
 	FilterMessage(c tb.Context) error
+	FilterMedia(c tb.Context) error
+	NightModeTick(now time.Time)
 }
@@ -5,15 +5,40 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+// MessageRef points to a message in a chat
+type MessageRef struct {
+	ChatID    int64 `json:"chat_id"`
+	MessageID int   `json:"message_id"`
+}
+
+// recentPerUser is how many latest group messages are remembered per user
+const recentPerUser = 100
+
+// recentMessage is a group message of a user and when it was sent
+type recentMessage struct {
+	ref MessageRef
+	at  time.Time
+}
+
 // State holds user quiz results and newbie flags
 type State struct {
 	mu          sync.RWMutex
-	UserCorrect map[int]int  `json:"user_correct"`
-	NewbieMap   map[int]bool `json:"is_newbie"`
+	UserCorrect map[int]int             `json:"user_correct"`
+	NewbieMap   map[int]bool            `json:"is_newbie"`
+	RiskMap     map[int]int             `json:"risk"`
+	JoinReqMap  map[int]int64           `json:"join_requests"`
+	RulesMap    map[int]int64           `json:"rules_accepted"` // Accepted before acceptance was kept per chat, counts in every chat
+	RulesIn     map[int]map[int64]int64 `json:"rules_accepted_in"`
+	RulesNext   map[int]string          `json:"rules_next"` // Verification step resumed once the rules are accepted
+	WelcomeMap  map[int][]MessageRef    `json:"welcome_messages"`
+	VerifiedMap map[int]int64           `json:"verified"`
+	PostedMap   map[int]int             `json:"posted_since_verified"`
+	recent      map[int][]recentMessage // kept in memory only, message IDs are of no use for long
 	file        string
 }
 
@@ -23,17 +48,72 @@ func NewState() UserState {
 	s := &State{
 		UserCorrect: make(map[int]int),
 		NewbieMap:   make(map[int]bool),
+		RiskMap:     make(map[int]int),
+		JoinReqMap:  make(map[int]int64),
+		RulesMap:    make(map[int]int64),
+		RulesIn:     make(map[int]map[int64]int64),
+		RulesNext:   make(map[int]string),
+		WelcomeMap:  make(map[int][]MessageRef),
+		VerifiedMap: make(map[int]int64),
+		PostedMap:   make(map[int]int),
+		recent:      make(map[int][]recentMessage),
 		file:        filepath.Join("data", "state.json"),
 	}
 	s.load()
 	return s
 }
 
-func (s *State) InitUser(id int)    { s.withLock(func() { s.UserCorrect[id] = 0 }) }
-func (s *State) IncCorrect(id int)  { s.withLock(func() { s.UserCorrect[id]++ }) }
-func (s *State) Reset(id int)       { s.withLock(func() { delete(s.UserCorrect, id) }) }
-func (s *State) SetNewbie(id int)   { s.withLock(func() { s.NewbieMap[id] = true }) }
-func (s *State) ClearNewbie(id int) { s.withLock(func() { delete(s.NewbieMap, id) }) }
+func (s *State) InitUser(id int)      { s.withLock(func() { s.UserCorrect[id] = 0 }) }
+func (s *State) SetCorrect(id, n int) { s.withLock(func() { s.UserCorrect[id] = n }) }
+func (s *State) Reset(id int)         { s.withLock(func() { delete(s.UserCorrect, id) }) }
+func (s *State) SetNewbie(id int)     { s.withLock(func() { s.NewbieMap[id] = true }) }
+func (s *State) ClearNewbie(id int)   { s.withLock(func() { delete(s.NewbieMap, id) }) }
+
+func (s *State) SetRisk(id int, level int) { s.withLock(func() { s.RiskMap[id] = level }) }
+func (s *State) ClearRisk(id int)          { s.withLock(func() { delete(s.RiskMap, id) }) }
+
+func (s *State) SetJoinRequest(id int, chatID int64) {
+	s.withLock(func() { s.JoinReqMap[id] = chatID })
+}
+
+func (s *State) ClearJoinRequest(id int) { s.withLock(func() { delete(s.JoinReqMap, id) }) }
+
+func (s *State) AddWelcome(id int, ref MessageRef) {
+	s.withLock(func() { s.WelcomeMap[id] = append(s.WelcomeMap[id], ref) })
+}
+
+// TakeWelcome returns and forgets all tracked welcome messages of the user
+func (s *State) TakeWelcome(id int) []MessageRef {
+	s.mu.Lock()
+	refs := s.WelcomeMap[id]
+	delete(s.WelcomeMap, id)
+	s.mu.Unlock()
+	if len(refs) > 0 {
+		s.save()
+	}
+	return refs
+}
+
+// ForgetWelcome stops tracking a single welcome message and reports whether it was tracked
+func (s *State) ForgetWelcome(id int, messageID int) bool {
+	found := false
+	s.withLock(func() {
+		refs := s.WelcomeMap[id]
+		for i, ref := range refs {
+			if ref.MessageID == messageID {
+				refs = append(refs[:i], refs[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if len(refs) == 0 {
+			delete(s.WelcomeMap, id)
+		} else {
+			s.WelcomeMap[id] = refs
+		}
+	})
+	return found
+}
 
 func (s *State) TotalCorrect(id int) int {
 	s.mu.RLock()
@@ -47,6 +127,113 @@ func (s *State) IsNewbie(id int) bool {
 	return s.NewbieMap[id]
 }
 
+func (s *State) Risk(id int) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.RiskMap[id]
+}
+
+func (s *State) JoinRequest(id int) (int64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	chatID, ok := s.JoinReqMap[id]
+	return chatID, ok
+}
+
+// AcceptRules records when the user accepted the rules of the chat
+func (s *State) AcceptRules(id int, chatID int64) {
+	s.withLock(func() {
+		if s.RulesIn[id] == nil {
+			s.RulesIn[id] = make(map[int64]int64)
+		}
+		s.RulesIn[id][chatID] = time.Now().Unix()
+	})
+}
+
+// RulesAccepted returns when the user accepted the rules of the chat
+func (s *State) RulesAccepted(id int, chatID int64) (time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ts, ok := s.RulesIn[id][chatID]
+	if !ok {
+		ts, ok = s.RulesMap[id]
+	}
+	return time.Unix(ts, 0), ok
+}
+
+// SetRulesNext remembers the verification step to resume once the user accepts the rules
+func (s *State) SetRulesNext(id int, step string) { s.withLock(func() { s.RulesNext[id] = step }) }
+
+// TakeRulesNext returns and forgets the step to resume after the rules
+func (s *State) TakeRulesNext(id int) (string, bool) {
+	s.mu.Lock()
+	step, ok := s.RulesNext[id]
+	delete(s.RulesNext, id)
+	s.mu.Unlock()
+	if ok {
+		s.save()
+	}
+	return step, ok
+}
+
+// VerifiedAt returns when the user was let into the chat
+func (s *State) VerifiedAt(id int) (time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ts, ok := s.VerifiedMap[id]
+	return time.Unix(ts, 0), ok
+}
+
+// SetVerified records when the user was let into the chat and restarts the post count
+func (s *State) SetVerified(id int) {
+	s.withLock(func() {
+		s.VerifiedMap[id] = time.Now().Unix()
+		delete(s.PostedMap, id)
+	})
+}
+
+// CountPost adds a message to the user's post count since verification and returns the new count
+func (s *State) CountPost(id int) int {
+	var n int
+	s.withLock(func() {
+		s.PostedMap[id]++
+		n = s.PostedMap[id]
+	})
+	return n
+}
+
+// PostsSinceVerified returns how many messages the user sent since verification
+func (s *State) PostsSinceVerified(id int) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.PostedMap[id]
+}
+
+// TrackMessage remembers a group message of the user, dropping the oldest beyond the per-user limit
+func (s *State) TrackMessage(id int, ref MessageRef) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msgs := append(s.recent[id], recentMessage{ref: ref, at: time.Now()})
+	if len(msgs) > recentPerUser {
+		msgs = msgs[len(msgs)-recentPerUser:]
+	}
+	s.recent[id] = msgs
+}
+
+// TakeMessages returns the user's remembered messages sent since the given time and forgets all of them
+func (s *State) TakeMessages(id int, since time.Time) []MessageRef {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var refs []MessageRef
+	for _, m := range s.recent[id] {
+		if !m.at.Before(since) {
+			refs = append(refs, m.ref)
+		}
+	}
+	delete(s.recent, id)
+	return refs
+}
+
 func (s *State) withLock(fn func()) {
 	s.mu.Lock()
 	fn()
@@ -74,7 +261,31 @@ func (s *State) load() {
 	if s.UserCorrect == nil {
 		s.UserCorrect = make(map[int]int)
 	}
+	if s.PostedMap == nil {
+		s.PostedMap = make(map[int]int)
+	}
 	if s.NewbieMap == nil {
 		s.NewbieMap = make(map[int]bool)
 	}
+	if s.RiskMap == nil {
+		s.RiskMap = make(map[int]int)
+	}
+	if s.JoinReqMap == nil {
+		s.JoinReqMap = make(map[int]int64)
+	}
+	if s.RulesMap == nil {
+		s.RulesMap = make(map[int]int64)
+	}
+	if s.RulesIn == nil {
+		s.RulesIn = make(map[int]map[int64]int64)
+	}
+	if s.RulesNext == nil {
+		s.RulesNext = make(map[int]string)
+	}
+	if s.WelcomeMap == nil {
+		s.WelcomeMap = make(map[int][]MessageRef)
+	}
+	if s.VerifiedMap == nil {
+		s.VerifiedMap = make(map[int]int64)
+	}
 }
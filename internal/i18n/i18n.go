@@ -2,7 +2,9 @@ package i18n
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/BurntSushi/toml"
@@ -26,20 +28,39 @@ type Messages struct {
 		Greeting             string `toml:"greeting"`
 		GreetingWithUsername string `toml:"greeting_with_username"`
 		ChooseOption         string `toml:"choose_option"`
+		MediaSet             string `toml:"media_set"`
+		MediaCleared         string `toml:"media_cleared"`
+		MediaUsage           string `toml:"media_usage"`
 	} `toml:"welcome"`
 	Buttons struct {
 		Student       string `toml:"student"`
 		Guest         string `toml:"guest"`
 		Ads           string `toml:"ads"`
+		Confirm       string `toml:"confirm"`
+		AcceptRules   string `toml:"accept_rules"`
+		Prev          string `toml:"prev"`
 		NotYourButton string `toml:"not_your_button"`
+		Skip          string `toml:"skip"`
 	} `toml:"buttons"`
 	Quiz struct {
 		VerificationPassed string `toml:"verification_passed"`
 		VerificationFailed string `toml:"verification_failed"`
+		TimedOut           string `toml:"timed_out"`
+		Progress           string `toml:"progress"`
+		AwaitingApproval   string `toml:"awaiting_approval"`
+		ApprovedByAdmin    string `toml:"approved_by_admin"`
 		Question1          string `toml:"question_1"`
 		Question2          string `toml:"question_2"`
 		Question3          string `toml:"question_3"`
 	} `toml:"quiz"`
+	Rules struct {
+		Prompt string `toml:"prompt"`
+		Link   string `toml:"link"`
+		Pinned string `toml:"pinned"`
+	} `toml:"rules"`
+	JoinRequest struct {
+		Greeting string `toml:"greeting"`
+	} `toml:"join_request"`
 	Guest struct {
 		CanWrite string `toml:"can_write"`
 	} `toml:"guest"`
@@ -57,12 +78,44 @@ type Messages struct {
 		TooFast string `toml:"too_fast"`
 	} `toml:"ratelimit"`
 	Filter struct {
-		Warning string `toml:"warning"`
+		Warning          string `toml:"warning"`
+		Flood            string `toml:"flood"`
+		Duplicate        string `toml:"duplicate"`
+		MediaFlood       string `toml:"media_flood"`
+		NewbieMedia      string `toml:"newbie_media"`
+		Premoderation    string `toml:"premoderation"`
+		PremodApproved   string `toml:"premod_approved"`
+		DMRemoved        string `toml:"dm_removed"`
+		DMWarned         string `toml:"dm_warned"`
+		DMReason         string `toml:"dm_reason"`
+		DMStrikes        string `toml:"dm_strikes"`
+		DMRules          string `toml:"dm_rules"`
+		RuleBlacklist    string `toml:"rule_blacklist"`
+		RuleSpam         string `toml:"rule_spam"`
+		RuleModeration   string `toml:"rule_moderation"`
+		RuleCaps         string `toml:"rule_caps"`
+		RuleChannel      string `toml:"rule_channel"`
+		RuleDomain       string `toml:"rule_domain"`
+		RulePromo        string `toml:"rule_promo"`
+		RuleProbation    string `toml:"rule_probation"`
+		RuleEntity       string `toml:"rule_entity"`
+		RuleNewbieLimits string `toml:"rule_newbie_limits"`
 	} `toml:"filter"`
 	Admin struct {
 		BanCommandAdminOnly     string `toml:"ban_command_admin_only"`
 		BanUsage                string `toml:"ban_usage"`
 		BanAdded                string `toml:"ban_added"`
+		BanInvalidPattern       string `toml:"ban_invalid_pattern"`
+		BanInvalidLevel         string `toml:"ban_invalid_level"`
+		ImportUsage             string `toml:"import_usage"`
+		ImportFailed            string `toml:"import_failed"`
+		ImportSummary           string `toml:"import_summary"`
+		ExportCaption           Plural `toml:"export_caption"`
+		ExportSent              string `toml:"export_sent"`
+		SyncNotConfigured       string `toml:"sync_not_configured"`
+		SyncUnchanged           string `toml:"sync_unchanged"`
+		SyncFailed              string `toml:"sync_failed"`
+		SyncDone                Plural `toml:"sync_done"`
 		UnbanCommandAdminOnly   string `toml:"unban_command_admin_only"`
 		UnbanUsage              string `toml:"unban_usage"`
 		UnbanNotFound           string `toml:"unban_not_found"`
@@ -70,11 +123,148 @@ type Messages struct {
 		ListCommandAdminOnly    string `toml:"list_command_admin_only"`
 		ListEmpty               string `toml:"list_empty"`
 		ListHeader              string `toml:"list_header"`
+		ListSearchHeader        string `toml:"list_search_header"`
+		ListNoMatches           string `toml:"list_no_matches"`
+		ListUncategorized       string `toml:"list_uncategorized"`
+		ListPage                string `toml:"list_page"`
 		SpambanCommandAdminOnly string `toml:"spamban_command_admin_only"`
 		SpambanUserNotFound     string `toml:"spamban_user_not_found"`
 		SpambanCannotBanAdmin   string `toml:"spamban_cannot_ban_admin"`
 		SpambanSuccess          string `toml:"spamban_success"`
+		SpambanWiped            string `toml:"spamban_wiped"`
 	} `toml:"admin"`
+	Settings struct {
+		AdminOnly    string `toml:"admin_only"`
+		Usage        string `toml:"usage"`
+		UnknownKey   string `toml:"unknown_key"`
+		InvalidValue string `toml:"invalid_value"`
+		Updated      string `toml:"updated"`
+		Current      string `toml:"current"`
+	} `toml:"settings"`
+	QuizAdmin struct {
+		AdminOnly  string `toml:"admin_only"`
+		AddUsage   string `toml:"add_usage"`
+		Added      string `toml:"added"`
+		DelUsage   string `toml:"del_usage"`
+		Deleted    string `toml:"deleted"`
+		NotFound   string `toml:"not_found"`
+		ListEmpty  string `toml:"list_empty"`
+		ListHeader string `toml:"list_header"`
+	} `toml:"quiz_admin"`
+	Trust struct {
+		AdminOnly    string `toml:"admin_only"`
+		Usage        string `toml:"usage"`
+		UntrustUsage string `toml:"untrust_usage"`
+		Added        string `toml:"added"`
+		Removed      string `toml:"removed"`
+		NotTrusted   string `toml:"not_trusted"`
+	} `toml:"trust"`
+	VerifyLog struct {
+		AdminOnly     string `toml:"admin_only"`
+		Usage         string `toml:"usage"`
+		Empty         string `toml:"empty"`
+		Header        string `toml:"header"`
+		Answers       string `toml:"answers"`
+		By            string `toml:"by"`
+		Joined        string `toml:"joined"`
+		JoinRequest   string `toml:"join_request"`
+		Trusted       string `toml:"trusted"`
+		QuizPassed    string `toml:"quiz_passed"`
+		QuizFailed    string `toml:"quiz_failed"`
+		TimedOut      string `toml:"timed_out"`
+		Guest         string `toml:"guest"`
+		Confirmed     string `toml:"confirmed"`
+		Awaiting      string `toml:"awaiting"`
+		Approved      string `toml:"approved"`
+		Declined      string `toml:"declined"`
+		CASBanned     string `toml:"cas_banned"`
+		ProfileKicked string `toml:"profile_kicked"`
+	} `toml:"verify_log"`
+	Domains struct {
+		AdminOnly  string `toml:"admin_only"`
+		Usage      string `toml:"usage"`
+		UnbanUsage string `toml:"unban_usage"`
+		ListHeader string `toml:"list_header"`
+		Invalid    string `toml:"invalid"`
+		Added      string `toml:"added"`
+		Removed    string `toml:"removed"`
+		NotFound   string `toml:"not_found"`
+	} `toml:"domains"`
+	Moderation struct {
+		AdminOnly          string `toml:"admin_only"`
+		ReplyRequired      string `toml:"reply_required"`
+		Reason             string `toml:"reason"`
+		Warned             string `toml:"warned"`
+		WarnBanned         Plural `toml:"warn_banned"`
+		Unwarned           string `toml:"unwarned"`
+		NoWarnings         string `toml:"no_warnings"`
+		Forever            string `toml:"forever"`
+		Muted              string `toml:"muted"`
+		Unmuted            string `toml:"unmuted"`
+		TempBanUsage       string `toml:"temp_ban_usage"`
+		Banned             string `toml:"banned"`
+		BannedNotice       string `toml:"banned_notice"`
+		Kicked             string `toml:"kicked"`
+		PurgeUsage         string `toml:"purge_usage"`
+		Purged             Plural `toml:"purged"`
+		ConfirmYes         string `toml:"confirm_yes"`
+		ConfirmCancel      string `toml:"confirm_cancel"`
+		ConfirmUnavailable string `toml:"confirm_unavailable"`
+		ConfirmCancelled   string `toml:"confirm_cancelled"`
+		ConfirmBan         string `toml:"confirm_ban"`
+		ConfirmKick        string `toml:"confirm_kick"`
+		ConfirmPurge       Plural `toml:"confirm_purge"`
+		ConfirmSpamBan     string `toml:"confirm_spam_ban"`
+		NothingToUndo      string `toml:"nothing_to_undo"`
+		UndoFailed         string `toml:"undo_failed"`
+		UndoneMute         string `toml:"undone_mute"`
+		UndoneUnmute       string `toml:"undone_unmute"`
+		UndoneBan          string `toml:"undone_ban"`
+		BanListHeader      string `toml:"ban_list_header"`
+		MuteListHeader     string `toml:"mute_list_header"`
+		BanListEmpty       string `toml:"ban_list_empty"`
+		MuteListEmpty      string `toml:"mute_list_empty"`
+		ListIssuedBy       string `toml:"list_issued_by"`
+		ListLifted         string `toml:"list_lifted"`
+		ListLiftGone       string `toml:"list_lift_gone"`
+		ListLiftFailed     string `toml:"list_lift_failed"`
+		TargetRequired     string `toml:"target_required"`
+		UserNotFound       string `toml:"user_not_found"`
+		UndoMuteOver       string `toml:"undo_mute_over"`
+	} `toml:"moderation"`
+	NamePatterns struct {
+		AdminOnly  string `toml:"admin_only"`
+		Usage      string `toml:"usage"`
+		UnbanUsage string `toml:"unban_usage"`
+		ListHeader string `toml:"list_header"`
+		Added      string `toml:"added"`
+		Removed    string `toml:"removed"`
+		NotFound   string `toml:"not_found"`
+	} `toml:"name_patterns"`
+	SlowMode struct {
+		AdminOnly    string `toml:"admin_only"`
+		Usage        string `toml:"usage"`
+		Invalid      string `toml:"invalid"`
+		Enabled      string `toml:"enabled"`
+		EnabledUntil string `toml:"enabled_until"`
+		Disabled     string `toml:"disabled"`
+		StatusOn     string `toml:"status_on"`
+		StatusOff    string `toml:"status_off"`
+	} `toml:"slowmode"`
+	TestFilter struct {
+		AdminOnly       string `toml:"admin_only"`
+		Usage           string `toml:"usage"`
+		NoMatch         string `toml:"no_match"`
+		Header          string `toml:"header"`
+		SpamScore       string `toml:"spam_score"`
+		SettingsChat    string `toml:"settings_chat"`
+		SettingsDefault string `toml:"settings_default"`
+		NotSimulated    string `toml:"not_simulated"`
+	} `toml:"testfilter"`
+	Night struct {
+		Start string `toml:"start"`
+		End   string `toml:"end"`
+	} `toml:"night"`
 	Start struct {
 		Greeting string `toml:"greeting"`
 	} `toml:"start"`
@@ -88,47 +278,269 @@ type Messages struct {
 		SpambanDesc     string `toml:"spamban_desc"`
 		RateDesc        string `toml:"rate_desc"`
 		RatingsDesc     string `toml:"ratings_desc"`
+		ReportDesc      string `toml:"report_desc"`
+		MyreviewsDesc   string `toml:"myreviews_desc"`
+		TopDesc         string `toml:"top_desc"`
 	} `toml:"commands"`
 	Rating struct {
-		ChooseType      string `toml:"choose_type"`
-		EnterName       string `toml:"enter_name"`
-		InvalidName     string `toml:"invalid_name"`
-		ChooseScore     string `toml:"choose_score"`
-		EnterReview     string `toml:"enter_review"`
-		ReviewTooShort  string `toml:"review_too_short"`
-		ReviewTooLong   string `toml:"review_too_long"`
-		ConfirmReview   string `toml:"confirm_review"`
-		Submitted       string `toml:"submitted"`
-		Cancelled       string `toml:"cancelled"`
-		Blocked         string `toml:"blocked"`
-		ReviewApproved  string `toml:"review_approved"`
-		ReviewRejected  string `toml:"review_rejected"`
-		NoReviews       string `toml:"no_reviews"`
-		NoSearchResults string `toml:"no_search_results"`
-		ListHeader      string `toml:"list_header"`
-		SearchPrompt    string `toml:"search_prompt"`
-		BtnPublic       string `toml:"btn_public"`
-		BtnAnonymous    string `toml:"btn_anonymous"`
-		BtnCancel       string `toml:"btn_cancel"`
-		BtnSubmit       string `toml:"btn_submit"`
-		BtnApprove      string `toml:"btn_approve"`
-		BtnReject       string `toml:"btn_reject"`
-		BtnBlock        string `toml:"btn_block"`
-		BtnPrev         string `toml:"btn_prev"`
-		BtnNext         string `toml:"btn_next"`
-		BtnSearch       string `toml:"btn_search"`
-		Sender          string `toml:"sender"`
-		Professor       string `toml:"professor"`
-		Score           string `toml:"score"`
-		ReviewLabel     string `toml:"review_label"`
-		Anonymous       string `toml:"anonymous"`
-		Public          string `toml:"public"`
-		TypeLabel       string `toml:"type_label"`
-		NewReviewAdmin  string `toml:"new_review_admin"`
-		StatusApproved  string `toml:"status_approved"`
-		StatusRejected  string `toml:"status_rejected"`
-		StatusBlocked   string `toml:"status_blocked"`
+		ChooseType             string `toml:"choose_type"`
+		EnterName              string `toml:"enter_name"`
+		InvalidName            string `toml:"invalid_name"`
+		ChooseScore            string `toml:"choose_score"`
+		EnterReview            string `toml:"enter_review"`
+		ReviewTooShort         string `toml:"review_too_short"`
+		ReviewTooLong          string `toml:"review_too_long"`
+		ConfirmReview          string `toml:"confirm_review"`
+		Submitted              string `toml:"submitted"`
+		Cancelled              string `toml:"cancelled"`
+		Blocked                string `toml:"blocked"`
+		ReviewApproved         string `toml:"review_approved"`
+		ReviewRejected         string `toml:"review_rejected"`
+		NoReviews              string `toml:"no_reviews"`
+		NoSearchResults        string `toml:"no_search_results"`
+		ListHeader             string `toml:"list_header"`
+		SearchPrompt           string `toml:"search_prompt"`
+		BtnPublic              string `toml:"btn_public"`
+		BtnAnonymous           string `toml:"btn_anonymous"`
+		BtnCancel              string `toml:"btn_cancel"`
+		BtnSubmit              string `toml:"btn_submit"`
+		BtnApprove             string `toml:"btn_approve"`
+		BtnReject              string `toml:"btn_reject"`
+		BtnBlock               string `toml:"btn_block"`
+		BtnPrev                string `toml:"btn_prev"`
+		BtnNext                string `toml:"btn_next"`
+		BtnSearch              string `toml:"btn_search"`
+		Sender                 string `toml:"sender"`
+		Professor              string `toml:"professor"`
+		Score                  string `toml:"score"`
+		ReviewLabel            string `toml:"review_label"`
+		Anonymous              string `toml:"anonymous"`
+		Public                 string `toml:"public"`
+		TypeLabel              string `toml:"type_label"`
+		NewReviewAdmin         string `toml:"new_review_admin"`
+		StatusApproved         string `toml:"status_approved"`
+		StatusRejected         string `toml:"status_rejected"`
+		StatusBlocked          string `toml:"status_blocked"`
+		MyReviewsHeader        string `toml:"my_reviews_header"`
+		MyReviewsEmpty         string `toml:"my_reviews_empty"`
+		MyStatusPending        string `toml:"my_status_pending"`
+		MyStatusApproved       string `toml:"my_status_approved"`
+		MyStatusRejected       string `toml:"my_status_rejected"`
+		BtnEdit                string `toml:"btn_edit"`
+		EditDenied             string `toml:"edit_denied"`
+		EditStarted            string `toml:"edit_started"`
+		EditOf                 string `toml:"edit_of"`
+		AlreadyReviewed        string `toml:"already_reviewed"`
+		AlreadyPending         string `toml:"already_pending"`
+		Cooldown               string `toml:"cooldown"`
+		DailyLimit             Plural `toml:"daily_limit"`
+		ProfessorSummary       string `toml:"professor_summary"`
+		BtnBackToList          string `toml:"btn_back_to_list"`
+		DidYouMean             string `toml:"did_you_mean"`
+		BtnKeepName            string `toml:"btn_keep_name"`
+		ChooseProfessor        string `toml:"choose_professor"`
+		BtnManual              string `toml:"btn_manual"`
+		AddProfUsage           string `toml:"add_prof_usage"`
+		DelProfUsage           string `toml:"del_prof_usage"`
+		ProfAdded              string `toml:"prof_added"`
+		ProfExists             string `toml:"prof_exists"`
+		ProfRemoved            string `toml:"prof_removed"`
+		ProfNotFound           string `toml:"prof_not_found"`
+		ChooseCriterion        string `toml:"choose_criterion"`
+		DifficultyHint         string `toml:"difficulty_hint"`
+		CriterionClarity       string `toml:"criterion_clarity"`
+		CriterionFairness      string `toml:"criterion_fairness"`
+		CriterionDifficulty    string `toml:"criterion_difficulty"`
+		BtnSkip                string `toml:"btn_skip"`
+		ChooseTag              string `toml:"choose_tag"`
+		EnterCourse            string `toml:"enter_course"`
+		InvalidCourse          string `toml:"invalid_course"`
+		TagLecture             string `toml:"tag_lecture"`
+		TagSeminar             string `toml:"tag_seminar"`
+		TagExam                string `toml:"tag_exam"`
+		VoteSaved              string `toml:"vote_saved"`
+		VoteRemoved            string `toml:"vote_removed"`
+		VoteDenied             string `toml:"vote_denied"`
+		ReportSent             string `toml:"report_sent"`
+		ReportAlready          string `toml:"report_already"`
+		ReportDenied           string `toml:"report_denied"`
+		ReviewRemoved          string `toml:"review_removed"`
+		MyStatusRemoved        string `toml:"my_status_removed"`
+		RejectReason           string `toml:"reject_reason"`
+		ReasonInsults          string `toml:"reason_insults"`
+		ReasonPersonalData     string `toml:"reason_personal_data"`
+		ReasonOffTopic         string `toml:"reason_off_topic"`
+		ReasonLowEffort        string `toml:"reason_low_effort"`
+		ReasonFalseInfo        string `toml:"reason_false_info"`
+		ReviewsApproved        string `toml:"reviews_approved"`
+		UnblockUsage           string `toml:"unblock_usage"`
+		Unblocked              string `toml:"unblocked"`
+		NotBlocked             string `toml:"not_blocked"`
+		BlockedHeader          string `toml:"blocked_header"`
+		BlockedEmpty           string `toml:"blocked_empty"`
+		BtnFixName             string `toml:"btn_fix_name"`
+		BtnFixScore            string `toml:"btn_fix_score"`
+		BtnFixText             string `toml:"btn_fix_text"`
+		BtnDraftSave           string `toml:"btn_draft_save"`
+		BtnDraftResume         string `toml:"btn_draft_resume"`
+		BtnDraftDiscard        string `toml:"btn_draft_discard"`
+		DraftSaved             string `toml:"draft_saved"`
+		DraftEmpty             string `toml:"draft_empty"`
+		DraftFound             string `toml:"draft_found"`
+		DraftRestored          string `toml:"draft_restored"`
+		DraftText              string `toml:"draft_text"`
+		SortNewest             string `toml:"sort_newest"`
+		SortHighest            string `toml:"sort_highest"`
+		SortHelpful            string `toml:"sort_helpful"`
+		TopBest                string `toml:"top_best"`
+		TopWorst               string `toml:"top_worst"`
+		TopNote                Plural `toml:"top_note"`
+		TopEmpty               Plural `toml:"top_empty"`
+		ExportCaption          string `toml:"export_caption"`
+		ExportSent             string `toml:"export_sent"`
+		ExportEmpty            string `toml:"export_empty"`
+		BtnAllReviews          string `toml:"btn_all_reviews"`
+		BtnShare               string `toml:"btn_share"`
+		LinkNotFound           string `toml:"link_not_found"`
+		BtnAddPhoto            string `toml:"btn_add_photo"`
+		BtnRemovePhoto         string `toml:"btn_remove_photo"`
+		BtnNoPhoto             string `toml:"btn_no_photo"`
+		SendPhoto              string `toml:"send_photo"`
+		BtnPhotos              string `toml:"btn_photos"`
+		NoPhotos               string `toml:"no_photos"`
+		VerifyProfUsage        string `toml:"verify_prof_usage"`
+		UnverifyProfUsage      string `toml:"unverify_prof_usage"`
+		ProfVerified           string `toml:"prof_verified"`
+		ProfUnverified         string `toml:"prof_unverified"`
+		NotProfessorAccount    string `toml:"not_professor_account"`
+		BtnReply               string `toml:"btn_reply"`
+		EnterResponse          string `toml:"enter_response"`
+		ResponseSubmitted      string `toml:"response_submitted"`
+		ResponseApproved       string `toml:"response_approved"`
+		ResponseRejected       string `toml:"response_rejected"`
+		ResponseDenied         string `toml:"response_denied"`
+		ProfessorResponse      string `toml:"professor_response"`
+		SemesterWinter         string `toml:"semester_winter"`
+		SemesterSummer         string `toml:"semester_summer"`
+		ArchivedLabel          string `toml:"archived_label"`
+		RenameProfUsage        string `toml:"rename_prof_usage"`
+		MergeProfUsage         string `toml:"merge_prof_usage"`
+		ProfRenamed            string `toml:"prof_renamed"`
+		ProfMerged             string `toml:"prof_merged"`
+		ProfNameTaken          string `toml:"prof_name_taken"`
+		ProfUnknown            string `toml:"prof_unknown"`
+		SameProfessor          string `toml:"same_professor"`
+		BtnSubscribe           string `toml:"btn_subscribe"`
+		BtnUnsubscribe         string `toml:"btn_unsubscribe"`
+		BtnOpenProfessor       string `toml:"btn_open_professor"`
+		Subscribed             string `toml:"subscribed"`
+		Unsubscribed           string `toml:"unsubscribed"`
+		NewReviewNotice        string `toml:"new_review_notice"`
+		PageTruncated          string `toml:"page_truncated"`
+		CardPhoto              string `toml:"card_photo"`
+		AlreadyDecided         string `toml:"already_decided"`
+		QueueEmpty             string `toml:"queue_empty"`
+		QueueHeader            string `toml:"queue_header"`
+		QueueShownFirst        string `toml:"queue_shown_first"`
+		BtnApproveShown        string `toml:"btn_approve_shown"`
+		Waiting                string `toml:"waiting"`
+		BtnApproveAuthor       string `toml:"btn_approve_author"`
+		ApprovedShown          string `toml:"approved_shown"`
+		ApprovedAuthor         string `toml:"approved_author"`
+		NothingToApprove       string `toml:"nothing_to_approve"`
+		AgeDays                string `toml:"age_days"`
+		AgeHours               string `toml:"age_hours"`
+		AgeMinutes             string `toml:"age_minutes"`
+		ReportHeader           string `toml:"report_header"`
+		ReportedBy             string `toml:"reported_by"`
+		BtnRemove              string `toml:"btn_remove"`
+		BtnKeep                string `toml:"btn_keep"`
+		ReportKept             string `toml:"report_kept"`
+		ReportRemoved          string `toml:"report_removed"`
+		ResponseHeader         string `toml:"response_header"`
+		ResponseFrom           string `toml:"response_from"`
+		ResponseLabel          string `toml:"response_label"`
+		ReviewNumber           string `toml:"review_number"`
+		BtnApproveResponse     string `toml:"btn_approve_response"`
+		BtnRejectResponse      string `toml:"btn_reject_response"`
+		ResponseDecided        string `toml:"response_decided"`
+		ResponseApprovedAdmin  string `toml:"response_approved_admin"`
+		ResponseRejectedAdmin  string `toml:"response_rejected_admin"`
+		SimilarHeader          string `toml:"similar_header"`
+		SimilarSameAuthor      string `toml:"similar_same_author"`
+		SimilarOtherAuthor     string `toml:"similar_other_author"`
+		ReviewStatusPending    string `toml:"review_status_pending"`
+		ReviewStatusApproved   string `toml:"review_status_approved"`
+		ReviewStatusRejected   string `toml:"review_status_rejected"`
+		ReviewStatusRemoved    string `toml:"review_status_removed"`
+		ReviewStatusReplaced   string `toml:"review_status_replaced"`
+		ArchiveSemesterUsage   string `toml:"archive_semester_usage"`
+		ArchiveSemesterList    string `toml:"archive_semester_list"`
+		ArchiveSemesterItem    string `toml:"archive_semester_item"`
+		ArchiveSemesterCurrent string `toml:"archive_semester_current"`
+		ArchiveSemesterDone    string `toml:"archive_semester_done"`
+		StatsHeader            string `toml:"stats_header"`
+		StatsApproval          string `toml:"stats_approval"`
+		StatsLatency           string `toml:"stats_latency"`
+		StatsBuckets           string `toml:"stats_buckets"`
+		StatsTopModerators     string `toml:"stats_top_moderators"`
 	} `toml:"rating"`
+	Whois struct {
+		AdminOnly        string `toml:"admin_only"`
+		Usage            string `toml:"usage"`
+		Unknown          string `toml:"unknown"`
+		Header           string `toml:"header"`
+		FirstSeen        string `toml:"first_seen"`
+		Verified         string `toml:"verified"`
+		Pending          string `toml:"pending"`
+		LastVerification string `toml:"last_verification"`
+		Trusted          string `toml:"trusted"`
+		Language         string `toml:"language"`
+		Warnings         string `toml:"warnings"`
+		ReviewBlocked    string `toml:"review_blocked"`
+		Yes              string `toml:"yes"`
+		No               string `toml:"no"`
+		ActionsHeader    string `toml:"actions_header"`
+		NoActions        string `toml:"no_actions"`
+		Until            string `toml:"until"`
+		Undone           string `toml:"undone"`
+		Mute             string `toml:"mute"`
+		Unmute           string `toml:"unmute"`
+		Ban              string `toml:"ban"`
+		SpamBan          string `toml:"spam_ban"`
+		Kick             string `toml:"kick"`
+		ExpiredWarnings  string `toml:"expired_warnings"`
+		Approve          string `toml:"approve"`
+		Reject           string `toml:"reject"`
+		Block            string `toml:"block"`
+		Delete           string `toml:"delete"`
+		Dismiss          string `toml:"dismiss"`
+		Unban            string `toml:"unban"`
+		Unblock          string `toml:"unblock"`
+		RulesAccepted    string `toml:"rules_accepted"`
+	} `toml:"whois"`
+	Roles struct {
+		OwnerOnly    string `toml:"owner_only"`
+		Usage        string `toml:"usage"`
+		DemoteUsage  string `toml:"demote_usage"`
+		Promoted     string `toml:"promoted"`
+		Demoted      string `toml:"demoted"`
+		NotFound     string `toml:"not_found"`
+		ListHeader   string `toml:"list_header"`
+		ListEmpty    string `toml:"list_empty"`
+		ReviewDenied string `toml:"review_denied"`
+	} `toml:"roles"`
+	Report struct {
+		ReplyRequired   string `toml:"reply_required"`
+		CannotReport    string `toml:"cannot_report"`
+		RateLimited     string `toml:"rate_limited"`
+		AlreadyReported string `toml:"already_reported"`
+		Sent            string `toml:"sent"`
+		Reason          string `toml:"reason"`
+		ActionDenied    string `toml:"action_denied"`
+	} `toml:"report"`
+
+	lang Lang
 }
 
 // Localizer manages translations
@@ -136,15 +548,17 @@ type Localizer struct {
 	mu          sync.RWMutex
 	messages    map[Lang]*Messages
 	defaultLang Lang
+	embedded    fs.FS // Locales built into the binary, files on disk override them key by key
 }
 
 var globalLocalizer *Localizer
 
-// Init initializes localization
-func Init(defaultLang Lang) error {
+// Init initializes localization, keys in locales/ files on disk take precedence over the embedded ones
+func Init(defaultLang Lang, embedded fs.FS) error {
 	globalLocalizer = &Localizer{
 		messages:    make(map[Lang]*Messages),
 		defaultLang: defaultLang,
+		embedded:    embedded,
 	}
 
 	// Load all languages
@@ -154,28 +568,52 @@ func Init(defaultLang Lang) error {
 			logrus.WithError(err).WithField("lang", lang).Warn("Failed to load language")
 		}
 	}
+	if _, ok := globalLocalizer.messages[defaultLang]; !ok {
+		return fmt.Errorf("default language %s is not available", defaultLang)
+	}
 
 	return nil
 }
 
-// loadLanguage loads a language file
+// loadLanguage loads the embedded language file and applies the keys of the file on disk over it
 func (l *Localizer) loadLanguage(lang Lang) error {
 	path := fmt.Sprintf("locales/%s.toml", lang)
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return err
+	var msgs Messages
+	var sources []string
+	if l.embedded != nil {
+		data, err := fs.ReadFile(l.embedded, path)
+		if err == nil {
+			if err := toml.Unmarshal(data, &msgs); err != nil {
+				return err
+			}
+			sources = append(sources, "embedded")
+		}
 	}
 
-	var msgs Messages
-	if err := toml.Unmarshal(data, &msgs); err != nil {
+	data, err := os.ReadFile(path)
+	switch {
+	case err != nil && len(sources) == 0:
 		return err
+	case err == nil:
+		// a file that does not parse must not leave the embedded messages half overwritten
+		var check Messages
+		if err := toml.Unmarshal(data, &check); err != nil {
+			if len(sources) == 0 {
+				return err
+			}
+			logrus.WithError(err).WithField("lang", lang).Warn("Failed to parse language file on disk, using the embedded one")
+			break
+		}
+		_ = toml.Unmarshal(data, &msgs)
+		sources = append(sources, "disk")
 	}
+	msgs.lang = lang
 
 	l.mu.Lock()
 	l.messages[lang] = &msgs
 	l.mu.Unlock()
 
-	logrus.WithField("lang", lang).Info("Language loaded")
+	logrus.WithFields(logrus.Fields{"lang": lang, "source": strings.Join(sources, "+")}).Info("Language loaded")
 	return nil
 }
 
@@ -0,0 +1,58 @@
+package i18n
+
+// Plural holds the forms of a message about a number by CLDR plural category, written in TOML as
+// key = { one = "%d review", other = "%d reviews" }; a missing category falls back to other
+type Plural map[string]string
+
+// CLDR plural categories used by the supported languages
+const (
+	One   = "one"
+	Few   = "few"
+	Many  = "many"
+	Other = "other"
+)
+
+// PluralCategory returns the CLDR plural category of a whole number in a language
+func PluralCategory(lang Lang, n int) string {
+	if n < 0 {
+		n = -n
+	}
+	mod10, mod100 := n%10, n%100
+	switch lang {
+	case EN:
+		if n == 1 {
+			return One
+		}
+		return Other
+	case PL:
+		switch {
+		case n == 1:
+			return One
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return Few
+		}
+		return Many
+	case RU, UK, BE:
+		switch {
+		case mod10 == 1 && mod100 != 11:
+			return One
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return Few
+		}
+		return Many
+	}
+	return Other
+}
+
+// Form returns the form of the message for n in a language
+func (p Plural) Form(lang Lang, n int) string {
+	if form, ok := p[PluralCategory(lang, n)]; ok {
+		return form
+	}
+	return p[Other]
+}
+
+// Lang returns the language of the messages
+func (m *Messages) Lang() Lang {
+	return m.lang
+}
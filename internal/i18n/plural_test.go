@@ -0,0 +1,29 @@
+package i18n
+
+import "testing"
+
+func TestPluralCategory(t *testing.T) {
+	tests := []struct {
+		n      int
+		ru, pl string
+	}{
+		{1, One, One},
+		{2, Few, Few},
+		{5, Many, Many},
+		{11, Many, Many},
+		{12, Many, Many},
+		{14, Many, Many},
+		{21, One, Many},
+		{22, Few, Few},
+		{25, Many, Many},
+		{112, Many, Many},
+	}
+	for _, tt := range tests {
+		if got := PluralCategory(RU, tt.n); got != tt.ru {
+			t.Errorf("PluralCategory(RU, %d) = %q, want %q", tt.n, got, tt.ru)
+		}
+		if got := PluralCategory(PL, tt.n); got != tt.pl {
+			t.Errorf("PluralCategory(PL, %d) = %q, want %q", tt.n, got, tt.pl)
+		}
+	}
+}
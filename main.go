@@ -1,9 +1,11 @@
 package main
 
 import (
+	"embed"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"capybot/internal/bot"
@@ -18,6 +20,11 @@ import (
 // Version is the current bot version
 const Version = "1.2.5"
 
+// locales are the translations built into the binary, overridden by files in locales/ next to it
+//
+//go:embed locales/*.toml
+var locales embed.FS
+
 // GitHubRepo is the repository URL
 const GitHubRepo = "https://github.com/arsmotorin/capybot"
 
@@ -28,10 +35,12 @@ type Handler struct {
 	quiz           core.QuizInterface
 	blacklist      core.BlacklistInterface
 	adminChatID    int64
-	violations     map[int64]int
 	adminHandler   core.AdminHandlerInterface
 	featureHandler core.FeatureHandlerInterface
 	ratingHandler  *bot.RatingHandler
+	scheduler      *bot.Scheduler
+	tasks          *bot.TaskQueue
+	api            *bot.RatingsAPI
 }
 
 func main() {
@@ -46,7 +55,7 @@ func main() {
 	if lang, ok := langMap[os.Getenv("DEFAULT_LANG")]; ok {
 		defaultLang = lang
 	}
-	if err := i18n.Init(defaultLang); err != nil {
+	if err := i18n.Init(defaultLang, locales); err != nil {
 		logrus.WithError(err).Fatal("Failed to initialize i18n")
 	}
 
@@ -60,7 +69,7 @@ func main() {
 	}
 	b, err := tb.NewBot(tb.Settings{
 		Token:  token,
-		Poller: &tb.LongPoller{Timeout: 10 * time.Second},
+		Poller: &tb.LongPoller{Timeout: 10 * time.Second, AllowedUpdates: tb.AllowedUpdates},
 	})
 	if err != nil {
 		logrus.WithError(err).Fatal("bot create failed")
@@ -71,14 +80,62 @@ func main() {
 	b.Start()
 }
 
+// adminLang reads the language of the review cards in the admin chat from ADMIN_LANG, Russian by default
+func adminLang() i18n.Lang {
+	switch lang := i18n.Lang(os.Getenv("ADMIN_LANG")); lang {
+	case i18n.PL, i18n.EN, i18n.RU, i18n.UK, i18n.BE:
+		return lang
+	case "":
+	default:
+		logrus.WithField("value", lang).Warn("ADMIN_LANG invalid, using Russian")
+	}
+	return i18n.RU
+}
+
+// reviewLimits reads the review throttling from REVIEW_COOLDOWN (like 10m) and REVIEW_DAILY_LIMIT, 0 disables a limit
+func reviewLimits() bot.ReviewLimits {
+	limits := bot.DefaultReviewLimits
+	if v := os.Getenv("REVIEW_COOLDOWN"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			limits.Cooldown = d
+		} else {
+			logrus.WithField("value", v).Warn("REVIEW_COOLDOWN invalid, using the default")
+		}
+	}
+	if v := os.Getenv("REVIEW_DAILY_LIMIT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			limits.Daily = n
+		} else {
+			logrus.WithField("value", v).Warn("REVIEW_DAILY_LIMIT invalid, using the default")
+		}
+	}
+	return limits
+}
+
 // NewHandler wires dependencies
 func NewHandler(b *tb.Bot, adminChatID int64) *Handler {
-	violations := make(map[int64]int)
 	state := core.NewState()
 	quiz := bot.DefaultQuiz()
 	black := bot.NewBlacklist("blacklist.json")
+	settings := bot.NewSettingsStore("data/settings.json")
+	quizzes := bot.NewQuizStore("data/quizzes.json")
+	trusted := bot.NewTrustStore("data/trusted.json")
+	audit := bot.NewAuditStore("data/verify_log.json")
+	strikes := bot.NewStrikeStore("data/strikes.json", "data/violations.json")
+	actions := bot.NewActionLog("data/actions.json")
+	ratings := bot.NewRatingStore("data/ratings.json")
+	roles := bot.NewRoleStore("data/roles.json", os.Getenv("BOT_OWNERS"))
+	digest := bot.NewDigestStore("data/digest.json")
+	tasks := bot.NewTaskQueue("data/tasks.json")
+	users := bot.NewUserIndex("data/users.json")
+	domains := bot.NewDomainStore("data/domains.json")
+	namePatterns := bot.NewNamePatternStore("data/name_patterns.json")
+	spamModel := bot.NewSpamModel("data/spam_model.json")
+	remote := bot.NewRemoteBlacklist(os.Getenv("BLACKLIST_URL"), black)
+	spamCorpus := bot.NewSpamCorpus("data/spam_corpus.json")
+	moderation := bot.NewModerationProvider(os.Getenv("MODERATION_PROVIDER"), os.Getenv("MODERATION_API_KEY"))
 
-	h := &Handler{bot: b, state: state, quiz: quiz, blacklist: black, adminChatID: adminChatID, violations: violations}
+	h := &Handler{bot: b, state: state, quiz: quiz, blacklist: black, adminChatID: adminChatID}
 
 	// Buttons
 	btns := struct{ Student, Guest, Ads tb.InlineButton }{
@@ -88,16 +145,38 @@ func NewHandler(b *tb.Bot, adminChatID int64) *Handler {
 	}
 
 	// Admin
-	adminHandler := bot.NewAdminHandler(b, black, adminChatID, violations)
+	adminHandler := bot.NewAdminHandler(b, black, adminChatID, strikes, actions, settings, domains, namePatterns, spamModel, remote, roles, digest, tasks, users, trusted, state)
 	h.adminHandler = adminHandler
 
 	// Feature
-	featureHandler := bot.NewFeatureHandler(b, state, quiz, black, adminChatID, violations, adminHandler, btns)
+	featureHandler := bot.NewFeatureHandler(b, state, quiz, black, adminChatID, adminHandler, btns, settings, quizzes, trusted, audit, strikes, actions, ratings, domains, namePatterns, spamModel, spamCorpus, moderation, tasks, users)
 	h.featureHandler = featureHandler
 
+	// Scheduled jobs
+	scheduler := bot.NewScheduler(time.Minute)
+	scheduler.Every("night_mode", time.Minute, featureHandler.NightModeTick)
+	scheduler.Every("spam_model", time.Minute, spamModel.Flush)
+	scheduler.Every("user_index", time.Minute, users.Flush)
+	// Timed tasks lift temporary actions on time, the sweep catches ones recorded before they existed
+	scheduler.Every("expire_actions", time.Hour, adminHandler.ExpireActions)
+	scheduler.Every("admin_cache", bot.AdminRefreshPeriod, adminHandler.RefreshAdmins)
+	scheduler.Every("admin_digest", time.Minute, adminHandler.SendDigests)
+	if remote != nil {
+		scheduler.Every("remote_blacklist", bot.RemoteSyncPeriod, remote.Tick)
+	}
+	h.scheduler = scheduler
+	h.tasks = tasks
+
 	// Rating
-	ratingHandler := bot.NewRatingHandler(b, adminChatID, adminHandler)
+	pages := bot.NewTelegraphPages("data/telegraph.json", os.Getenv("TELEGRAPH_TOKEN"), ratings)
+	if pages != nil {
+		scheduler.Every("telegraph_pages", bot.TelegraphPeriod, pages.Tick)
+	}
+	ratingHandler := bot.NewRatingHandler(b, adminChatID, adminLang(), adminHandler, ratings, reviewLimits(), pages, bot.NewSubscriptions("data/subscriptions.json"))
 	h.ratingHandler = ratingHandler
+	featureHandler.OnStartPayload(bot.ProfLinkPrefix, ratingHandler.HandleProfessorLink)
+	feed, _ := strconv.ParseBool(os.Getenv("RATINGS_FEED"))
+	h.api = bot.NewRatingsAPI(ratings, os.Getenv("RATINGS_API_ADDR"), os.Getenv("RATINGS_API_TOKEN"), "https://t.me/"+b.Me.Username, feed)
 
 	return h
 }
@@ -106,20 +185,95 @@ func NewHandler(b *tb.Bot, adminChatID int64) *Handler {
 func (h *Handler) Register() {
 	h.bot.Handle(tb.OnUserJoined, h.featureHandler.HandleUserJoined)
 	h.bot.Handle(tb.OnUserLeft, h.featureHandler.HandleUserLeft)
+	h.bot.Handle(tb.OnChatJoinRequest, h.featureHandler.HandleJoinRequest)
+	h.bot.Handle(tb.OnChatMember, h.adminHandler.HandleChatMember)
 	h.bot.Handle("/rate", h.ratingHandler.HandleRate)
 	h.bot.Handle("/ratings", h.ratingHandler.HandleRatings)
+	h.bot.Handle("/myreviews", h.ratingHandler.HandleMyReviews)
+	h.bot.Handle("/top", h.ratingHandler.HandleTop)
+	h.bot.Handle("/addprof", h.ratingHandler.HandleAddProfessor)
+	h.bot.Handle("/delprof", h.ratingHandler.HandleDelProfessor)
+	h.bot.Handle("/pending", h.ratingHandler.HandlePending)
+	h.bot.Handle("/unblockrating", h.ratingHandler.HandleUnblockRating)
+	h.bot.Handle("/exportreviews", h.ratingHandler.HandleExportReviews)
+	h.bot.Handle("/ratingstats", h.ratingHandler.HandleRatingStats)
+	h.bot.Handle("/verifyprof", h.ratingHandler.HandleVerifyProfessor)
+	h.bot.Handle("/unverifyprof", h.ratingHandler.HandleUnverifyProfessor)
+	h.bot.Handle("/archivesemester", h.ratingHandler.HandleArchiveSemester)
+	h.bot.Handle("/renameprof", h.ratingHandler.HandleRenameProfessor)
+	h.bot.Handle("/mergeprof", h.ratingHandler.HandleMergeProfessor)
 	h.ratingHandler.RegisterHandlers(h.bot)
 
 	h.featureHandler.RegisterQuizHandlers(h.bot)
 	h.bot.Handle("/banword", h.adminHandler.HandleBan)
 	h.bot.Handle("/unbanword", h.adminHandler.HandleUnban)
 	h.bot.Handle("/listbanword", h.adminHandler.HandleListBan)
+	h.bot.Handle(&tb.InlineButton{Unique: "banlist_page"}, h.adminHandler.HandleListBanPage)
+	h.bot.Handle(&tb.InlineButton{Unique: "mod_confirm"}, h.adminHandler.HandleConfirmAction)
+	h.bot.Handle(&tb.InlineButton{Unique: "mod_cancel"}, h.adminHandler.HandleConfirmAction)
+	h.bot.Handle("/importbanwords", h.adminHandler.HandleImportBan)
+	h.bot.Handle("/exportbanwords", h.adminHandler.HandleExportBan)
+	h.bot.Handle("/syncbanwords", h.adminHandler.HandleSyncBan)
 	h.bot.Handle("/spamban", h.adminHandler.HandleSpamBan)
+	h.bot.Handle("/warn", h.adminHandler.HandleWarn)
+	h.bot.Handle("/unwarn", h.adminHandler.HandleUnwarn)
+	h.bot.Handle("/mute", h.adminHandler.HandleMute)
+	h.bot.Handle("/unmute", h.adminHandler.HandleUnmute)
+	h.bot.Handle("/ban", h.adminHandler.HandleBanUser)
+	h.bot.Handle("/tban", h.adminHandler.HandleTempBan)
+	h.bot.Handle("/kick", h.adminHandler.HandleKick)
+	h.bot.Handle("/purge", h.adminHandler.HandlePurge)
+	h.bot.Handle("/del", h.adminHandler.HandleDel)
+	h.bot.Handle("/undo", h.adminHandler.HandleUndo)
+	h.bot.Handle("/banlist", h.adminHandler.HandleBanList)
+	h.bot.Handle("/mutelist", h.adminHandler.HandleMuteList)
+	h.bot.Handle(&tb.InlineButton{Unique: "modlist_page"}, h.adminHandler.HandleModListPage)
+	h.bot.Handle(&tb.InlineButton{Unique: "modlist_lift"}, h.adminHandler.HandleModListLift)
+	h.bot.Handle("/report", h.adminHandler.HandleReport)
+	for _, unique := range []string{"report_delete", "report_warn", "report_ban", "report_ignore"} {
+		h.bot.Handle(&tb.InlineButton{Unique: unique}, h.adminHandler.HandleReportAction)
+	}
+	h.bot.Handle("/whois", h.featureHandler.HandleWhois)
+	h.bot.Handle("/promotemod", h.adminHandler.HandlePromoteMod)
+	h.bot.Handle("/demotemod", h.adminHandler.HandleDemoteMod)
+	h.bot.Handle("/mods", h.adminHandler.HandleListMods)
+	h.bot.Handle("/pin", h.adminHandler.HandlePin)
+	h.bot.Handle("/unpin", h.adminHandler.HandleUnpin)
+	h.bot.Handle("/unpinall", h.adminHandler.HandleUnpinAll)
+	h.bot.Handle("/settings", h.adminHandler.HandleSettings)
+	h.bot.Handle("/set", h.adminHandler.HandleSet)
+	h.bot.Handle("/setwelcomemedia", h.adminHandler.HandleSetWelcomeMedia)
+	h.bot.Handle("/bandomain", h.adminHandler.HandleBanDomain)
+	h.bot.Handle("/unbandomain", h.adminHandler.HandleUnbanDomain)
+	h.bot.Handle("/bannamepattern", h.adminHandler.HandleBanNamePattern)
+	h.bot.Handle("/unbannamepattern", h.adminHandler.HandleUnbanNamePattern)
+	h.bot.Handle("/slowmode", h.adminHandler.HandleSlowMode)
+	h.bot.Handle("/addquestion", h.featureHandler.HandleAddQuestion)
+	h.bot.Handle("/delquestion", h.featureHandler.HandleDelQuestion)
+	h.bot.Handle("/listquestions", h.featureHandler.HandleListQuestions)
+	h.bot.Handle("/trust", h.featureHandler.HandleTrust)
+	h.bot.Handle("/untrust", h.featureHandler.HandleUntrust)
+	h.bot.Handle("/verifylog", h.featureHandler.HandleVerifyLog)
+	h.bot.Handle("/testfilter", h.featureHandler.HandleTestFilter)
 	h.bot.Handle("/ping", h.featureHandler.RateLimit(h.featureHandler.HandlePing))
 	h.bot.Handle("/start", h.featureHandler.HandleStart)
 	h.bot.Handle("/version", h.handleVersion)
 	h.bot.Handle(tb.OnText, h.handleTextMessage)
+	h.bot.Handle(tb.OnPhoto, h.handlePhoto)
+	h.bot.Handle(tb.OnVideo, h.featureHandler.FilterMessage)
+	h.bot.Handle(tb.OnVideoNote, h.featureHandler.FilterMessage)
+	h.bot.Handle(tb.OnDocument, h.handleDocument)
+	h.bot.Handle(tb.OnAudio, h.featureHandler.FilterMessage)
+	h.bot.Handle(tb.OnVoice, h.featureHandler.FilterMessage)
+	h.bot.Handle(tb.OnPoll, h.featureHandler.FilterMessage)
+	h.bot.Handle(tb.OnSticker, h.featureHandler.FilterMedia)
+	h.bot.Handle(tb.OnAnimation, h.featureHandler.FilterMedia)
 	h.setBotCommands()
+	h.scheduler.Start()
+	h.tasks.Start()
+	if h.api != nil {
+		h.api.Start()
+	}
 }
 
 // handleVersion returns bot version
@@ -172,6 +326,24 @@ func (h *Handler) handleTextMessage(c tb.Context) error {
 		if err := h.featureHandler.HandlePrivateMessage(c); err != nil {
 			return err
 		}
+	} else if bot.IsReportMention(c.Message()) {
+		return h.adminHandler.HandleReport(c)
+	}
+	return h.featureHandler.FilterMessage(c)
+}
+
+// handlePhoto attaches photos sent in private to a review being written, other photos are filtered
+func (h *Handler) handlePhoto(c tb.Context) error {
+	if h.ratingHandler.HandleRatePhoto(c) {
+		return nil
+	}
+	return h.featureHandler.FilterMessage(c)
+}
+
+// handleDocument routes banword files sent with the import command in the caption, other documents are filtered
+func (h *Handler) handleDocument(c tb.Context) error {
+	if command, _, _ := strings.Cut(c.Message().Caption, " "); command == "/importbanwords" || strings.HasPrefix(command, "/importbanwords@") {
+		return h.adminHandler.HandleImportBan(c)
 	}
 	return h.featureHandler.FilterMessage(c)
 }
@@ -189,6 +361,9 @@ func (h *Handler) setBotCommands() {
 			{Text: "version", Description: msgs.Commands.VersionDesc},
 			{Text: "rate", Description: msgs.Commands.RateDesc},
 			{Text: "ratings", Description: msgs.Commands.RatingsDesc},
+			{Text: "top", Description: msgs.Commands.TopDesc},
+			{Text: "myreviews", Description: msgs.Commands.MyreviewsDesc},
+			{Text: "report", Description: msgs.Commands.ReportDesc},
 		}
 
 		// Set commands with language code